@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/alecthomas/kong"
+	"github.com/fatih/color"
 	"github.com/ibrt/golang-utils/errorz"
 	"github.com/ibrt/golang-utils/filez"
 	"github.com/ibrt/golang-utils/outz"
@@ -24,6 +25,17 @@ const (
 
 var (
 	defaultCLI = NewCLI()
+
+	prefixColors = []color.Attribute{
+		color.FgCyan,
+		color.FgMagenta,
+		color.FgBlue,
+		color.FgYellow,
+		color.FgGreen,
+		color.FgHiCyan,
+		color.FgHiMagenta,
+		color.FgHiBlue,
+	}
 )
 
 // DefaultCLI is a default, shared instance of [*CLI].
@@ -179,17 +191,90 @@ func (c *CLI) Notice(scope string, highlight string, secondary ...string) {
 	fmt.Println()
 }
 
-// Command prints a command.
+// Prefixed prints a line of output of one of several concurrent tasks, prefixed by the task name right-aligned to
+// "width". The prefix color is picked from a fixed palette based on "colorIndex", and stderr lines are dimmed.
+func (c *CLI) Prefixed(prefix string, width int, colorIndex int, isStderr bool, line string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	_, _ = color.New(prefixColors[colorIndex%len(prefixColors)]).Printf("[%v]", alignRight(prefix, width))
+
+	if isStderr {
+		_, _ = c.styles.Secondary().Print(" ", line)
+	} else {
+		_, _ = c.styles.Default().Print(" ", line)
+	}
+
+	fmt.Println()
+}
+
+// Command prints a command. The command and its params are quoted for a POSIX shell, if needed.
 func (c *CLI) Command(cmd string, params ...string) {
 	c.m.Lock()
 	defer c.m.Unlock()
 
 	fmt.Print(IconRunner)
-	fmt.Printf(" %v ", filez.MustRelForDisplay(cmd))
-	_, _ = c.styles.Secondary().Print(strings.Join(params, " "))
+	c.printCommand(cmd, params...)
 	fmt.Println()
 }
 
+// Redirection describes a file redirection of a command, e.g. "> out.txt".
+type Redirection struct {
+	Operator string // e.g. "<", ">", ">>", "2>" or "2>&1"
+	FilePath string // empty if the operator doesn't take a file (e.g. "2>&1")
+}
+
+// RedirectedCommand is like [*CLI.Command] but also prints the given file redirections after the params.
+func (c *CLI) RedirectedCommand(cmd string, params []string, redirections ...*Redirection) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	fmt.Print(IconRunner)
+	c.printCommand(cmd, params...)
+
+	for _, r := range redirections {
+		fmt.Print(" ", r.Operator)
+
+		if r.FilePath != "" {
+			fmt.Print(" ", ShellQuote(filez.MustRelForDisplay(r.FilePath)))
+		}
+	}
+
+	fmt.Println()
+}
+
+// Pipeline prints a pipeline of commands on a single line.
+// Each element of "cmds" contains a command followed by its params.
+func (c *CLI) Pipeline(cmds ...[]string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	fmt.Print(IconRunner)
+
+	for i, cmd := range cmds {
+		if i > 0 {
+			fmt.Print(" |")
+		}
+
+		if len(cmd) > 0 {
+			c.printCommand(cmd[0], cmd[1:]...)
+		}
+	}
+
+	fmt.Println()
+}
+
+func (c *CLI) printCommand(cmd string, params ...string) {
+	quotedParams := make([]string, 0, len(params))
+
+	for _, p := range params {
+		quotedParams = append(quotedParams, ShellQuote(p))
+	}
+
+	fmt.Printf(" %v ", ShellQuote(filez.MustRelForDisplay(cmd)))
+	_, _ = c.styles.Secondary().Print(strings.Join(quotedParams, " "))
+}
+
 // NewTable creates a new table.
 func (c *CLI) NewTable(columnHeaders ...any) table.Table {
 	return table.
@@ -198,7 +283,22 @@ func (c *CLI) NewTable(columnHeaders ...any) table.Table {
 		WithFirstColumnFormatter(c.styles.Warning().SprintfFunc())
 }
 
-// Error prints an error.
+// ErrorDetail is a labeled value describing an error (see [DetailedError]).
+type ErrorDetail struct {
+	Label string
+	Value string
+}
+
+// DetailedError is implemented by errors that can describe themselves with a one-line summary and a list of labeled
+// details, which [*CLI.Error] renders as a structured block.
+type DetailedError interface {
+	error
+	GetSummary() string
+	GetDetails() []*ErrorDetail
+}
+
+// Error prints an error. If the error is (or wraps) a [DetailedError], its summary and details are printed instead of
+// the error message.
 func (c *CLI) Error(err error, debug bool) {
 	c.m.Lock()
 	defer c.m.Unlock()
@@ -207,13 +307,39 @@ func (c *CLI) Error(err error, debug bool) {
 	fmt.Print(IconCollision)
 	fmt.Print(" ")
 	_, _ = c.styles.Highlight().Println("Error")
-	_, _ = c.styles.Error().Println(err.Error())
+
+	if dErr, ok := errorz.As[DetailedError](err); ok {
+		_, _ = c.styles.Error().Println(dErr.GetSummary())
+		c.printErrorDetails(dErr.GetDetails())
+	} else {
+		_, _ = c.styles.Error().Println(err.Error())
+	}
 
 	if debug {
 		fmt.Println(errorz.SDump(err))
 	}
 }
 
+func (c *CLI) printErrorDetails(details []*ErrorDetail) {
+	width := 0
+
+	for _, d := range details {
+		width = max(width, len([]rune(d.Label)))
+	}
+
+	for _, d := range details {
+		for i, line := range strings.Split(strings.TrimRight(d.Value, "\n"), "\n") {
+			if i == 0 {
+				_, _ = c.styles.Secondary().Printf("%-*v", width+1, d.Label+":")
+			} else {
+				fmt.Print(strings.Repeat(" ", width+1))
+			}
+
+			_, _ = c.styles.Default().Println(" " + line)
+		}
+	}
+}
+
 // Recover calls [*CLI.Error] on a recovered panic and exits.
 func (c *CLI) Recover(debug bool) {
 	if err := errorz.MaybeWrapRecover(recover()); err != nil {
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/alecthomas/kong"
+	"github.com/ibrt/golang-utils/errorz"
 	"github.com/ibrt/golang-utils/filez"
 	"github.com/ibrt/golang-utils/fixturez"
 	"github.com/ibrt/golang-utils/outz"
@@ -151,6 +152,20 @@ func (*CLISuite) TestNotice(g *WithT) {
 	g.Expect(errBuf).To(BeEmpty())
 }
 
+func (*CLISuite) TestPrefixed(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	consolez.DefaultCLI.Prefixed("first", 6, 0, false, "out")
+	consolez.DefaultCLI.Prefixed("second", 6, 9, true, "err")
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(
+		"\x1b[36m[.first]\x1b[0m\x1b[0m out\x1b[0m\n" +
+			"\x1b[35m[second]\x1b[0m\x1b[2m err\x1b[0m\n"))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
 func (*CLISuite) TestCommand_Rel(g *WithT) {
 	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
 	defer outz.ResetOutputCapture()
@@ -173,6 +188,31 @@ func (*CLISuite) TestCommand_Abs(g *WithT) {
 	g.Expect(errBuf).To(BeEmpty())
 }
 
+func (*CLISuite) TestRedirectedCommand(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	consolez.DefaultCLI.RedirectedCommand("cmd", []string{"p1"},
+		&consolez.Redirection{Operator: "<", FilePath: "in.txt"},
+		&consolez.Redirection{Operator: ">>", FilePath: filez.MustAbs("my out.txt")},
+		&consolez.Redirection{Operator: "2>&1"})
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(fmt.Sprintf("%v cmd \x1b[2mp1\x1b[0m < in.txt >> 'my out.txt' 2>&1\n", consolez.IconRunner)))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*CLISuite) TestPipeline(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	consolez.DefaultCLI.Pipeline([]string{"cmd1", "p1", "p2"}, []string{filez.MustAbs("cmd2")}, []string{"cmd3", "p3"})
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(fmt.Sprintf("%v cmd1 \x1b[2mp1 p2\x1b[0m | cmd2 \x1b[2m\x1b[0m | cmd3 \x1b[2mp3\x1b[0m\n", consolez.IconRunner)))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
 func (*CLISuite) TestNewTable(g *WithT) {
 	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
 	defer outz.ResetOutputCapture()
@@ -206,6 +246,39 @@ func (*CLISuite) TestError_DebugTrue(g *WithT) {
 	g.Expect(errBuf).To(BeEmpty())
 }
 
+type testDetailedError struct {
+	// intentionally empty
+}
+
+func (*testDetailedError) Error() string {
+	return "test error"
+}
+
+func (*testDetailedError) GetSummary() string {
+	return "test summary"
+}
+
+func (*testDetailedError) GetDetails() []*consolez.ErrorDetail {
+	return []*consolez.ErrorDetail{
+		{Label: "Key", Value: "value"},
+		{Label: "Long Key", Value: "first\nsecond\n"},
+	}
+}
+
+func (*CLISuite) TestError_Detailed(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	consolez.DefaultCLI.Error(errorz.Wrap(&testDetailedError{}), false)
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(fmt.Sprintf("\n%v Error\ntest summary\n", consolez.IconCollision) +
+		"Key:      value\n" +
+		"Long Key: first\n" +
+		"          second\n"))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
 func (*CLISuite) TestRecover(g *WithT) {
 	c := consolez.NewCLI().
 		SetStyles(outz.DefaultStyles).
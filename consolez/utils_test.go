@@ -28,3 +28,12 @@ func (*UtilsSuite) TestTruncateLeft(g *WithT) {
 	g.Expect(truncateLeft("abcd", 6)).To(Equal("abcd"))
 	g.Expect(truncateLeft("abcdef", 4)).To(Equal("...f"))
 }
+
+func (*UtilsSuite) TestShellQuote(g *WithT) {
+	g.Expect(ShellQuote("")).To(Equal("''"))
+	g.Expect(ShellQuote("./a/b-c_d@e%f+g=h:i,j")).To(Equal("./a/b-c_d@e%f+g=h:i,j"))
+	g.Expect(ShellQuote("a b")).To(Equal("'a b'"))
+	g.Expect(ShellQuote("it's")).To(Equal(`'it'\''s'`))
+	g.Expect(ShellQuote("$HOME")).To(Equal("'$HOME'"))
+	g.Expect(ShellQuote("***")).To(Equal("'***'"))
+}
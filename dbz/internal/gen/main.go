@@ -28,6 +28,7 @@ func main() {
 		"--quiet", "--depth", "1", "--branch", gtz.GoToolSQLCGenGo.GetVersion(),
 		fmt.Sprintf("https://%v", gtz.GoToolSQLCGenGo.GetPackage())).
 		SetDir(dirPath).
+		SetRetry(shellz.NewRetryPolicy(3)).
 		MustRun()
 
 	consolez.DefaultCLI.Notice("dbz-gen", "merging templates...")
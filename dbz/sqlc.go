@@ -11,6 +11,7 @@ import (
 
 	"github.com/ibrt/golang-dev/dbz/internal/assets"
 	"github.com/ibrt/golang-dev/gtz"
+	"github.com/ibrt/golang-dev/shellz"
 )
 
 // SQLCConfig describes the SQLC configuration.
@@ -286,3 +287,10 @@ func (c *SQLCGenerator) MustGenerate() {
 	gtz.GoToolSQLC.MustRun("vet", "-f", c.params.GetConfigFilePath())
 	gtz.GoToolSQLC.MustRun("generate", "-f", c.params.GetConfigFilePath())
 }
+
+// GetSQLCRequirements returns the requirements of [*SQLCGenerator], for use with [shellz.Require] or [shellz.Doctor].
+func GetSQLCRequirements() []*shellz.Requirement {
+	return []*shellz.Requirement{
+		gtz.GetGoRequirement(),
+	}
+}
@@ -184,3 +184,26 @@ func NewDockerComposeConfigHealthCheckShell(format string, a ...any) *ct.HealthC
 		},
 	}
 }
+
+// GetRequirements returns the requirements of the Docker Compose workflows, for use with [shellz.Require] or
+// [shellz.Doctor]: the Docker CLI, a reachable Docker daemon, and the Docker Compose plugin.
+func GetRequirements() []*shellz.Requirement {
+	return []*shellz.Requirement{
+		{
+			Cmd:  "docker",
+			Hint: "install Docker from https://docs.docker.com/get-docker/",
+		},
+		{
+			Name:  "docker daemon",
+			Check: shellz.NewCommand("docker", "info", "--format", "{{.ServerVersion}}"),
+			Hint:  "start the Docker daemon (e.g. Docker Desktop), and check that the current user can access it",
+		},
+		{
+			Name:          "docker compose",
+			Cmd:           "docker",
+			MinVersion:    "2.0.0",
+			VersionParams: []string{"compose", "version"},
+			Hint:          "install the Docker Compose plugin from https://docs.docker.com/compose/install/",
+		},
+	}
+}
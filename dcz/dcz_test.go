@@ -47,6 +47,7 @@ func (*Suite) TestDockerCompose(g *WithT) {
 	g.Expect(dc.GetCommand().GetParams()).To(HaveExactElements(
 		"compose", "--ansi", "never", "--progress", "plain", "-f", "-"))
 	g.Eventually(gbytes.BufferReader(dc.GetCommand().GetIn())).Should(gbytes.Say("name: test\nservices: {}\n"))
+	g.Expect(dc.GetCommand().GetProcessGroup()).To(BeFalse())
 
 	dc = dc.WithProjectName("projectName")
 	g.Expect(dc.GetProjectName()).To(Equal("projectName"))
@@ -128,3 +129,13 @@ func (*Suite) TestNewDockerComposeConfigHealthCheck(g *WithT) {
 			},
 		}))
 }
+
+func (*Suite) TestGetRequirements(g *WithT) {
+	requirements := dcz.GetRequirements()
+	g.Expect(requirements).To(HaveLen(3))
+	g.Expect(requirements[0].GetName()).To(Equal("docker"))
+	g.Expect(requirements[1].GetName()).To(Equal("docker daemon"))
+	g.Expect(requirements[1].Check.String()).To(Equal("docker info --format '{{.ServerVersion}}'"))
+	g.Expect(requirements[2].GetName()).To(Equal("docker compose"))
+	g.Expect(requirements[2].MinVersion).To(Equal("2.0.0"))
+}
@@ -6,10 +6,12 @@ require (
 	github.com/alecthomas/kong v1.6.0
 	github.com/axw/gocov v1.2.1
 	github.com/compose-spec/compose-go v1.20.2
+	github.com/creack/pty v1.1.24
 	github.com/fatih/color v1.18.0
 	github.com/ibrt/golang-utils v0.12.0
 	github.com/jackc/pgx/v5 v5.7.1
 	github.com/jackc/tern/v2 v2.3.0
+	github.com/mattn/go-isatty v0.0.20
 	github.com/onsi/gomega v1.36.1
 	github.com/rodaine/table v1.3.0
 	go.uber.org/mock v0.5.0
@@ -31,7 +33,6 @@ require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-shellwords v1.0.12 // indirect
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/mapstructure v1.5.0 // indirect
@@ -2,9 +2,11 @@
 package gtz
 
 import (
-	"bytes"
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +33,10 @@ var (
 	GoToolStaticCheck = NewGoTool("honnef.co/go/tools", "cmd/staticcheck", "2024.1.1")
 )
 
+var (
+	goModVersionRegexp = regexp.MustCompile(`(?m)^go\s+(\S+)\s*$`)
+)
+
 // GoTool describes a Go tool.
 type GoTool struct {
 	m              *sync.Mutex
@@ -76,9 +82,55 @@ func (t *GoTool) GetArgument() string {
 	return fmt.Sprintf("%v%v@%v", t.pkg, t.path, t.getVersion())
 }
 
-// GetCommand returns a [*shellz.Command] for this Go tool.
+// GetCommand returns a [*shellz.Command] that runs this Go tool using "go run". Unless the command is run by a simulated
+// executor (see [shellz.IsSimulated]), pinned tools are installed in [DefaultGoToolCache] (unless it is nil), using the
+// dir and environment of the command, and their binary is executed directly instead: failures to install the tool are
+// returned as the error of the command. Tools at version "latest" always use "go run", as do
+// [*shellz.Command.Exec] and commands wrapped to apply resource limits.
 func (t *GoTool) GetCommand() *shellz.Command {
-	return shellz.NewCommand("go", "run").AddParams(t.GetArgument())
+	arg := t.GetArgument()
+
+	return shellz.NewCommand("go", "run", arg).
+		Use(t.newInstallMiddleware(arg))
+}
+
+// newInstallMiddleware returns a [shellz.Middleware] that replaces "go run" with the binary of the tool installed in
+// [DefaultGoToolCache] (see [*GoTool.GetCommand]).
+func (t *GoTool) newInstallMiddleware(arg string) shellz.Middleware {
+	return func(call *shellz.ExecutorCall, next shellz.ExecutorHandler) *shellz.ExecutorResult {
+		cache := DefaultGoToolCache
+
+		if cache == nil || !isGoRunCall(call, arg) || t.GetVersion() == "latest" {
+			return next(call)
+		}
+
+		binFilePath, err := cache.install(t, call.Command)
+		if err != nil {
+			return &shellz.ExecutorResult{Err: errorz.Wrap(err, errorz.Errorf("cannot install Go tool %q", t.GetPackage()))}
+		}
+
+		call.Cmd.Path = binFilePath
+		call.Cmd.Args = append([]string{binFilePath}, call.Cmd.Args[3:]...)
+		return next(call)
+	}
+}
+
+// isGoRunCall returns true if the call starts a process for a "go run" command with the given argument, using an
+// executor that is not simulated (so that fake executors and dry-run mode see the "go run" command).
+func isGoRunCall(call *shellz.ExecutorCall, arg string) bool {
+	switch call.Method {
+	case shellz.ExecutorMethodExecCmdRun,
+		shellz.ExecutorMethodExecCmdOutput,
+		shellz.ExecutorMethodExecCmdCombinedOutput,
+		shellz.ExecutorMethodExecCmdStart:
+		if shellz.IsSimulated(call.Command.GetExecutor()) {
+			return false
+		}
+
+		return len(call.Cmd.Args) >= 3 && slices.Equal(call.Cmd.Args[:3], []string{"go", "run", arg})
+	default:
+		return false
+	}
 }
 
 // MustRun runs the Go tool.
@@ -93,8 +145,13 @@ func (t *GoTool) getVersion() string {
 
 	func() {
 		defer func() { recover() }()
-		out := shellz.NewCommand("go", "list", "-m", t.pkg).SetEcho(false).MustCombinedOutputString()
-		t.currentVersion = strings.TrimSpace(strings.TrimPrefix(out, t.pkg))
+		lines := shellz.NewCommand("go", "list", "-m", t.pkg).
+			SetEcho(false).
+			MustOutputLines(false, &shellz.OutputLinesOptions{TrimSpace: true, SkipEmpty: true})
+
+		if len(lines) > 0 {
+			t.currentVersion = strings.TrimSpace(strings.TrimPrefix(lines[len(lines)-1], t.pkg))
+		}
 	}()
 
 	if t.currentVersion == "" {
@@ -129,6 +186,7 @@ func MustRunGoChecks(params *GoChecksParams) {
 	shellz.NewCommand("go", "build", "-v").
 		AddParamsIfTrue(len(params.BuildTags) > 0, fmt.Sprintf("-tags=%v", strings.Join(params.BuildTags, ","))).
 		AddParams(params.AllPackages...).
+		SetCaptureLimit(shellz.DefaultCaptureLimit).
 		MustRun()
 
 	consolez.DefaultCLI.Notice("go-checks", "linting...")
@@ -141,6 +199,7 @@ func MustRunGoChecks(params *GoChecksParams) {
 
 	shellz.NewCommand("go", "vet").
 		AddParams(params.AllPackages...).
+		SetCaptureLimit(shellz.DefaultCaptureLimit).
 		MustRun()
 
 	GoToolStaticCheck.
@@ -212,7 +271,7 @@ func MustRunGoTests(params *GoTestsParams) {
 	consolez.NewCoveragePrinter().Print(jsonz.MustUnmarshal[*consolez.Coverage](coverageJSON))
 
 	if params.OpenCoverage {
-		openGoCoverage(params, coverageJSON)
+		openGoCoverage(params)
 	}
 }
 
@@ -232,32 +291,26 @@ func processGoCoverage(params *GoTestsParams) []byte {
 		0777, 0666,
 		strings.Join(coverageOutLines, "\n"))
 
-	coverageJSON := GoToolGoCov.
+	GoToolGoCov.
 		GetCommand().
 		AddParams("convert", filepath.Join(params.CoverageDirPath, "coverage.out")).
-		MustOutput(false)
-
-	filez.MustWriteFile(
-		filepath.Join(params.CoverageDirPath, "coverage.json"),
-		0777, 0666,
-		coverageJSON)
+		SetStdoutFile(filepath.Join(params.CoverageDirPath, "coverage.json"), false).
+		SetEcho(false).
+		MustRun()
 
-	return coverageJSON
+	return filez.MustReadFile(filepath.Join(params.CoverageDirPath, "coverage.json"))
 }
 
-func openGoCoverage(params *GoTestsParams, coverageJSON []byte) {
+func openGoCoverage(params *GoTestsParams) {
 	consolez.DefaultCLI.Notice("go-tests", "opening coverage...")
 
-	coverageHTML := GoToolGoCovHTML.
+	GoToolGoCovHTML.
 		GetCommand().
 		AddParams("-t", "golang").
-		SetIn(bytes.NewReader(coverageJSON)).
-		MustOutput(false)
-
-	filez.MustWriteFile(
-		filepath.Join(params.CoverageDirPath, "coverage.html"),
-		0777, 0666,
-		coverageHTML)
+		SetStdinFile(filepath.Join(params.CoverageDirPath, "coverage.json")).
+		SetStdoutFile(filepath.Join(params.CoverageDirPath, "coverage.html"), false).
+		SetEcho(false).
+		MustRun()
 
 	shellz.NewCommand("open").
 		AddParams(filepath.Join(params.CoverageDirPath, "coverage.html")).
@@ -276,3 +329,38 @@ func MustGenerateShortVersion() string {
 func MustGenerateLongVersion() string {
 	return fmt.Sprintf("%v-%v", time.Now().UTC().Format("20060102T150405"), MustGenerateShortVersion())
 }
+
+// GetGoRequirement returns a [*shellz.Requirement] for Go, at least at the version declared by the "go.mod" file in the
+// current working directory (if any).
+func GetGoRequirement() *shellz.Requirement {
+	return &shellz.Requirement{
+		Cmd:           "go",
+		MinVersion:    getGoModVersion(),
+		VersionParams: []string{"version"},
+		Hint:          "install Go from https://go.dev/dl/",
+	}
+}
+
+// GetRequirements returns the requirements of the Go workflows, for use with [shellz.Require] or [shellz.Doctor].
+func GetRequirements() []*shellz.Requirement {
+	return []*shellz.Requirement{
+		GetGoRequirement(),
+		{
+			Cmd:  "git",
+			Hint: "install Git from https://git-scm.com/downloads",
+		},
+	}
+}
+
+func getGoModVersion() string {
+	buf, err := os.ReadFile("go.mod")
+	if err != nil {
+		return ""
+	}
+
+	if m := goModVersionRegexp.FindSubmatch(buf); m != nil {
+		return string(m[1])
+	}
+
+	return ""
+}
@@ -30,10 +30,17 @@ func TestSuite(t *testing.T) {
 }
 
 func (*Suite) TestGoTool(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	gtz.DefaultGoToolCache = gtz.NewGoToolCache(dirPath)
+	defer gtz.RestoreDefaultGoToolCache()
+
 	gtz.GoToolGolint.MustRun(".")
 
 	g.Expect(gtz.NewGoTool("a", "b", "c").GetPackage()).To(Equal("a"))
 	g.Expect(gtz.NewGoTool("a", "b", "c").GetArgument()).To(Equal("a/b@c"))
+	g.Expect(gtz.NewGoTool("a", "b", "c").GetCommand().GetProcessGroup()).To(BeFalse())
 	g.Expect(gtz.NewGoTool("a", "b", "c").GetVersion()).To(Equal("c"))
 	g.Expect(gtz.NewGoTool("github.com/axw/gocov", "", "unused").GetVersion()).To(Equal("v1.2.1"))
 
@@ -51,6 +58,7 @@ func (*Suite) TestRunGoChecks(g *WithT, ctrl *gomock.Controller) {
 	defer shellz.RestoreDefaultExecutor()
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "mod", "tidy"})
@@ -59,6 +67,7 @@ func (*Suite) TestRunGoChecks(g *WithT, ctrl *gomock.Controller) {
 		Return(nil)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "generate", "./..."})
@@ -67,6 +76,7 @@ func (*Suite) TestRunGoChecks(g *WithT, ctrl *gomock.Controller) {
 		Return(nil)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "fmt", "./..."})
@@ -76,6 +86,9 @@ func (*Suite) TestRunGoChecks(g *WithT, ctrl *gomock.Controller) {
 
 	m.EXPECT().ExecCmdRun(
 		gomock.Any(),
+		gomock.Cond(func(c *shellz.Command) bool {
+			return c.GetCaptureLimit() == shellz.DefaultCaptureLimit
+		}),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "build", "-v", "-tags=t1,t2", "./..."})
 		})).
@@ -83,6 +96,7 @@ func (*Suite) TestRunGoChecks(g *WithT, ctrl *gomock.Controller) {
 		Return(nil)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "run", gtz.GoToolGolint.GetArgument(), "-set_exit_status", "./..."})
@@ -92,6 +106,9 @@ func (*Suite) TestRunGoChecks(g *WithT, ctrl *gomock.Controller) {
 
 	m.EXPECT().ExecCmdRun(
 		gomock.Any(),
+		gomock.Cond(func(c *shellz.Command) bool {
+			return c.GetCaptureLimit() == shellz.DefaultCaptureLimit
+		}),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "vet", "./..."})
 		})).
@@ -99,6 +116,7 @@ func (*Suite) TestRunGoChecks(g *WithT, ctrl *gomock.Controller) {
 		Return(nil)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "run", gtz.GoToolStaticCheck.GetArgument(), "./..."})
@@ -107,6 +125,7 @@ func (*Suite) TestRunGoChecks(g *WithT, ctrl *gomock.Controller) {
 		Return(nil)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "mod", "tidy"})
@@ -152,6 +171,7 @@ func (*Suite) TestRunGoTests_SelectedPackages(g *WithT, ctrl *gomock.Controller)
 	defer filez.MustRemoveAll(dirPath)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "generate", "./..."})
@@ -160,6 +180,7 @@ func (*Suite) TestRunGoTests_SelectedPackages(g *WithT, ctrl *gomock.Controller)
 		Return(nil)
 
 	m.EXPECT().ExecCmdStart(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			isMatch := reflect.DeepEqual(c.Args, []string{
@@ -181,6 +202,7 @@ func (*Suite) TestRunGoTests_SelectedPackages(g *WithT, ctrl *gomock.Controller)
 		Return(nil)
 
 	m.EXPECT().ExecCmdWait(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			isMatch := reflect.DeepEqual(c.Args, []string{
@@ -200,30 +222,45 @@ func (*Suite) TestRunGoTests_SelectedPackages(g *WithT, ctrl *gomock.Controller)
 		Times(1).
 		Return(nil)
 
-	m.EXPECT().ExecCmdOutput(
+	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
-			return reflect.DeepEqual(c.Args, []string{
+			isMatch := reflect.DeepEqual(c.Args, []string{
 				"go", "run",
 				gtz.GoToolGoCov.GetArgument(),
 				"convert",
 				filepath.Join(dirPath, "coverage.out")})
+
+			if isMatch {
+				_, _ = c.Stdout.Write([]byte("{}"))
+			}
+
+			return isMatch
 		})).
 		Times(1).
-		Return([]byte("{}"), nil)
+		Return(nil)
 
-	m.EXPECT().ExecCmdOutput(
+	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
-			return reflect.DeepEqual(c.Args, []string{
+			isMatch := reflect.DeepEqual(c.Args, []string{
 				"go", "run",
 				gtz.GoToolGoCovHTML.GetArgument(),
 				"-t", "golang"})
+
+			if isMatch {
+				_, _ = c.Stdout.Write([]byte("<html></html>"))
+			}
+
+			return isMatch
 		})).
 		Times(1).
-		Return([]byte("<html></html>"), nil)
+		Return(nil)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"open", filepath.Join(dirPath, "coverage.html")})
@@ -251,7 +288,7 @@ func (*Suite) TestRunGoTests_SelectedPackages(g *WithT, ctrl *gomock.Controller)
 		"[................go-tests] generating Go code...",
 		"🏃 go generate ./...",
 		"[................go-tests] running tests...",
-		fmt.Sprintf("🏃 go test -trimpath -race -failfast -shuffle=on -covermode=atomic -coverprofile=%v/coverage.out -count=1 -run=^test$ -v ./package", dirPath),
+		fmt.Sprintf("🏃 go test -trimpath -race -failfast -shuffle=on -covermode=atomic -coverprofile=%v/coverage.out -count=1 '-run=^test$' -v ./package", dirPath),
 		"DONE    [SKIP: 0, PASS: 0]                                           0s        ",
 		"[................go-tests] processing coverage...",
 		"DONE    [LOWC: 0, MEDC: 0, HIGC: 0]                                  100.0% [0/0]",
@@ -260,6 +297,8 @@ func (*Suite) TestRunGoTests_SelectedPackages(g *WithT, ctrl *gomock.Controller)
 		"",
 	}, "\n")))
 	g.Expect(errBuf).To(BeEmpty())
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "coverage.json"))).To(Equal("{}"))
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "coverage.html"))).To(Equal("<html></html>"))
 }
 
 func (*Suite) TestRunGoTests_AllPackages(g *WithT, ctrl *gomock.Controller) {
@@ -274,6 +313,7 @@ func (*Suite) TestRunGoTests_AllPackages(g *WithT, ctrl *gomock.Controller) {
 	defer filez.MustRemoveAll(dirPath)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"go", "generate", "./..."})
@@ -282,6 +322,7 @@ func (*Suite) TestRunGoTests_AllPackages(g *WithT, ctrl *gomock.Controller) {
 		Return(nil)
 
 	m.EXPECT().ExecCmdStart(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			isMatch := reflect.DeepEqual(c.Args, []string{
@@ -303,6 +344,7 @@ func (*Suite) TestRunGoTests_AllPackages(g *WithT, ctrl *gomock.Controller) {
 		Return(nil)
 
 	m.EXPECT().ExecCmdWait(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			isMatch := reflect.DeepEqual(c.Args, []string{
@@ -322,30 +364,45 @@ func (*Suite) TestRunGoTests_AllPackages(g *WithT, ctrl *gomock.Controller) {
 		Times(1).
 		Return(nil)
 
-	m.EXPECT().ExecCmdOutput(
+	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
-			return reflect.DeepEqual(c.Args, []string{
+			isMatch := reflect.DeepEqual(c.Args, []string{
 				"go", "run",
 				gtz.GoToolGoCov.GetArgument(),
 				"convert",
 				filepath.Join(dirPath, "coverage.out")})
+
+			if isMatch {
+				_, _ = c.Stdout.Write([]byte("{}"))
+			}
+
+			return isMatch
 		})).
 		Times(1).
-		Return([]byte("{}"), nil)
+		Return(nil)
 
-	m.EXPECT().ExecCmdOutput(
+	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
-			return reflect.DeepEqual(c.Args, []string{
+			isMatch := reflect.DeepEqual(c.Args, []string{
 				"go", "run",
 				gtz.GoToolGoCovHTML.GetArgument(),
 				"-t", "golang"})
+
+			if isMatch {
+				_, _ = c.Stdout.Write([]byte("<html></html>"))
+			}
+
+			return isMatch
 		})).
 		Times(1).
-		Return([]byte("<html></html>"), nil)
+		Return(nil)
 
 	m.EXPECT().ExecCmdRun(
+		gomock.Any(),
 		gomock.Any(),
 		gomock.Cond(func(c *exec.Cmd) bool {
 			return reflect.DeepEqual(c.Args, []string{"open", filepath.Join(dirPath, "coverage.html")})
@@ -373,7 +430,7 @@ func (*Suite) TestRunGoTests_AllPackages(g *WithT, ctrl *gomock.Controller) {
 		"[................go-tests] generating Go code...",
 		"🏃 go generate ./...",
 		"[................go-tests] running tests...",
-		fmt.Sprintf("🏃 go test -trimpath -race -failfast -shuffle=on -covermode=atomic -coverprofile=%v/coverage.out -count=1 -run=^test$ ./...", dirPath),
+		fmt.Sprintf("🏃 go test -trimpath -race -failfast -shuffle=on -covermode=atomic -coverprofile=%v/coverage.out -count=1 '-run=^test$' ./...", dirPath),
 		"DONE    [SKIP: 0, PASS: 0]                                           0s        ",
 		"[................go-tests] processing coverage...",
 		"DONE    [LOWC: 0, MEDC: 0, HIGC: 0]                                  100.0% [0/0]",
@@ -382,9 +439,31 @@ func (*Suite) TestRunGoTests_AllPackages(g *WithT, ctrl *gomock.Controller) {
 		"",
 	}, "\n")))
 	g.Expect(errBuf).To(BeEmpty())
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "coverage.json"))).To(Equal("{}"))
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "coverage.html"))).To(Equal("<html></html>"))
 }
 
 func (*Suite) TestGenerateVersions(g *WithT) {
 	g.Expect(gtz.MustGenerateShortVersion()).To(MatchRegexp(`^[a-f0-9]{7}$`))
 	g.Expect(gtz.MustGenerateLongVersion()).To(MatchRegexp(`^\d{8}T\d{6}-[a-f0-9]{7}$`))
 }
+
+func (*Suite) TestGetRequirements(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	wd, err := os.Getwd()
+	g.Expect(err).To(Succeed())
+	g.Expect(os.Chdir(dirPath)).To(Succeed())
+	defer func() { g.Expect(os.Chdir(wd)).To(Succeed()) }()
+
+	requirements := gtz.GetRequirements()
+	g.Expect(requirements).To(HaveLen(2))
+	g.Expect(requirements[0].GetName()).To(Equal("go"))
+	g.Expect(requirements[0].MinVersion).To(BeEmpty())
+	g.Expect(requirements[1].GetName()).To(Equal("git"))
+
+	filez.MustWriteFileString("go.mod", 0777, 0666, "module example.com/m\n\ngo 1.23.4\r\n")
+	g.Expect(gtz.GetGoRequirement().MinVersion).To(Equal("1.23.4"))
+	g.Expect(shellz.Require(gtz.GetGoRequirement())).To(Succeed())
+}
@@ -0,0 +1,294 @@
+package gtz
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/hashz"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+const (
+	goToolCacheKeyVersion = "gtz-tool-cache-v2"
+	goToolCacheBinDirName = "bin"
+)
+
+var (
+	// goToolCacheEnvKeys are the Go environment variables that affect the binaries built by "go install".
+	goToolCacheEnvKeys = []string{"GOVERSION", "GOOS", "GOARCH", "GOFLAGS", "GOEXPERIMENT", "CGO_ENABLED"}
+)
+
+var (
+	defaultGoToolCache = NewGoToolCache(filepath.Join(".build", "tools"))
+
+	// DefaultGoToolCache is the [*GoToolCache] used by [*GoTool.GetCommand] (nil means tools are run using "go run").
+	DefaultGoToolCache = defaultGoToolCache
+)
+
+// RestoreDefaultGoToolCache restores [DefaultGoToolCache].
+func RestoreDefaultGoToolCache() {
+	DefaultGoToolCache = defaultGoToolCache
+}
+
+// GoToolCache is a directory of Go tool binaries, stored in a directory for each combination of package, path, version
+// and Go environment (e.g. ".build/tools/<hash>/bin"). Each tool is installed using "go install" the first time it is
+// used, then its binary is executed directly.
+type GoToolCache struct {
+	m              *sync.Mutex
+	dirPath        string
+	isForceRebuild bool
+	goEnvs         map[string]string
+	binFilePaths   map[string]string
+}
+
+// NewGoToolCache initializes a new [*GoToolCache] stored in the given directory.
+// The directory is created when the first tool is installed.
+func NewGoToolCache(dirPath string) *GoToolCache {
+	return &GoToolCache{
+		m:              &sync.Mutex{},
+		dirPath:        dirPath,
+		isForceRebuild: false,
+		goEnvs:         make(map[string]string),
+		binFilePaths:   make(map[string]string),
+	}
+}
+
+// SetForceRebuild configures the cache to rebuild each tool the first time it is used by this process, even if it is
+// already installed.
+func (c *GoToolCache) SetForceRebuild(isForceRebuild bool) *GoToolCache {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.isForceRebuild = isForceRebuild
+	return c
+}
+
+// GetForceRebuild returns true if the cache is configured to rebuild tools (see [*GoToolCache.SetForceRebuild]).
+func (c *GoToolCache) GetForceRebuild() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.isForceRebuild
+}
+
+// GetDirPath returns the directory of the cache.
+func (c *GoToolCache) GetDirPath() string {
+	return c.dirPath
+}
+
+// Install installs the given Go tool in the cache, unless already installed, and returns the path of its binary.
+// It is built in the working directory and environment of the current process. Tools at version "latest" cannot be
+// cached and result in an error.
+func (c *GoToolCache) Install(t *GoTool) (string, error) {
+	return c.install(t, shellz.NewCommand("go"))
+}
+
+// install is like [*GoToolCache.Install], but the tool is built in the working directory and environment of "goCmd"
+// (e.g. so that GOOS, GOFLAGS or GOTOOLCHAIN overrides are honored). The key of the cache entry includes the resulting
+// Go environment.
+func (c *GoToolCache) install(t *GoTool, goCmd *shellz.Command) (string, error) {
+	version := t.GetVersion()
+	if version == "latest" {
+		return "", errorz.Errorf("cannot cache Go tool %q at version %q", t.GetPackage(), version)
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	key, err := c.getKey(t, version, goCmd)
+	if err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	if binFilePath, ok := c.binFilePaths[key]; ok {
+		return binFilePath, nil
+	}
+
+	dirPath, err := filepath.Abs(filepath.Join(c.dirPath, key))
+	if err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	if !c.isForceRebuild {
+		if binFilePath, err := findGoToolBinary(dirPath); err == nil {
+			now := time.Now()
+			_ = os.Chtimes(dirPath, now, now) // marks the entry as recently used, see [*GoToolCache.Prune]
+			c.binFilePaths[key] = binFilePath
+			return binFilePath, nil
+		}
+	}
+
+	binFilePath, err := installGoTool(t, dirPath, goCmd)
+	if err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	c.binFilePaths[key] = binFilePath
+	return binFilePath, nil
+}
+
+// MustInstall is like [*GoToolCache.Install] but panics on error.
+func (c *GoToolCache) MustInstall(t *GoTool) string {
+	binFilePath, err := c.Install(t)
+	errorz.MaybeMustWrap(err)
+	return binFilePath
+}
+
+// Prune removes the tools that have not been installed or used for longer than "maxAge". Leftover temporary directories
+// of interrupted installations are removed too, but only once they are older than "maxAge" as well.
+func (c *GoToolCache) Prune(maxAge time.Duration) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	entries, err := os.ReadDir(c.dirPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return errorz.Wrap(err)
+	}
+
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return errorz.Wrap(err)
+		}
+
+		if !e.IsDir() || time.Since(fi.ModTime()) <= maxAge {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(c.dirPath, e.Name())); err != nil {
+			return errorz.Wrap(err)
+		}
+
+		delete(c.binFilePaths, e.Name())
+	}
+
+	return nil
+}
+
+// MustPrune is like [*GoToolCache.Prune] but panics on error.
+func (c *GoToolCache) MustPrune(maxAge time.Duration) {
+	errorz.MaybeMustWrap(c.Prune(maxAge))
+}
+
+// Clear removes all the tools from the cache.
+func (c *GoToolCache) Clear() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.binFilePaths = make(map[string]string)
+	return errorz.MaybeWrap(os.RemoveAll(c.dirPath))
+}
+
+// MustClear is like [*GoToolCache.Clear] but panics on error.
+func (c *GoToolCache) MustClear() {
+	errorz.MaybeMustWrap(c.Clear())
+}
+
+// getKey returns the cache key of the given Go tool, built with "goCmd" (see [*GoToolCache.install]).
+func (c *GoToolCache) getKey(t *GoTool, version string, goCmd *shellz.Command) (string, error) {
+	envKey := hashz.MustHashSHA256([]byte(strings.Join(append(goCmd.GetEffectiveEnv(), goCmd.GetDir()), "\x00")))
+	goEnv, ok := c.goEnvs[envKey]
+
+	if !ok {
+		out, err := newGoCommand(goCmd, append([]string{"env"}, goToolCacheEnvKeys...)...).
+			SetEcho(false).
+			OutputString(false)
+		if err != nil {
+			return "", errorz.Wrap(err)
+		}
+
+		goEnv = strings.TrimRight(out, "\r\n")
+		c.goEnvs[envKey] = goEnv
+	}
+
+	return hashz.MustHashSHA256([]byte(strings.Join([]string{
+		goToolCacheKeyVersion,
+		t.GetPackage(),
+		t.path,
+		version,
+		goEnv,
+	}, "\x00"))), nil
+}
+
+// installGoTool installs the given Go tool in a temporary directory, which then atomically replaces "dirPath".
+func installGoTool(t *GoTool, dirPath string, goCmd *shellz.Command) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dirPath), 0777); err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	tmpDirPath, err := os.MkdirTemp(filepath.Dir(dirPath), "."+filepath.Base(dirPath)+".*.tmp")
+	if err != nil {
+		return "", errorz.Wrap(err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDirPath) }()
+
+	if err := newGoCommand(goCmd, "install", t.GetArgument()).
+		SetEnv("GOBIN", filepath.Join(tmpDirPath, goToolCacheBinDirName)).
+		Run(); err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	if _, err := findGoToolBinary(tmpDirPath); err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	if err := os.RemoveAll(dirPath); err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	if err := os.Rename(tmpDirPath, dirPath); err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	return findGoToolBinary(dirPath)
+}
+
+// findGoToolBinary returns the path of the single binary installed in the given cache entry.
+func findGoToolBinary(dirPath string) (string, error) {
+	binDirPath := filepath.Join(dirPath, goToolCacheBinDirName)
+
+	entries, err := os.ReadDir(binDirPath)
+	if err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	if len(entries) != 1 || entries[0].IsDir() {
+		return "", errorz.Errorf("expected a single binary in %q", binDirPath)
+	}
+
+	return filepath.Join(binDirPath, entries[0].Name()), nil
+}
+
+// newGoCommand returns a "go" command with the given params, and the same dir and environment as "goCmd".
+func newGoCommand(goCmd *shellz.Command, params ...string) *shellz.Command {
+	c := shellz.NewCommand("go", params...).
+		SetDir(goCmd.GetDir()).
+		SetEnvMode(goCmd.GetEnvMode(), goCmd.GetEnvAllowlist()...).
+		UnsetEnv(goCmd.GetUnsetEnv()...)
+
+	secretEnvKeys := goCmd.GetSecretEnvKeys()
+
+	for k, v := range goCmd.GetEnv() {
+		if slices.Contains(secretEnvKeys, k) {
+			c = c.SetSecretEnv(k, v)
+		} else {
+			c = c.SetEnv(k, v)
+		}
+	}
+
+	return c
+}
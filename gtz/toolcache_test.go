@@ -0,0 +1,197 @@
+package gtz_test
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/gtz"
+	"github.com/ibrt/golang-dev/shellz"
+	"github.com/ibrt/golang-dev/shellz/tshellz"
+)
+
+type ToolCacheSuite struct {
+	// intentionally empty
+}
+
+func TestToolCacheSuite(t *testing.T) {
+	fixturez.RunSuite(t, &ToolCacheSuite{})
+}
+
+const toolScript = "#!/bin/sh\necho tool \"$@\"\n"
+
+func newToolCacheFakeExecutor() (*tshellz.FakeExecutor, *tshellz.FakeRule) {
+	e := tshellz.NewFakeExecutor()
+	e.On("go", "list", "**").SetExitCode(1)
+	e.On("go", "env", "**").SetFunc(func(call *tshellz.FakeCall) *tshellz.FakeResponse {
+		return &tshellz.FakeResponse{Stdout: "go1.23.0\n" + cmp.Or(call.Env["GOOS"], "linux") + "\namd64\n\n\n1\n"}
+	})
+	e.On("go", "install", "example.com/broken*").SetExitCode(1)
+
+	r := e.On("go", "install", "**").SetFunc(func(call *tshellz.FakeCall) *tshellz.FakeResponse {
+		filez.MustWriteFileString(filepath.Join(call.Env["GOBIN"], "tool"), 0777, 0777, toolScript)
+		return &tshellz.FakeResponse{}
+	})
+
+	return e, r
+}
+
+func newNoopMiddleware() shellz.Middleware {
+	return func(call *shellz.ExecutorCall, next shellz.ExecutorHandler) *shellz.ExecutorResult {
+		return next(call)
+	}
+}
+
+func listDir(dirPath string) []string {
+	entries, err := os.ReadDir(dirPath)
+	errorz.MaybeMustWrap(err)
+
+	names := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return names
+}
+
+func (*ToolCacheSuite) TestInstall(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	e, r := newToolCacheFakeExecutor()
+	shellz.DefaultExecutor = e
+	defer shellz.RestoreDefaultExecutor()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	c := gtz.NewGoToolCache(dirPath)
+	g.Expect(c.GetDirPath()).To(Equal(dirPath))
+	g.Expect(c.GetForceRebuild()).To(BeFalse())
+
+	binFilePath := c.MustInstall(gtz.NewGoTool("example.com/tool", "cmd/tool", "v1.0.0"))
+	g.Expect(filepath.Base(binFilePath)).To(Equal("tool"))
+	g.Expect(filepath.Dir(filepath.Dir(filepath.Dir(binFilePath)))).To(Equal(dirPath))
+	g.Expect(filez.MustReadFileString(binFilePath)).To(Equal(toolScript))
+	g.Expect(c.MustInstall(gtz.NewGoTool("example.com/tool", "cmd/tool", "v1.0.0"))).To(Equal(binFilePath))
+	g.Expect(r.GetCount()).To(Equal(1))
+
+	g.Expect(c.MustInstall(gtz.NewGoTool("example.com/tool", "cmd/tool", "v1.1.0"))).ToNot(Equal(binFilePath))
+	g.Expect(r.GetCount()).To(Equal(2))
+	g.Expect(listDir(dirPath)).To(HaveLen(2))
+
+	c = gtz.NewGoToolCache(dirPath)
+	g.Expect(c.MustInstall(gtz.NewGoTool("example.com/tool", "cmd/tool", "v1.0.0"))).To(Equal(binFilePath))
+	g.Expect(r.GetCount()).To(Equal(2))
+
+	c = gtz.NewGoToolCache(dirPath).SetForceRebuild(true)
+	g.Expect(c.GetForceRebuild()).To(BeTrue())
+	g.Expect(c.MustInstall(gtz.NewGoTool("example.com/tool", "cmd/tool", "v1.0.0"))).To(Equal(binFilePath))
+	g.Expect(c.MustInstall(gtz.NewGoTool("example.com/tool", "cmd/tool", "v1.0.0"))).To(Equal(binFilePath))
+	g.Expect(r.GetCount()).To(Equal(3))
+	g.Expect(listDir(dirPath)).To(HaveLen(2))
+
+	_, err := c.Install(gtz.NewGoTool("example.com/tool", "cmd/tool", ""))
+	g.Expect(err).To(MatchError(`cannot cache Go tool "example.com/tool" at version "latest"`))
+
+	g.Expect(func() { c.MustInstall(gtz.NewGoTool("example.com/broken", "", "v1.0.0")) }).
+		To(PanicWith(MatchError(ContainSubstring("exit status 1"))))
+	g.Expect(listDir(dirPath)).To(HaveLen(2))
+
+	outBuf, _ := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(ContainSubstring("go \x1b[2minstall example.com/tool/cmd/tool@v1.0.0\x1b[0m"))
+}
+
+func (*ToolCacheSuite) TestPrune(g *WithT) {
+	e, r := newToolCacheFakeExecutor()
+	shellz.DefaultExecutor = e
+	defer shellz.RestoreDefaultExecutor()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	c := gtz.NewGoToolCache(filepath.Join(dirPath, "tools"))
+	g.Expect(c.Prune(time.Hour)).To(Succeed())
+
+	oldBinFilePath := c.SetForceRebuild(false).MustInstall(gtz.NewGoTool("example.com/tool", "", "v1.0.0"))
+	newBinFilePath := c.MustInstall(gtz.NewGoTool("example.com/tool", "", "v1.1.0"))
+	oldDirPath := filepath.Dir(filepath.Dir(oldBinFilePath))
+
+	before := time.Now().Add(-2 * time.Hour)
+	g.Expect(os.Chtimes(oldDirPath, before, before)).To(Succeed())
+
+	c.MustPrune(time.Hour)
+	g.Expect(listDir(c.GetDirPath())).To(ConsistOf(filepath.Base(filepath.Dir(filepath.Dir(newBinFilePath)))))
+
+	g.Expect(c.MustInstall(gtz.NewGoTool("example.com/tool", "", "v1.0.0"))).To(Equal(oldBinFilePath))
+	g.Expect(r.GetCount()).To(Equal(3))
+
+	c.MustClear()
+	g.Expect(listDir(dirPath)).To(BeEmpty())
+	g.Expect(func() { c.MustPrune(0) }).ToNot(Panic())
+}
+
+func (*ToolCacheSuite) TestGetCommand(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	e, r := newToolCacheFakeExecutor()
+	shellz.DefaultExecutor = e
+	defer shellz.RestoreDefaultExecutor()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	gtz.DefaultGoToolCache = gtz.NewGoToolCache(dirPath)
+	defer gtz.RestoreDefaultGoToolCache()
+
+	gt := gtz.NewGoTool("example.com/tool", "cmd/tool", "v1.0.0")
+	c := gt.GetCommand().AddParams("a", "b").SetEcho(false)
+	g.Expect(c.GetCmd()).To(Equal("go"))
+	g.Expect(c.GetParams()).To(Equal([]string{"run", "example.com/tool/cmd/tool@v1.0.0", "a", "b"}))
+	g.Expect(c.GetProcessGroup()).To(BeFalse())
+	g.Expect(r.GetCount()).To(BeZero())
+
+	// Fake executors see the "go run" command, and nothing is installed.
+	e.On("go", "run", "**")
+	g.Expect(c.Run()).To(Succeed())
+	g.Expect(r.GetCount()).To(BeZero())
+	g.Expect(e.GetCalls()[len(e.GetCalls())-1].String()).To(Equal("go run example.com/tool/cmd/tool@v1.0.0 a b"))
+
+	// Real executors run the installed binary.
+	c = c.SetExecutor(&shellz.RealExecutor{})
+	g.Expect(c.OutputString(false)).To(Equal("tool a b\n"))
+	g.Expect(c.OutputString(false)).To(Equal("tool a b\n"))
+	g.Expect(r.GetCount()).To(Equal(1))
+
+	// The tool is installed with the environment of the command, in a separate entry if the Go environment differs.
+	g.Expect(c.SetEnv("GOOS", "linux").OutputString(false)).To(Equal("tool a b\n"))
+	g.Expect(r.GetCount()).To(Equal(1))
+	g.Expect(c.SetEnv("GOOS", "plan9").OutputString(false)).To(Equal("tool a b\n"))
+	g.Expect(r.GetCount()).To(Equal(2))
+	g.Expect(e.GetCalls()[len(e.GetCalls())-1].Env).To(HaveKeyWithValue("GOOS", "plan9"))
+	g.Expect(listDir(dirPath)).To(HaveLen(2))
+
+	// Wrapped executors that are not simulated run the installed binary too.
+	g.Expect(c.SetExecutor(shellz.ChainExecutor(&shellz.RealExecutor{}, newNoopMiddleware())).OutputString(false)).
+		To(Equal("tool a b\n"))
+	g.Expect(r.GetCount()).To(Equal(2))
+
+	err := gtz.NewGoTool("example.com/broken", "", "v1.0.0").GetCommand().SetEcho(false).SetExecutor(&shellz.RealExecutor{}).Run()
+	g.Expect(err).To(MatchError(`execution error: cannot install Go tool "example.com/broken": execution error: exit status 1`))
+
+	g.Expect(gtz.NewGoTool("example.com/tool", "cmd/tool", "").GetCommand().GetParams()).
+		To(Equal([]string{"run", "example.com/tool/cmd/tool@latest"}))
+
+	gtz.RestoreDefaultGoToolCache()
+	g.Expect(gtz.DefaultGoToolCache.GetDirPath()).To(Equal(filepath.Join(".build", "tools")))
+}
@@ -0,0 +1,708 @@
+package shellz
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/hashz"
+	"github.com/ibrt/golang-utils/memz"
+)
+
+const (
+	// DefaultCacheMaxBytes is the default maximum size of a [*Cache].
+	DefaultCacheMaxBytes = 256 * 1024 * 1024
+
+	cacheKeyVersion   = "shellz-cache-v1"
+	cacheEntryFileExt = ".json"
+)
+
+// CacheOptions describes the inputs of a cached command (see [*Command.SetCache]).
+type CacheOptions struct {
+	// Inputs are paths or glob patterns (see [filepath.Match]) of files whose content is part of the cache key.
+	// Relative paths are resolved against the dir of the command (see [*Command.SetDir]). Directories are hashed
+	// recursively. Patterns that match nothing are allowed, so that creating a matching file invalidates the cache.
+	Inputs []string
+
+	// EnvKeys are the names of the environment variables whose values are part of the cache key (see
+	// [*Command.GetEffectiveEnv]). Other environment variables are ignored.
+	EnvKeys []string
+}
+
+func (o *CacheOptions) clone() *CacheOptions {
+	if o == nil {
+		return &CacheOptions{}
+	}
+
+	return &CacheOptions{
+		Inputs:  memz.ShallowCopySlice(o.Inputs),
+		EnvKeys: memz.ShallowCopySlice(o.EnvKeys),
+	}
+}
+
+// Cache is a content-addressed cache of command outputs, stored in a directory (see [*Command.SetCache]).
+// Each entry is a file, and the least recently used entries are evicted when the cache exceeds its maximum size.
+//
+// Only completed executions are stored (i.e. successes and non-zero exits), and failures to store them are ignored.
+// To be recorded, output that would otherwise go to the terminal is piped through the current process.
+type Cache struct {
+	m        *sync.Mutex
+	dirPath  string
+	maxBytes int64
+	pending  map[*exec.Cmd]*pendingCacheEntry
+}
+
+// cacheEntry is the content of a cache entry file.
+type cacheEntry struct {
+	Kind        InteractionKind `json:"kind"`
+	CommandLine string          `json:"commandLine"`
+	Stdout      []byte          `json:"stdout,omitempty"`
+	Stderr      []byte          `json:"stderr,omitempty"`
+	ExitCode    int             `json:"exitCode"`
+}
+
+// pendingCacheEntry tracks a started command until it is waited for.
+type pendingCacheEntry struct {
+	filePath string
+	hit      *cacheEntry
+	done     chan struct{}
+	stdout   *bytes.Buffer
+	stderr   *bytes.Buffer
+	wait     func()
+	isTapped bool
+}
+
+// NewCache initializes a new [*Cache] stored in the given directory, with a maximum size of [DefaultCacheMaxBytes].
+// The directory is created when the first entry is stored.
+func NewCache(dirPath string) *Cache {
+	return &Cache{
+		m:        &sync.Mutex{},
+		dirPath:  dirPath,
+		maxBytes: DefaultCacheMaxBytes,
+		pending:  make(map[*exec.Cmd]*pendingCacheEntry),
+	}
+}
+
+// SetMaxBytes sets the maximum size of the cache (if not positive, the size is unlimited).
+func (c *Cache) SetMaxBytes(maxBytes int64) *Cache {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.maxBytes = maxBytes
+	return c
+}
+
+// GetMaxBytes returns the maximum size of the cache.
+func (c *Cache) GetMaxBytes() int64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.maxBytes
+}
+
+// GetDirPath returns the directory of the cache.
+func (c *Cache) GetDirPath() string {
+	return c.dirPath
+}
+
+// GetSize returns the total size of the cache entries.
+func (c *Cache) GetSize() (int64, error) {
+	_, size, err := c.listEntries()
+	if err != nil {
+		return 0, errorz.Wrap(err)
+	}
+
+	return size, nil
+}
+
+// MustGetSize is like [*Cache.GetSize] but panics on error.
+func (c *Cache) MustGetSize() int64 {
+	size, err := c.GetSize()
+	errorz.MaybeMustWrap(err)
+	return size
+}
+
+// Invalidate removes all the entries for the given command, i.e. for its command, params and dir, regardless of the
+// other parts of the cache key.
+func (c *Cache) Invalidate(cmd *Command) error {
+	if err := os.RemoveAll(filepath.Join(c.dirPath, getCacheGroup(cmd))); err != nil {
+		return errorz.Wrap(err)
+	}
+
+	return nil
+}
+
+// MustInvalidate is like [*Cache.Invalidate] but panics on error.
+func (c *Cache) MustInvalidate(cmd *Command) {
+	errorz.MaybeMustWrap(c.Invalidate(cmd))
+}
+
+// Clear removes all the entries.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dirPath); err != nil {
+		return errorz.Wrap(err)
+	}
+
+	return nil
+}
+
+// MustClear is like [*Cache.Clear] but panics on error.
+func (c *Cache) MustClear() {
+	errorz.MaybeMustWrap(c.Clear())
+}
+
+// Prune evicts the least recently used entries until the cache does not exceed its maximum size.
+// It is called automatically after an entry is stored.
+func (c *Cache) Prune() error {
+	maxBytes := c.GetMaxBytes()
+	if maxBytes <= 0 {
+		return nil
+	}
+
+	entries, size, err := c.listEntries()
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	slices.SortFunc(entries, func(a, b *cacheEntryInfo) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for _, e := range entries {
+		if size <= maxBytes {
+			break
+		}
+
+		if err := os.Remove(e.filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errorz.Wrap(err)
+		}
+
+		size -= e.size
+		_ = os.Remove(filepath.Dir(e.filePath)) // only succeeds if the group directory is empty
+	}
+
+	return nil
+}
+
+// MustPrune is like [*Cache.Prune] but panics on error.
+func (c *Cache) MustPrune() {
+	errorz.MaybeMustWrap(c.Prune())
+}
+
+type cacheEntryInfo struct {
+	filePath string
+	size     int64
+	modTime  time.Time
+}
+
+func (c *Cache) listEntries() ([]*cacheEntryInfo, int64, error) {
+	entries := make([]*cacheEntryInfo, 0)
+	size := int64(0)
+
+	err := filepath.WalkDir(c.dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if d.IsDir() || filepath.Ext(path) != cacheEntryFileExt {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		entries = append(entries, &cacheEntryInfo{
+			filePath: path,
+			size:     fi.Size(),
+			modTime:  fi.ModTime(),
+		})
+
+		size += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, 0, errorz.Wrap(err)
+	}
+
+	return entries, size, nil
+}
+
+// SetCache enables caching the results of the command in the given [*Cache] (or disables it, if nil), keyed by the
+// command, its input, dir and environment setup, and the inputs described by the options (see [CacheOptions]).
+// On a hit the recorded output and exit code are replayed. Caching is silently skipped if the input is not a regular
+// file or in memory (e.g. a terminal or a pipe), with secrets, in PTY or dry-run mode, and for [*Command.Exec].
+func (c *Command) SetCache(cache *Cache, opts *CacheOptions) *Command {
+	cc := c.clone()
+	cc.cache = cache
+	cc.cacheOptions = opts.clone()
+	return cc
+}
+
+// GetCache returns the current [*Cache], or nil if caching is disabled.
+func (c *Command) GetCache() *Cache {
+	return c.cache
+}
+
+// GetCacheOptions returns the current cache options.
+func (c *Command) GetCacheOptions() *CacheOptions {
+	return c.cacheOptions.clone()
+}
+
+// newMiddleware returns a [Middleware] that serves and stores cache entries.
+func (c *Cache) newMiddleware(opts *CacheOptions) Middleware {
+	return func(call *ExecutorCall, next ExecutorHandler) *ExecutorResult {
+		switch call.Method {
+		case ExecutorMethodExecCmdRun:
+			return c.handle(call, next, opts, InteractionKindRun)
+		case ExecutorMethodExecCmdOutput:
+			return c.handle(call, next, opts, InteractionKindOutput)
+		case ExecutorMethodExecCmdCombinedOutput:
+			return c.handle(call, next, opts, InteractionKindCombinedOutput)
+		case ExecutorMethodExecCmdStart:
+			return c.handleStart(call, next, opts)
+		case ExecutorMethodExecCmdWait:
+			return c.handleWait(call, next)
+		default:
+			return next(call)
+		}
+	}
+}
+
+func (c *Cache) handle(call *ExecutorCall, next ExecutorHandler, opts *CacheOptions, kind InteractionKind) *ExecutorResult {
+	filePath, err := c.getEntryFilePath(call, opts, kind)
+	if err != nil {
+		return &ExecutorResult{Err: errorz.Wrap(err)}
+	}
+
+	if filePath == "" {
+		return next(call)
+	}
+
+	isStderrCaptured := kind == InteractionKindOutput && call.Cmd.Stderr == nil
+
+	if e := c.load(filePath); e != nil {
+		if kind != InteractionKindCombinedOutput {
+			writeCachedOutput(call.Cmd.Stdout, e.Stdout)
+			writeCachedOutput(call.Cmd.Stderr, e.Stderr)
+		}
+
+		r := &ExecutorResult{Err: e.getError(isStderrCaptured)}
+
+		if kind != InteractionKindRun {
+			r.Output = slices.Clone(e.Stdout)
+		}
+
+		return r
+	}
+
+	outBuf := &bytes.Buffer{}
+	errBuf := &bytes.Buffer{}
+
+	if kind == InteractionKindRun {
+		call.Cmd.Stdout = teeWriter(call.Cmd.Stdout, outBuf)
+	}
+
+	if kind != InteractionKindCombinedOutput && !isStderrCaptured {
+		call.Cmd.Stderr = teeWriter(call.Cmd.Stderr, errBuf)
+	}
+
+	r := next(call)
+
+	if kind != InteractionKindRun {
+		outBuf.Write(r.Output)
+	}
+
+	if eErr, ok := errorz.As[*exec.ExitError](r.Err); ok && isStderrCaptured {
+		errBuf.Write(eErr.Stderr)
+	}
+
+	c.maybeStore(call, filePath, kind, outBuf.Bytes(), errBuf.Bytes(), r.Err)
+	return r
+}
+
+func (c *Cache) handleStart(call *ExecutorCall, next ExecutorHandler, opts *CacheOptions) *ExecutorResult {
+	filePath, err := c.getEntryFilePath(call, opts, InteractionKindStart)
+	if err != nil {
+		return &ExecutorResult{Err: errorz.Wrap(err)}
+	}
+
+	if filePath == "" {
+		return next(call)
+	}
+
+	p := &pendingCacheEntry{
+		filePath: filePath,
+	}
+
+	if p.hit = c.load(filePath); p.hit != nil {
+		p.done = make(chan struct{})
+		stdout, stderr := call.Cmd.Stdout, call.Cmd.Stderr
+
+		go func() {
+			defer close(p.done)
+			writeSyntheticOutput(stdout, string(p.hit.Stdout))
+			writeSyntheticOutput(stderr, string(p.hit.Stderr))
+		}()
+	} else {
+		p.stdout = &bytes.Buffer{}
+		p.stderr = &bytes.Buffer{}
+		finishStdout, isStdoutTapped := tapStartWriter(&call.Cmd.Stdout, p.stdout)
+		finishStderr, isStderrTapped := tapStartWriter(&call.Cmd.Stderr, p.stderr)
+
+		r := next(call)
+		waitStdout := finishStdout(r.Err == nil)
+		waitStderr := finishStderr(r.Err == nil)
+
+		if r.Err != nil {
+			return r
+		}
+
+		p.isTapped = isStdoutTapped && isStderrTapped
+
+		p.wait = func() {
+			waitStdout()
+			waitStderr()
+		}
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.pending[call.Cmd] = p
+	return &ExecutorResult{}
+}
+
+func (c *Cache) handleWait(call *ExecutorCall, next ExecutorHandler) *ExecutorResult {
+	c.m.Lock()
+	p, ok := c.pending[call.Cmd]
+	delete(c.pending, call.Cmd)
+	c.m.Unlock()
+
+	if !ok {
+		return next(call)
+	}
+
+	if p.hit != nil {
+		<-p.done
+		return &ExecutorResult{Err: p.hit.getError(false)}
+	}
+
+	r := next(call)
+	p.wait()
+
+	if p.isTapped {
+		c.maybeStore(call, p.filePath, InteractionKindStart, p.stdout.Bytes(), p.stderr.Bytes(), r.Err)
+	}
+
+	return r
+}
+
+// getEntryFilePath returns the path of the cache entry file for the given call, or an empty string if the call cannot
+// be cached. If the input of the command is not an [*os.File], it is read in full to compute its digest and replaced.
+// If it is a regular file (e.g. see [*Command.SetStdinFile]) its content is hashed, while other files (e.g. terminals
+// and pipes) make the call not cacheable.
+func (c *Cache) getEntryFilePath(call *ExecutorCall, opts *CacheOptions, kind InteractionKind) (string, error) {
+	if len(call.Command.secrets) > 0 || len(call.Command.secretEnvKeys) > 0 {
+		return "", nil // secrets could end up in the stored output, and the cache key would be derived from them
+	}
+
+	stdinDigest := ""
+
+	if f, ok := call.Cmd.Stdin.(*os.File); ok {
+		digest, err := hashStdinFile(f)
+		if err != nil || digest == "" {
+			return "", errorz.MaybeWrap(err)
+		}
+
+		stdinDigest = digest
+	} else if call.Cmd.Stdin != nil {
+		in, err := io.ReadAll(call.Cmd.Stdin)
+		if err != nil {
+			return "", errorz.Wrap(err)
+		}
+
+		call.Cmd.Stdin = bytes.NewReader(in)
+		stdinDigest = hashz.MustHashSHA256(in)
+	}
+
+	group := getCacheGroup(call.Command)
+
+	k := newCacheKey()
+	k.add(group, string(kind), stdinDigest)
+	addCacheKeyEnv(k, call.Command)
+
+	envKeys := slices.Clone(opts.EnvKeys)
+	slices.Sort(envKeys)
+	env := call.Command.GetEffectiveEnv()
+
+	for _, envKey := range slices.Compact(envKeys) {
+		v, ok := lookupEnv(env, envKey)
+		k.add(envKey, strconv.FormatBool(ok), v)
+	}
+
+	for _, pattern := range opts.Inputs {
+		if err := k.addInputs(call.Command.dir, pattern); err != nil {
+			return "", errorz.Wrap(err)
+		}
+	}
+
+	return filepath.Join(c.dirPath, group, k.sum()+cacheEntryFileExt), nil
+}
+
+// hashStdinFile returns the digest of the remaining content of the given file, leaving its offset unchanged. It returns
+// an empty string if the file is not a regular file, as its content cannot be read ahead of the command.
+func hashStdinFile(f *os.File) (string, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		return "", nil
+	}
+
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	h := sha256.New()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// load returns the cache entry stored in the given file, or nil if not available.
+func (c *Cache) load(filePath string) *cacheEntry {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+
+	e := &cacheEntry{}
+
+	if err := json.Unmarshal(buf, e); err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(filePath, now, now)
+	return e
+}
+
+// maybeStore stores a cache entry for the given call, if it completed.
+func (c *Cache) maybeStore(call *ExecutorCall, filePath string, kind InteractionKind, stdout, stderr []byte, err error) {
+	if call.Context != nil && call.Context.Err() != nil {
+		return
+	}
+
+	e := &cacheEntry{
+		Kind:        kind,
+		CommandLine: call.Command.String(),
+		Stdout:      stdout,
+		Stderr:      stderr,
+		ExitCode:    0,
+	}
+
+	if err != nil {
+		eErr, ok := errorz.As[interface{ ExitCode() int }](err)
+		if !ok || eErr.ExitCode() <= 0 {
+			return
+		}
+
+		e.ExitCode = eErr.ExitCode()
+	}
+
+	if err := c.store(filePath, e); err == nil {
+		_ = c.Prune()
+	}
+}
+
+// store atomically writes a cache entry file.
+func (c *Cache) store(filePath string, e *cacheEntry) error {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0777); err != nil {
+		return errorz.Wrap(err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filePath), ".tmp-*")
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err := f.Write(buf); err != nil {
+		_ = f.Close()
+		return errorz.Wrap(err)
+	}
+
+	if err := f.Close(); err != nil {
+		return errorz.Wrap(err)
+	}
+
+	if err := os.Rename(f.Name(), filePath); err != nil {
+		return errorz.Wrap(err)
+	}
+
+	return nil
+}
+
+// getError returns the error to report for the cache entry.
+func (e *cacheEntry) getError(includeStderr bool) error {
+	if e.ExitCode == 0 {
+		return nil
+	}
+
+	var stderr []byte
+
+	if includeStderr {
+		stderr = slices.Clone(e.Stderr)
+	}
+
+	return NewExitError(e.ExitCode, stderr)
+}
+
+// cacheKey computes a digest of an unambiguous sequence of strings.
+type cacheKey struct {
+	h hash.Hash
+}
+
+func newCacheKey() *cacheKey {
+	k := &cacheKey{
+		h: sha256.New(),
+	}
+
+	k.add(cacheKeyVersion)
+	return k
+}
+
+func (k *cacheKey) add(parts ...string) {
+	for _, part := range parts {
+		_, _ = fmt.Fprintf(k.h, "%v:%v;", len(part), part)
+	}
+}
+
+// addInputs adds the paths and contents of the files matching the given pattern.
+func (k *cacheKey) addInputs(dir string, pattern string) error {
+	resolved := pattern
+
+	if dir != "" && !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(dir, resolved)
+	}
+
+	matches, err := filepath.Glob(resolved)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	k.add(pattern, strconv.Itoa(len(matches)))
+
+	for _, match := range matches {
+		err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+				return err
+			}
+
+			buf, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			k.add(filepath.ToSlash(path), hashz.MustHashSHA256(buf))
+			return nil
+		})
+		if err != nil {
+			return errorz.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (k *cacheKey) sum() string {
+	return hex.EncodeToString(k.h.Sum(nil))
+}
+
+// getCacheGroup returns a digest of the command, params and dir, which identifies the group of entries for a command.
+func getCacheGroup(c *Command) string {
+	k := newCacheKey()
+	k.add(c.cmd, c.dir, strconv.Itoa(len(c.params)))
+	k.add(c.params...)
+	return k.sum()
+}
+
+// addCacheKeyEnv adds the environment set up by the command itself (i.e. its env overrides, env mode and unset env).
+func addCacheKeyEnv(k *cacheKey, c *Command) {
+	envAllowlist := slices.Sorted(slices.Values(c.envAllowlist))
+	k.add(c.envMode.String(), strconv.Itoa(len(envAllowlist)))
+	k.add(envAllowlist...)
+
+	unsetEnv := c.GetUnsetEnv()
+	k.add(strconv.Itoa(len(unsetEnv)))
+	k.add(unsetEnv...)
+
+	k.add(strconv.Itoa(len(c.env)))
+
+	for _, key := range slices.Sorted(maps.Keys(c.env)) {
+		k.add(key, c.env[key])
+	}
+}
+
+// lookupEnv looks up a variable in the given "KEY=value" list (the last occurrence wins).
+func lookupEnv(env []string, key string) (string, bool) {
+	for _, kv := range slices.Backward(env) {
+		if k, v, ok := cutEnv(kv); ok && k == key {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// writeCachedOutput writes the cached output to "w" (which can be nil).
+func writeCachedOutput(w io.Writer, out []byte) {
+	if w != nil {
+		_, _ = w.Write(out)
+	}
+}
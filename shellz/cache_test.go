@@ -0,0 +1,296 @@
+package shellz_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type CacheSuite struct {
+	// intentionally empty
+}
+
+func TestCacheSuite(t *testing.T) {
+	fixturez.RunSuite(t, &CacheSuite{})
+}
+
+// newCountingCommand returns a command that appends a line to "<dirPath>/count" each time it runs, then runs "script".
+func newCountingCommand(dirPath string, script string) *shellz.Command {
+	return shellz.NewCommand("sh", "-c", `echo x >> "$0"; `+script, filepath.Join(dirPath, "count")).SetEcho(false)
+}
+
+func getCount(dirPath string) int {
+	buf, err := os.ReadFile(filepath.Join(dirPath, "count"))
+	if err != nil {
+		return 0
+	}
+
+	return strings.Count(string(buf), "\n")
+}
+
+func (*CacheSuite) TestSetCache(g *WithT) {
+	cache := shellz.NewCache("dir")
+	g.Expect(cache.GetDirPath()).To(Equal("dir"))
+	g.Expect(cache.GetMaxBytes()).To(BeEquivalentTo(shellz.DefaultCacheMaxBytes))
+	g.Expect(cache.SetMaxBytes(10).GetMaxBytes()).To(BeEquivalentTo(10))
+
+	c := shellz.NewCommand("cmd")
+	g.Expect(c.GetCache()).To(BeNil())
+	g.Expect(c.GetCacheOptions()).To(Equal(&shellz.CacheOptions{}))
+
+	opts := &shellz.CacheOptions{Inputs: []string{"a"}, EnvKeys: []string{"K"}}
+	cc := c.SetCache(cache, opts)
+	g.Expect(cc.GetCache()).To(BeIdenticalTo(cache))
+	g.Expect(cc.GetCacheOptions()).To(Equal(opts))
+	g.Expect(cc.GetCacheOptions()).ToNot(BeIdenticalTo(opts))
+	g.Expect(c.GetCache()).To(BeNil())
+}
+
+func (*CacheSuite) TestRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	cache := shellz.NewCache(filepath.Join(dirPath, "cache"))
+	c := newCountingCommand(dirPath, "echo out; echo err >&2").SetCache(cache, nil)
+
+	g.Expect(c.Run()).To(Succeed())
+	g.Expect(c.Run()).To(Succeed())
+	g.Expect(getCount(dirPath)).To(Equal(1))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("out\nout\n"))
+	g.Expect(errBuf).To(Equal("err\nerr\n"))
+	g.Expect(cache.MustGetSize()).To(BeNumerically(">", 0))
+}
+
+func (*CacheSuite) TestOutput(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	c := newCountingCommand(dirPath, "echo out").SetCache(shellz.NewCache(filepath.Join(dirPath, "cache")), nil)
+
+	for range 2 {
+		g.Expect(c.OutputString(false)).To(Equal("out\n"))
+		g.Expect(c.CombinedOutputString()).To(Equal("out\n"))
+		g.Expect(c.MustOutputLines(false, nil)).To(Equal([]string{"out"}))
+	}
+
+	lines := make([]string, 0)
+
+	for range 2 {
+		g.Expect(c.Lines(func(line string) { lines = append(lines, line) })).To(Succeed())
+	}
+
+	g.Expect(lines).To(Equal([]string{"out", "out"}))
+	g.Expect(getCount(dirPath)).To(Equal(3))
+}
+
+func (*CacheSuite) TestStart(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	c := newCountingCommand(dirPath, "echo out").SetCache(shellz.NewCache(filepath.Join(dirPath, "cache")), nil)
+
+	for range 2 {
+		p, err := c.Start()
+		g.Expect(err).To(Succeed())
+		g.Expect(p.Wait()).To(Succeed())
+	}
+
+	g.Expect(getCount(dirPath)).To(Equal(1))
+}
+
+func (*CacheSuite) TestExitCode(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	c := newCountingCommand(dirPath, "echo out; echo fail >&2; exit 3").
+		SetCache(shellz.NewCache(filepath.Join(dirPath, "cache")), nil)
+
+	for range 2 {
+		out, err := c.Output(false)
+		g.Expect(out).To(BeNil())
+
+		eErr, ok := errorz.As[*shellz.ExecutionError](err)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(eErr.GetExitCode()).To(Equal(3))
+		g.Expect(eErr.GetCapturedStderr()).To(Equal("fail\n"))
+	}
+
+	g.Expect(getCount(dirPath)).To(Equal(1))
+}
+
+func (*CacheSuite) TestStdinFile(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	inFilePath := filez.MustWriteFileString(filepath.Join(dirPath, "in.txt"), 0777, 0666, "a\n")
+	c := newCountingCommand(dirPath, "cat").
+		SetStdinFile(inFilePath).
+		SetCache(shellz.NewCache(filepath.Join(dirPath, "cache")), nil)
+
+	g.Expect(c.OutputString(false)).To(Equal("a\n"))
+	g.Expect(c.OutputString(false)).To(Equal("a\n"))
+	g.Expect(getCount(dirPath)).To(Equal(1))
+
+	filez.MustWriteFileString(inFilePath, 0777, 0666, "b\n")
+	g.Expect(c.OutputString(false)).To(Equal("b\n"))
+	g.Expect(c.OutputString(false)).To(Equal("b\n"))
+	g.Expect(getCount(dirPath)).To(Equal(2))
+}
+
+func (*CacheSuite) TestNotStored(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	cache := shellz.NewCache(filepath.Join(dirPath, "cache"))
+	c := newCountingCommand(dirPath, "kill -KILL $$").SetCache(cache, nil)
+
+	for range 2 {
+		g.Expect(c.Run()).ToNot(Succeed())
+	}
+
+	g.Expect(getCount(dirPath)).To(Equal(2))
+	g.Expect(cache.MustGetSize()).To(BeZero())
+
+	shellz.SetDryRun(true)
+	defer shellz.SetDryRun(false)
+
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	g.Expect(newCountingCommand(dirPath, "echo out").SetCache(cache, nil).Run()).To(Succeed())
+	g.Expect(cache.MustGetSize()).To(BeZero())
+}
+
+func (*CacheSuite) TestKey(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	inputsDirPath := filepath.Join(dirPath, "inputs")
+	filez.MustWriteFileString(filepath.Join(inputsDirPath, "a.txt"), 0777, 0666, "a")
+	filez.MustWriteFileString(filepath.Join(inputsDirPath, "sub", "b.txt"), 0777, 0666, "b")
+
+	cache := shellz.NewCache(filepath.Join(dirPath, "cache"))
+	opts := &shellz.CacheOptions{Inputs: []string{"*.txt", "sub", "missing/*"}, EnvKeys: []string{"K"}}
+
+	run := func(c *shellz.Command) {
+		g.Expect(c.SetDir(inputsDirPath).SetCache(cache, opts).Output(false)).Error().To(Succeed())
+	}
+
+	c := newCountingCommand(dirPath, "cat")
+
+	run(c)
+	run(c)
+	g.Expect(getCount(dirPath)).To(Equal(1))
+
+	run(c.SetEnv("OTHER", "V"))
+	run(c.SetEnv("OTHER", "V"))
+	g.Expect(getCount(dirPath)).To(Equal(2))
+
+	run(c.SetEnv("K", "V"))
+	run(c.SetEnv("K", "V"))
+	g.Expect(getCount(dirPath)).To(Equal(3))
+
+	run(c.UnsetEnv("OTHER"))
+	run(c.SetEnvMode(shellz.EnvModeAllowlist, "PATH"))
+	run(c.SetEnvMode(shellz.EnvModeAllowlist, "PATH", "HOME"))
+	g.Expect(getCount(dirPath)).To(Equal(6))
+
+	run(c.SetIn(strings.NewReader("in")))
+	run(c.SetIn(strings.NewReader("in")))
+	g.Expect(getCount(dirPath)).To(Equal(7))
+
+	filez.MustWriteFileString(filepath.Join(inputsDirPath, "a.txt"), 0777, 0666, "aa")
+	run(c)
+	g.Expect(getCount(dirPath)).To(Equal(8))
+
+	filez.MustWriteFileString(filepath.Join(inputsDirPath, "sub", "c.txt"), 0777, 0666, "c")
+	run(c)
+	g.Expect(getCount(dirPath)).To(Equal(9))
+
+	filez.MustWriteFileString(filepath.Join(inputsDirPath, "missing", "d"), 0777, 0666, "d")
+	run(c)
+	run(c)
+	g.Expect(getCount(dirPath)).To(Equal(10))
+
+	run(c.AddSecretParams("s3cr3t"))
+	run(c.AddSecretParams("s3cr3t"))
+	run(c.SetSecretEnv("TOKEN", "t0k3n"))
+	run(c.SetSecretEnv("TOKEN", "t0k3n"))
+	g.Expect(getCount(dirPath)).To(Equal(14))
+
+	g.Expect(c.SetCache(cache, &shellz.CacheOptions{Inputs: []string{"["}}).Run()).
+		To(MatchError(ContainSubstring("syntax error in pattern")))
+}
+
+func (*CacheSuite) TestInvalidate(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	cache := shellz.NewCache(filepath.Join(dirPath, "cache"))
+	c1 := newCountingCommand(dirPath, "echo 1").SetCache(cache, nil)
+	c2 := newCountingCommand(dirPath, "echo 2").SetCache(cache, nil)
+
+	g.Expect(c1.OutputString(false)).To(Equal("1\n"))
+	g.Expect(c2.OutputString(false)).To(Equal("2\n"))
+	g.Expect(getCount(dirPath)).To(Equal(2))
+
+	cache.MustInvalidate(c1)
+	g.Expect(c1.OutputString(false)).To(Equal("1\n"))
+	g.Expect(c2.OutputString(false)).To(Equal("2\n"))
+	g.Expect(getCount(dirPath)).To(Equal(3))
+
+	cache.MustClear()
+	g.Expect(cache.MustGetSize()).To(BeZero())
+	g.Expect(c1.OutputString(false)).To(Equal("1\n"))
+	g.Expect(c2.OutputString(false)).To(Equal("2\n"))
+	g.Expect(getCount(dirPath)).To(Equal(5))
+}
+
+func (*CacheSuite) TestPrune(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	cache := shellz.NewCache(filepath.Join(dirPath, "cache"))
+	c1 := newCountingCommand(dirPath, "echo 1").SetCache(cache, nil)
+	c2 := newCountingCommand(dirPath, "echo 2").SetCache(cache, nil)
+
+	g.Expect(c1.OutputString(false)).To(Equal("1\n"))
+	size := cache.MustGetSize()
+	g.Expect(size).To(BeNumerically(">", 0))
+
+	for _, filePath := range filez.MustListRegularFilePaths(cache.GetDirPath()) {
+		filePath = filepath.Join(cache.GetDirPath(), filePath)
+		g.Expect(os.Chtimes(filePath, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))).To(Succeed())
+	}
+
+	cache.SetMaxBytes(size)
+	g.Expect(c2.OutputString(false)).To(Equal("2\n"))
+	g.Expect(cache.MustGetSize()).To(BeNumerically("<=", size))
+
+	g.Expect(c2.OutputString(false)).To(Equal("2\n"))
+	g.Expect(c1.OutputString(false)).To(Equal("1\n"))
+	g.Expect(getCount(dirPath)).To(Equal(3))
+
+	cache.SetMaxBytes(0)
+	cache.MustPrune()
+	g.Expect(cache.MustGetSize()).To(BeNumerically("<=", size))
+
+	cache.SetMaxBytes(1)
+	cache.MustPrune()
+	g.Expect(cache.MustGetSize()).To(BeZero())
+}
@@ -0,0 +1,237 @@
+package shellz
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultCaptureLimit is a reasonable capture limit for [*Command.SetCaptureLimit].
+const DefaultCaptureLimit = 64 * 1024
+
+// ringBuffer is an [io.Writer] that only retains the last "limit" bytes written to it.
+type ringBuffer struct {
+	m     *sync.Mutex
+	limit int
+	buf   []byte
+}
+
+func newRingBuffer(limit int) *ringBuffer {
+	return &ringBuffer{
+		m:     &sync.Mutex{},
+		limit: limit,
+		buf:   make([]byte, 0),
+	}
+}
+
+// Write implements the [io.Writer] interface.
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if len(p) >= r.limit {
+		r.buf = append(r.buf[:0], p[len(p)-r.limit:]...)
+		return len(p), nil
+	}
+
+	if overflow := len(r.buf) + len(p) - r.limit; overflow > 0 {
+		r.buf = append(r.buf[:0], r.buf[overflow:]...)
+	}
+
+	r.buf = append(r.buf, p...)
+	return len(p), nil
+}
+
+// String returns the retained bytes as a string.
+func (r *ringBuffer) String() string {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return string(r.buf)
+}
+
+// outputLimit calls "onExceeded" once when more than "max" bytes have been written to the streams sharing it.
+type outputLimit struct {
+	max        int64
+	n          *atomic.Int64
+	isExceeded *atomic.Bool
+	onExceeded func()
+}
+
+func (l *outputLimit) add(n int) {
+	if l != nil && l.n.Add(int64(n)) > l.max && l.isExceeded.CompareAndSwap(false, true) {
+		l.onExceeded()
+	}
+}
+
+// captureStream counts the bytes written to it, and optionally retains their tail.
+type captureStream struct {
+	n           *atomic.Int64
+	buf         *ringBuffer
+	limit       *outputLimit
+	isUncounted bool
+}
+
+// Write implements the [io.Writer] interface.
+func (s *captureStream) Write(p []byte) (int, error) {
+	s.n.Add(int64(len(p)))
+	s.limit.add(len(p))
+
+	if s.buf != nil {
+		_, _ = s.buf.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// outputCapture retains the tail of the standard output and error of an execution, and counts their bytes.
+// A nil *outputCapture is valid and neither captures nor counts anything.
+type outputCapture struct {
+	stdout            *captureStream
+	stderr            *captureStream
+	limit             *outputLimit
+	isRetained        bool
+	isStderrDiscarded bool
+}
+
+// newOutputCapture initializes a new *outputCapture that retains up to "limit" bytes per stream (if positive),
+// and counts bytes if "isCounted" is true. It returns nil if it would do neither.
+func newOutputCapture(limit int, isCounted bool) *outputCapture {
+	if limit <= 0 && !isCounted {
+		return nil
+	}
+
+	c := &outputCapture{
+		stdout:     &captureStream{n: &atomic.Int64{}},
+		stderr:     &captureStream{n: &atomic.Int64{}},
+		isRetained: limit > 0,
+	}
+
+	if c.isRetained {
+		c.stdout.buf = newRingBuffer(limit)
+		c.stderr.buf = newRingBuffer(limit)
+	}
+
+	return c
+}
+
+// setOutputLimit calls "onExceeded" once when more than "max" bytes of output (if positive) have been captured.
+func (c *outputCapture) setOutputLimit(max int64, onExceeded func()) {
+	if c == nil || max <= 0 {
+		return
+	}
+
+	c.limit = &outputLimit{
+		max:        max,
+		n:          &atomic.Int64{},
+		isExceeded: &atomic.Bool{},
+		onExceeded: onExceeded,
+	}
+
+	c.stdout.limit = c.limit
+	c.stderr.limit = c.limit
+}
+
+// isOutputLimitExceeded returns true if the output limit was exceeded.
+func (c *outputCapture) isOutputLimitExceeded() bool {
+	return c != nil && c.limit != nil && c.limit.isExceeded.Load()
+}
+
+func (c *outputCapture) getStream(stream Stream) *captureStream {
+	if stream == StreamStderr {
+		return c.stderr
+	}
+
+	return c.stdout
+}
+
+// tee returns a writer that writes to "w" (which can be nil) and captures the given stream.
+func (c *outputCapture) tee(w io.Writer, stream Stream) io.Writer {
+	if c == nil {
+		return w
+	}
+
+	if w == nil {
+		// Retain discarded stderr anyway, like [exec.Cmd.Output] would, so that it can be reported on error.
+		if stream == StreamStderr && !c.isRetained {
+			c.isStderrDiscarded = true
+			c.stderr.buf = newRingBuffer(DefaultCaptureLimit)
+		}
+
+		return c.getStream(stream)
+	}
+
+	return io.MultiWriter(w, c.getStream(stream))
+}
+
+// teeFile is like [*outputCapture.tee], but if the output only needs to be counted (e.g. for observers), it returns
+// "f" itself and marks the stream as uncounted instead. This way the child process inherits the file (e.g. a terminal)
+// rather than a pipe, and keeps its colors, terminal detection and buffering behavior.
+func (c *outputCapture) teeFile(f *os.File, stream Stream) io.Writer {
+	if c != nil && !c.isRetained && c.limit == nil {
+		c.getStream(stream).isUncounted = true
+		return f
+	}
+
+	return c.tee(f, stream)
+}
+
+// teeReader returns a reader that reads from "r" and captures the given stream.
+func (c *outputCapture) teeReader(r io.Reader, stream Stream) io.Reader {
+	if c == nil {
+		return r
+	}
+
+	return io.TeeReader(r, c.getStream(stream))
+}
+
+// write captures the given output for the given stream.
+func (c *outputCapture) write(buf []byte, stream Stream) {
+	if c != nil {
+		_, _ = c.getStream(stream).Write(buf)
+	}
+}
+
+// setUncounted marks the output as not observable, e.g. because it is written directly to the terminal.
+func (c *outputCapture) setUncounted() {
+	if c != nil {
+		c.stdout.isUncounted = true
+		c.stderr.isUncounted = true
+	}
+}
+
+// getBytes returns the number of bytes captured for the given stream, or -1 if not counted.
+func (c *outputCapture) getBytes(stream Stream) int64 {
+	if c == nil || c.getStream(stream).isUncounted {
+		return -1
+	}
+
+	return c.getStream(stream).n.Load()
+}
+
+// getStderr returns the retained tail of the standard error, if any.
+func (c *outputCapture) getStderr() string {
+	if c == nil || c.stderr.buf == nil {
+		return ""
+	}
+
+	return c.stderr.buf.String()
+}
+
+// apply populates the captured output of the given [*ExecutionError], redacting the secrets of the given [*Command].
+func (c *outputCapture) apply(e *ExecutionError, cmd *Command) {
+	if c == nil {
+		return
+	}
+
+	if c.isRetained {
+		e.capturedStdout = cmd.redact(c.stdout.buf.String())
+	}
+
+	if c.isRetained || c.isStderrDiscarded {
+		if stderr := c.stderr.buf.String(); stderr != "" {
+			e.capturedStderr = cmd.redact(stderr)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package shellz_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type CaptureSuite struct {
+	// intentionally empty
+}
+
+func TestCaptureSuite(t *testing.T) {
+	fixturez.RunSuite(t, &CaptureSuite{})
+}
+
+func (*CaptureSuite) TestSetCaptureLimit(g *WithT) {
+	c := shellz.NewCommand("cmd")
+	g.Expect(c.GetCaptureLimit()).To(Equal(0))
+	g.Expect(c.SetCaptureLimit(shellz.DefaultCaptureLimit).GetCaptureLimit()).To(Equal(shellz.DefaultCaptureLimit))
+	g.Expect(c.GetCaptureLimit()).To(Equal(0))
+}
+
+func (*CaptureSuite) TestRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	err := shellz.NewCommand("sh", "-c", "echo 0123456789; echo abcdefghij >&2; exit 1").
+		SetEcho(false).
+		SetCaptureLimit(5).
+		Run()
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetCapturedStdout()).To(Equal("6789\n"))
+	g.Expect(eErr.GetCapturedStderr()).To(Equal("ghij\n"))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("0123456789\n"))
+	g.Expect(errBuf).To(Equal("abcdefghij\n"))
+}
+
+func (*CaptureSuite) TestRun_Disabled(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	err := shellz.NewCommand("sh", "-c", "echo out; echo err >&2; exit 1").
+		SetEcho(false).
+		Run()
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetCapturedStdout()).To(BeEmpty())
+	g.Expect(eErr.GetCapturedStderr()).To(BeEmpty())
+}
+
+func (*CaptureSuite) TestOutput(g *WithT) {
+	for _, echoStderr := range []bool{false, true} {
+		g.Expect(func() {
+			outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+			defer outz.ResetOutputCapture()
+
+			_, err := shellz.NewCommand("sh", "-c", "echo out; echo err >&2; exit 1").
+				SetCaptureLimit(shellz.DefaultCaptureLimit).
+				Output(echoStderr)
+
+			eErr, ok := errorz.As[*shellz.ExecutionError](err)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(eErr.GetCapturedStdout()).To(Equal("out\n"))
+			g.Expect(eErr.GetCapturedStderr()).To(Equal("err\n"))
+
+			_, errBuf := outz.MustEndOutputCapture()
+			g.Expect(errBuf).To(Equal(map[bool]string{false: "", true: "err\n"}[echoStderr]))
+		}).ToNot(Panic(), fmt.Sprintf("echoStderr: %v", echoStderr))
+	}
+}
+
+func (*CaptureSuite) TestCombinedOutput(g *WithT) {
+	_, err := shellz.NewCommand("sh", "-c", "echo out; echo err >&2; exit 1").
+		SetCaptureLimit(shellz.DefaultCaptureLimit).
+		CombinedOutput()
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetCapturedStdout()).To(Equal("out\nerr\n"))
+	g.Expect(eErr.GetCapturedStderr()).To(BeEmpty())
+}
+
+func (*CaptureSuite) TestLines(g *WithT) {
+	lines := make([]string, 0)
+
+	err := shellz.NewCommand("sh", "-c", fmt.Sprintf("echo %v; echo err >&2; exit 1", strings.Repeat("x", 100))).
+		SetEcho(false).
+		SetCaptureLimit(10).
+		Lines(func(line string) { lines = append(lines, line) })
+
+	g.Expect(lines).To(ConsistOf(strings.Repeat("x", 100), "err"))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetCapturedStdout()).To(Equal(strings.Repeat("x", 9) + "\n"))
+	g.Expect(eErr.GetCapturedStderr()).To(Equal("err\n"))
+}
+
+func (*CaptureSuite) TestRun_Background(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	o := newTestObserver()
+	startTime := time.Now()
+
+	// The background process holds the output pipe open after the command exits.
+	g.Expect(shellz.NewCommand("sh", "-c", "echo out; sleep 5 &").
+		SetEcho(false).
+		SetGracePeriod(0).
+		SetCaptureLimit(10).
+		AddObserver(o).
+		Run()).To(Succeed())
+
+	g.Expect(time.Since(startTime)).To(BeNumerically("<", 4*time.Second))
+	g.Expect(o.exits[0].StdoutBytes).To(Equal(int64(4)))
+
+	outBuf, _ := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("out\n"))
+}
@@ -0,0 +1,171 @@
+package shellz
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+var (
+	_ consolez.DetailedError = (*ExecutionError)(nil)
+)
+
+// IsNotFound returns true if the command failed to start because its executable was not found.
+func (e *ExecutionError) IsNotFound() bool {
+	if errors.Is(e.err, exec.ErrNotFound) {
+		return true
+	}
+
+	if _, ok := errorz.As[*redirectionError](e.err); ok {
+		return false
+	}
+
+	pErr, ok := errorz.As[*fs.PathError](e.err)
+	return ok && pErr.Op != "chdir" && errors.Is(pErr.Err, fs.ErrNotExist)
+}
+
+// IsPermissionDenied returns true if the command failed to start because of insufficient permissions, e.g. because
+// its executable is not executable or its working directory is not accessible.
+func (e *ExecutionError) IsPermissionDenied() bool {
+	return errors.Is(e.err, fs.ErrPermission)
+}
+
+// IsMissingDir returns true if the command failed to start because its working directory, or the directory of one of
+// its output files (see [*Command.SetStdoutFile]), does not exist.
+func (e *ExecutionError) IsMissingDir() bool {
+	if e.isMissingOutputDir() {
+		return true
+	}
+
+	pErr, ok := errorz.As[*fs.PathError](e.err)
+	return ok && pErr.Op == "chdir" && errors.Is(pErr.Err, fs.ErrNotExist)
+}
+
+func (e *ExecutionError) isMissingOutputDir() bool {
+	rErr, ok := errorz.As[*redirectionError](e.err)
+	return ok && rErr.isOutput && errors.Is(rErr.err, fs.ErrNotExist)
+}
+
+// IsSignaled returns true if the process was terminated by a signal (see [*ExecutionError.GetSignal]).
+func (e *ExecutionError) IsSignaled() bool {
+	return e.signal != nil
+}
+
+// GetSignal returns the signal that terminated the process, or nil if it was not terminated by a signal.
+func (e *ExecutionError) GetSignal() os.Signal {
+	return e.signal
+}
+
+// GetDuration returns the run time of the command (zero if it failed to start).
+func (e *ExecutionError) GetDuration() time.Duration {
+	return e.duration
+}
+
+// GetSummary returns a one-line human-readable description of the failure.
+func (e *ExecutionError) GetSummary() string {
+	switch {
+	case e.IsNotFound():
+		return fmt.Sprintf("%q failed to start: executable not found", e.shortName)
+	case e.isMissingOutputDir():
+		return fmt.Sprintf("%q failed to start: output file directory does not exist", e.shortName)
+	case e.IsMissingDir():
+		return fmt.Sprintf("%q failed to start: working directory %q does not exist", e.shortName, e.dir)
+	case e.IsPermissionDenied():
+		return fmt.Sprintf("%q failed to start: permission denied", e.shortName)
+	case e.IsNoTerminal():
+		return fmt.Sprintf("%q failed to start: interactive mode requires a terminal", e.shortName)
+	case e.isTimedOut && e.timeout > 0:
+		return fmt.Sprintf("%q timed out after %v", e.shortName, e.timeout)
+	case e.isTimedOut:
+		return fmt.Sprintf("%q timed out%v", e.shortName, e.getAfter())
+	case e.isCanceled:
+		return fmt.Sprintf("%q was canceled%v", e.shortName, e.getAfter())
+	}
+
+	if lErr, ok := errorz.As[*LimitError](e.err); ok {
+		return fmt.Sprintf("%q exceeded its %v limit (%v)%v", e.shortName, lErr.kind, lErr.limit, e.getAfter())
+	}
+
+	switch {
+	case e.signal != nil:
+		return fmt.Sprintf("%q was killed by signal %q%v", e.shortName, e.signal.String(), e.getAfter())
+	case e.exitCode >= 0:
+		return fmt.Sprintf("%q exited with code %v%v", e.shortName, e.exitCode, e.getAfter())
+	default:
+		return fmt.Sprintf("%q failed: %v", e.shortName, e.message)
+	}
+}
+
+// GetDetails implements the [consolez.DetailedError] interface.
+func (e *ExecutionError) GetDetails() []*consolez.ErrorDetail {
+	details := []*consolez.ErrorDetail{
+		{Label: "Command", Value: e.commandLine},
+		{Label: "Error", Value: e.message},
+	}
+
+	if e.exitCode >= 0 {
+		details = append(details, &consolez.ErrorDetail{Label: "Exit Code", Value: fmt.Sprintf("%v", e.exitCode)})
+	}
+
+	if e.signal != nil {
+		details = append(details, &consolez.ErrorDetail{Label: "Signal", Value: e.signal.String()})
+	}
+
+	if e.isTimedOut && e.timeout > 0 {
+		details = append(details, &consolez.ErrorDetail{Label: "Timeout", Value: e.timeout.String()})
+	}
+
+	if e.duration > 0 {
+		details = append(details, &consolez.ErrorDetail{Label: "Duration", Value: e.duration.Round(time.Millisecond).String()})
+	}
+
+	if e.resourceUsage != nil {
+		details = append(details, &consolez.ErrorDetail{Label: "Resources", Value: e.resourceUsage.String()})
+	}
+
+	if stdout := strings.TrimRight(e.capturedStdout, "\n"); stdout != "" {
+		details = append(details, &consolez.ErrorDetail{Label: "Stdout", Value: stdout})
+	}
+
+	if stderr := strings.TrimRight(e.capturedStderr, "\n"); stderr != "" {
+		details = append(details, &consolez.ErrorDetail{Label: "Stderr", Value: stderr})
+	}
+
+	return details
+}
+
+// setProcessInfo records the run time and resource usage of the process, if one was started.
+func (e *ExecutionError) setProcessInfo(cmd *exec.Cmd, startTime time.Time, usage *ResourceUsage) {
+	if cmd.Process != nil {
+		e.duration = time.Since(startTime)
+		e.resourceUsage = usage
+	}
+}
+
+func (e *ExecutionError) getAfter() string {
+	if e.duration <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" after %v", e.duration.Round(time.Millisecond))
+}
+
+// getShortName returns a short name for the command, e.g. "go test" for "/usr/bin/go test -v ./...".
+func (c *Command) getShortName() string {
+	name := filepath.Base(c.redact(c.cmd))
+
+	if len(c.params) > 0 && c.params[0] != "" && !strings.HasPrefix(c.params[0], "-") && !strings.ContainsAny(c.params[0], "/ ") {
+		name += " " + c.redact(c.params[0])
+	}
+
+	return name
+}
@@ -0,0 +1,173 @@
+package shellz_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/consolez"
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type ClassifySuite struct {
+	// intentionally empty
+}
+
+func TestClassifySuite(t *testing.T) {
+	fixturez.RunSuite(t, &ClassifySuite{})
+}
+
+func mustGetExecutionError(g *WithT, err error) *shellz.ExecutionError {
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	return eErr
+}
+
+func (*ClassifySuite) TestNotFound(g *WithT) {
+	for _, cmd := range []string{"shellz-not-found", "/shellz-not-found/cmd"} {
+		eErr := mustGetExecutionError(g, shellz.NewCommand(cmd, "sub").SetEcho(false).Run())
+		g.Expect(eErr.IsNotFound()).To(BeTrue())
+		g.Expect(eErr.IsPermissionDenied()).To(BeFalse())
+		g.Expect(eErr.IsMissingDir()).To(BeFalse())
+		g.Expect(eErr.IsSignaled()).To(BeFalse())
+		g.Expect(eErr.GetDuration()).To(BeZero())
+		g.Expect(eErr.GetSummary()).To(Equal(fmt.Sprintf(`"%v sub" failed to start: executable not found`, filepath.Base(cmd))))
+	}
+}
+
+func (*ClassifySuite) TestPermissionDenied(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	filePath := filez.MustWriteFile(filepath.Join(dirPath, "script"), 0777, 0666, []byte("#!/bin/sh\n"))
+
+	eErr := mustGetExecutionError(g, shellz.NewCommand(filePath).SetEcho(false).Run())
+	g.Expect(eErr.IsNotFound()).To(BeFalse())
+	g.Expect(eErr.IsPermissionDenied()).To(BeTrue())
+	g.Expect(eErr.IsMissingDir()).To(BeFalse())
+	g.Expect(eErr.GetSummary()).To(Equal(`"script" failed to start: permission denied`))
+}
+
+func (*ClassifySuite) TestMissingDir(g *WithT) {
+	eErr := mustGetExecutionError(g, shellz.NewCommand("true").SetDir("/shellz-not-found").SetEcho(false).Run())
+	g.Expect(eErr.IsNotFound()).To(BeFalse())
+	g.Expect(eErr.IsPermissionDenied()).To(BeFalse())
+	g.Expect(eErr.IsMissingDir()).To(BeTrue())
+	g.Expect(eErr.GetSummary()).To(Equal(`"true" failed to start: working directory "/shellz-not-found" does not exist`))
+
+	eErr = mustGetExecutionError(g, shellz.NewCommand("true").SetStdoutFile("/shellz-not-found/out.txt", false).SetEcho(false).Run())
+	g.Expect(eErr.IsNotFound()).To(BeFalse())
+	g.Expect(eErr.IsMissingDir()).To(BeTrue())
+	g.Expect(eErr.GetSummary()).To(Equal(`"true" failed to start: output file directory does not exist`))
+
+	eErr = mustGetExecutionError(g, shellz.NewCommand("true").SetStdinFile("/shellz-not-found").SetEcho(false).Run())
+	g.Expect(eErr.IsNotFound()).To(BeFalse())
+	g.Expect(eErr.IsMissingDir()).To(BeFalse())
+	g.Expect(eErr.GetSummary()).To(Equal(`"true" failed: open /shellz-not-found: no such file or directory`))
+}
+
+func (*ClassifySuite) TestSignaled(g *WithT) {
+	eErr := mustGetExecutionError(g, shellz.NewCommand("sh", "-c", "kill -KILL $$").SetEcho(false).Run())
+	g.Expect(eErr.IsSignaled()).To(BeTrue())
+	g.Expect(eErr.GetSignal()).To(Equal(syscall.SIGKILL))
+	g.Expect(eErr.GetExitCode()).To(Equal(-1))
+	g.Expect(eErr.GetDuration()).To(BeNumerically(">", 0))
+	g.Expect(eErr.GetSummary()).To(MatchRegexp(`^"sh" was killed by signal "killed" after \S+$`))
+}
+
+func (*ClassifySuite) TestExited(g *WithT) {
+	eErr := mustGetExecutionError(g, shellz.NewCommand("sh", "-c", "sleep 0.1; exit 3").SetEcho(false).Run())
+	g.Expect(eErr.IsSignaled()).To(BeFalse())
+	g.Expect(eErr.GetSignal()).To(BeNil())
+	g.Expect(eErr.GetDuration()).To(BeNumerically(">=", 100*time.Millisecond))
+	g.Expect(eErr.GetSummary()).To(MatchRegexp(`^"sh" exited with code 3 after \d+ms$`))
+}
+
+func (*ClassifySuite) TestTimedOut(g *WithT) {
+	eErr := mustGetExecutionError(g, shellz.NewCommand("sleep", "5").SetTimeout(100*time.Millisecond).SetEcho(false).Run())
+	g.Expect(eErr.IsTimedOut()).To(BeTrue())
+	g.Expect(eErr.IsSignaled()).To(BeTrue())
+	g.Expect(eErr.GetSummary()).To(Equal(`"sleep 5" timed out after 100ms`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	eErr = mustGetExecutionError(g, shellz.NewCommand("sleep", "5").SetEcho(false).RunContext(ctx))
+	g.Expect(eErr.GetSummary()).To(MatchRegexp(`^"sleep 5" timed out after \d+ms$`))
+}
+
+func (*ClassifySuite) TestCanceled(g *WithT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	eErr := mustGetExecutionError(g, shellz.NewCommand("sleep", "5").SetEcho(false).RunContext(ctx))
+	g.Expect(eErr.IsCanceled()).To(BeTrue())
+	g.Expect(eErr.GetSummary()).To(MatchRegexp(`^"sleep 5" was canceled after \d+ms$`))
+}
+
+func (*ClassifySuite) TestLimit(g *WithT) {
+	_, err := shellz.NewCommand("echo", "0123456789").
+		SetResourceLimits(&shellz.ResourceLimits{MaxOutputBytes: 10}).
+		SetEcho(false).
+		Output(false)
+
+	eErr := mustGetExecutionError(g, err)
+	g.Expect(eErr.GetSummary()).To(MatchRegexp(`^"echo 0123456789" exceeded its output bytes limit \(10 B\) after \S+$`))
+}
+
+func (*ClassifySuite) TestOther(g *WithT) {
+	eErr := shellz.NewExecutionError(fmt.Errorf("custom s3cr3t"), shellz.NewCommand("cmd").AddSecretParams("s3cr3t"))
+	g.Expect(eErr.GetSummary()).To(Equal(`"cmd ***" failed: custom ***`))
+
+	eErr = shellz.NewExecutionError(shellz.NewExitError(2, nil), shellz.NewCommand("cmd"))
+	g.Expect(eErr.GetSummary()).To(Equal(`"cmd" exited with code 2`))
+}
+
+func (*ClassifySuite) TestGetDetails(g *WithT) {
+	err := shellz.NewCommand("sh", "-c", "echo out; echo err >&2; exit 3").
+		SetCaptureLimit(shellz.DefaultCaptureLimit).
+		SetEcho(false).
+		Run()
+
+	details := mustGetExecutionError(g, err).GetDetails()
+	labels := make([]string, 0, len(details))
+
+	for _, d := range details {
+		labels = append(labels, d.Label)
+	}
+
+	g.Expect(labels).To(Equal([]string{"Command", "Error", "Exit Code", "Duration", "Resources", "Stdout", "Stderr"}))
+	g.Expect(details[0].Value).To(Equal("sh -c 'echo out; echo err >&2; exit 3'"))
+	g.Expect(details[1].Value).To(Equal("exit status 3"))
+	g.Expect(details[2].Value).To(Equal("3"))
+	g.Expect(details[5].Value).To(Equal("out"))
+	g.Expect(details[6].Value).To(Equal("err"))
+
+	details = mustGetExecutionError(g, shellz.NewCommand("sleep", "5").SetTimeout(100*time.Millisecond).SetEcho(false).Run()).GetDetails()
+	g.Expect(details[2]).To(Equal(&consolez.ErrorDetail{Label: "Signal", Value: "terminated"}))
+	g.Expect(details[3]).To(Equal(&consolez.ErrorDetail{Label: "Timeout", Value: "100ms"}))
+}
+
+func (*ClassifySuite) TestCLIError(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	err := shellz.NewCommand("shellz-not-found").SetEnv("K", "V").SetEcho(false).Run()
+	consolez.DefaultCLI.Error(errorz.Wrap(err), false)
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(errBuf).To(BeEmpty())
+	g.Expect(outBuf).To(Equal(fmt.Sprintf("\n%v Error\n", consolez.IconCollision) +
+		"\"shellz-not-found\" failed to start: executable not found\n" +
+		"Command: K=V shellz-not-found\n" +
+		"Error:   exec: \"shellz-not-found\": executable file not found in $PATH\n"))
+}
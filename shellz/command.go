@@ -3,13 +3,19 @@ package shellz
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/ibrt/golang-utils/errorz"
 	"github.com/ibrt/golang-utils/memz"
@@ -17,6 +23,11 @@ import (
 	"github.com/ibrt/golang-dev/consolez"
 )
 
+// DefaultGracePeriod is the default time a command is given to exit after SIGTERM before it is killed.
+const DefaultGracePeriod = 10 * time.Second
+
+const minWaitDelay = 100 * time.Millisecond
+
 var (
 	defaultExecutor = &RealExecutor{}
 )
@@ -33,11 +44,11 @@ func RestoreDefaultExecutor() {
 
 // Executor implements the OS-level operations related to a command.
 type Executor interface {
-	ExecCmdCombinedOutput(c *Command, cmd *exec.Cmd) ([]byte, error)
-	ExecCmdOutput(c *Command, cmd *exec.Cmd) ([]byte, error)
-	ExecCmdRun(c *Command, cmd *exec.Cmd) error
-	ExecCmdStart(c *Command, cmd *exec.Cmd) error
-	ExecCmdWait(c *Command, cmd *exec.Cmd) error
+	ExecCmdCombinedOutput(ctx context.Context, c *Command, cmd *exec.Cmd) ([]byte, error)
+	ExecCmdOutput(ctx context.Context, c *Command, cmd *exec.Cmd) ([]byte, error)
+	ExecCmdRun(ctx context.Context, c *Command, cmd *exec.Cmd) error
+	ExecCmdStart(ctx context.Context, c *Command, cmd *exec.Cmd) error
+	ExecCmdWait(ctx context.Context, c *Command, cmd *exec.Cmd) error
 	ExecLookPath(c *Command, file string) (string, error)
 	OSChdir(c *Command, dir string) error
 	SyscallExec(c *Command, argv0 string, argv []string, envv []string) error
@@ -49,27 +60,27 @@ type RealExecutor struct {
 }
 
 // ExecCmdCombinedOutput implements the [Executor] interface.
-func (e *RealExecutor) ExecCmdCombinedOutput(_ *Command, cmd *exec.Cmd) ([]byte, error) {
+func (e *RealExecutor) ExecCmdCombinedOutput(_ context.Context, _ *Command, cmd *exec.Cmd) ([]byte, error) {
 	return cmd.CombinedOutput()
 }
 
 // ExecCmdOutput implements the [Executor] interface.
-func (e *RealExecutor) ExecCmdOutput(_ *Command, cmd *exec.Cmd) ([]byte, error) {
+func (e *RealExecutor) ExecCmdOutput(_ context.Context, _ *Command, cmd *exec.Cmd) ([]byte, error) {
 	return cmd.Output()
 }
 
 // ExecCmdRun implements the [Executor] interface.
-func (e *RealExecutor) ExecCmdRun(_ *Command, cmd *exec.Cmd) error {
+func (e *RealExecutor) ExecCmdRun(_ context.Context, _ *Command, cmd *exec.Cmd) error {
 	return cmd.Run()
 }
 
 // ExecCmdStart implements the [Executor] interface.
-func (e *RealExecutor) ExecCmdStart(_ *Command, cmd *exec.Cmd) error {
+func (e *RealExecutor) ExecCmdStart(_ context.Context, _ *Command, cmd *exec.Cmd) error {
 	return cmd.Start()
 }
 
 // ExecCmdWait implements the [Executor] interface.
-func (e *RealExecutor) ExecCmdWait(_ *Command, cmd *exec.Cmd) error {
+func (e *RealExecutor) ExecCmdWait(_ context.Context, _ *Command, cmd *exec.Cmd) error {
 	return cmd.Wait()
 }
 
@@ -88,6 +99,40 @@ func (e *RealExecutor) SyscallExec(_ *Command, argv0 string, argv []string, envv
 	return syscall.Exec(argv0, argv, envv)
 }
 
+var (
+	_ error = (*ExitError)(nil)
+)
+
+// ExitError describes a simulated command exit, for example from a replayed or fake execution.
+// It is treated like [*exec.ExitError] by [NewExecutionError].
+type ExitError struct {
+	exitCode int
+	stderr   []byte
+}
+
+// NewExitError initializes a new [*ExitError].
+func NewExitError(exitCode int, stderr []byte) *ExitError {
+	return &ExitError{
+		exitCode: exitCode,
+		stderr:   stderr,
+	}
+}
+
+// ExitCode returns the exit code.
+func (e *ExitError) ExitCode() int {
+	return e.exitCode
+}
+
+// GetStderr returns the captured standard error (if available).
+func (e *ExitError) GetStderr() []byte {
+	return e.stderr
+}
+
+// Error implements the error interface.
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %v", e.exitCode)
+}
+
 var (
 	_ error               = (*ExecutionError)(nil)
 	_ errorz.UnwrapSingle = (*ExecutionError)(nil)
@@ -96,37 +141,80 @@ var (
 // ExecutionError describes an error.
 type ExecutionError struct {
 	cmd            string
+	shortName      string
+	commandLine    string
 	params         []string
 	dir            string
 	env            map[string]string
 	exitCode       int
+	signal         os.Signal
+	capturedStdout string
 	capturedStderr string
+	duration       time.Duration
+	timeout        time.Duration
+	isTimedOut     bool
+	isCanceled     bool
+	resourceUsage  *ResourceUsage
+	message        string
 	err            error
 }
 
 // NewExecutionError initializes a new [*ExecutionError].
+// Secrets are redacted from the params, env, dir, captured output and message (see [*Command.AddSecretParams]).
 func NewExecutionError(err error, c *Command) *ExecutionError {
+	err = c.redactError(err)
+
 	e := &ExecutionError{
-		cmd:            c.cmd,
-		params:         memz.ShallowCopySlice(c.params),
-		dir:            c.dir,
-		env:            memz.ShallowCopyMap(c.env),
+		cmd:            c.redact(c.cmd),
+		shortName:      c.getShortName(),
+		commandLine:    c.String(),
+		params:         c.getRedactedParams(),
+		dir:            c.redact(c.dir),
+		env:            c.getRedactedEnv(),
 		exitCode:       -1,
+		signal:         nil,
+		capturedStdout: "",
 		capturedStderr: "",
+		duration:       0,
+		timeout:        c.timeout,
+		isTimedOut:     false,
+		isCanceled:     false,
+		resourceUsage:  nil,
+		message:        c.redact(err.Error()),
 		err:            err,
 	}
 
 	if eErr, ok := errorz.As[*exec.ExitError](err); ok {
 		e.exitCode = eErr.ExitCode()
+		e.signal = getSignal(eErr.ProcessState)
 
 		if len(eErr.Stderr) > 0 {
-			e.capturedStderr = string(eErr.Stderr)
+			e.capturedStderr = c.redact(string(eErr.Stderr))
+		}
+	} else if eErr, ok := errorz.As[*ExitError](err); ok {
+		e.exitCode = eErr.ExitCode()
+
+		if len(eErr.GetStderr()) > 0 {
+			e.capturedStderr = c.redact(string(eErr.GetStderr()))
 		}
 	}
 
 	return e
 }
 
+func newContextExecutionError(ctx context.Context, err error, c *Command) *ExecutionError {
+	e := NewExecutionError(err, c)
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		e.isTimedOut = true
+	case errors.Is(ctx.Err(), context.Canceled):
+		e.isCanceled = true
+	}
+
+	return e
+}
+
 // GetCommand returns the originating command.
 func (e *ExecutionError) GetCommand() string {
 	return e.cmd
@@ -152,14 +240,44 @@ func (e *ExecutionError) GetExitCode() int {
 	return e.exitCode
 }
 
+// GetCapturedStdout returns the tail of the originating standard output (if captured, see [*Command.SetCaptureLimit]).
+func (e *ExecutionError) GetCapturedStdout() string {
+	return e.capturedStdout
+}
+
 // GetCapturedStderr returns the originating captured standard error (if available).
 func (e *ExecutionError) GetCapturedStderr() string {
 	return e.capturedStderr
 }
 
+// IsTimedOut returns true if the command was terminated because its timeout or context deadline expired.
+func (e *ExecutionError) IsTimedOut() bool {
+	return e.isTimedOut
+}
+
+// IsCanceled returns true if the command was terminated because its context was canceled.
+func (e *ExecutionError) IsCanceled() bool {
+	return e.isCanceled
+}
+
+// GetResourceUsage returns the resources used by the originating process (nil if not available, e.g. if the command
+// failed to start).
+func (e *ExecutionError) GetResourceUsage() *ResourceUsage {
+	return e.resourceUsage
+}
+
 // Error implements the error interface.
 func (e *ExecutionError) Error() string {
-	return "execution error: " + e.err.Error()
+	switch {
+	case e.isTimedOut && e.timeout > 0:
+		return fmt.Sprintf("execution error: timed out after %v: %v", e.timeout, e.message)
+	case e.isTimedOut:
+		return "execution error: timed out: " + e.message
+	case e.isCanceled:
+		return "execution error: canceled: " + e.message
+	default:
+		return "execution error: " + e.message
+	}
 }
 
 // Unwrap implements the [errorz.UnwrapSingle] interface.
@@ -172,23 +290,51 @@ type Command struct {
 	cmd    string
 	params []string
 
-	dir      string
-	env      map[string]string
-	in       io.Reader
-	echo     *bool
-	executor Executor
+	dir            string
+	env            map[string]string
+	envMode        EnvMode
+	envAllowlist   []string
+	unsetEnv       map[string]struct{}
+	in             io.Reader
+	stdinFile      string
+	stdoutFile     *fileRedirection
+	stderrFile     *fileRedirection
+	echo           *bool
+	timeout        time.Duration
+	gracePeriod    time.Duration
+	isProcessGroup bool
+	isPTY          bool
+	isInteractive  bool
+	retryPolicy    *RetryPolicy
+	captureLimit   int
+	resourceLimits *ResourceLimits
+	secrets        []string
+	secretEnvKeys  map[string]struct{}
+	observers      []Observer
+	middlewares    []Middleware
+	cache          *Cache
+	cacheOptions   *CacheOptions
+	executor       Executor
 }
 
 // NewCommand creates a new [*Command].
 func NewCommand(cmd string, params ...string) *Command {
 	return &Command{
-		cmd:      cmd,
-		params:   memz.ShallowCopySlice(params),
-		env:      make(map[string]string),
-		executor: DefaultExecutor,
+		cmd:           cmd,
+		params:        memz.ShallowCopySlice(params),
+		env:           make(map[string]string),
+		unsetEnv:      make(map[string]struct{}),
+		gracePeriod:   DefaultGracePeriod,
+		secretEnvKeys: make(map[string]struct{}),
+		executor:      DefaultExecutor,
 	}
 }
 
+// GetCmd returns the command.
+func (c *Command) GetCmd() string {
+	return c.cmd
+}
+
 // AddParams adds the given params.
 func (c *Command) AddParams(params ...string) *Command {
 	cc := c.clone()
@@ -268,6 +414,78 @@ func (c *Command) GetEcho() *bool {
 	return memz.Ptr(*c.echo)
 }
 
+// SetTimeout sets the maximum execution time (zero means no timeout).
+// When the timeout expires the command receives SIGTERM, and SIGKILL after the grace period.
+func (c *Command) SetTimeout(timeout time.Duration) *Command {
+	cc := c.clone()
+	cc.timeout = timeout
+	return cc
+}
+
+// GetTimeout returns the current timeout.
+func (c *Command) GetTimeout() time.Duration {
+	return c.timeout
+}
+
+// SetGracePeriod sets the time a command is given to exit after SIGTERM before it is killed.
+func (c *Command) SetGracePeriod(gracePeriod time.Duration) *Command {
+	cc := c.clone()
+	cc.gracePeriod = gracePeriod
+	return cc
+}
+
+// GetGracePeriod returns the current grace period.
+func (c *Command) GetGracePeriod() time.Duration {
+	return c.gracePeriod
+}
+
+// SetProcessGroup configures whether the command is started in its own process group.
+// When enabled, termination signals (including SIGINT, SIGTERM, SIGHUP and SIGQUIT received by the current process)
+// are delivered to the whole process tree, and any leftover processes in the group are killed when the command returns.
+// Note that the command is no longer in the foreground process group of the terminal, so it is stopped (SIGTTIN) if
+// it reads from the terminal: it should not be enabled for commands that prompt the user.
+func (c *Command) SetProcessGroup(isProcessGroup bool) *Command {
+	cc := c.clone()
+	cc.isProcessGroup = isProcessGroup
+	return cc
+}
+
+// GetProcessGroup returns the current process group configuration.
+func (c *Command) GetProcessGroup() bool {
+	return c.isProcessGroup
+}
+
+// SetRetry sets the [*RetryPolicy] for the command (nil disables retries). Pipelines fail if any stage has a retry
+// policy (see [*Pipeline]).
+func (c *Command) SetRetry(retryPolicy *RetryPolicy) *Command {
+	cc := c.clone()
+	cc.retryPolicy = retryPolicy
+	return cc
+}
+
+// GetRetry returns the current [*RetryPolicy].
+func (c *Command) GetRetry() *RetryPolicy {
+	return c.retryPolicy
+}
+
+// SetCaptureLimit enables capturing the last "limit" bytes of standard output and error (zero disables it).
+// Output is still streamed as usual (e.g. to the terminal for [*Command.Run]), and the captured output is made available
+// by [*ExecutionError.GetCapturedStdout] and [*ExecutionError.GetCapturedStderr] in all execution modes.
+// For [*Command.CombinedOutput], the combined output is captured as standard output.
+// Note that for [*Command.Run] the output is then written to the terminal through a pipe, so the command no longer
+// detects a terminal (e.g. to enable colors); if background processes keep the pipe open after the command exits, their
+// output is discarded after the grace period (see [*Command.SetGracePeriod]).
+func (c *Command) SetCaptureLimit(limit int) *Command {
+	cc := c.clone()
+	cc.captureLimit = limit
+	return cc
+}
+
+// GetCaptureLimit returns the current capture limit.
+func (c *Command) GetCaptureLimit() int {
+	return c.captureLimit
+}
+
 // SetExecutor sets the [Executor] for the command.
 func (c *Command) SetExecutor(executor Executor) *Command {
 	cc := c.clone()
@@ -275,48 +493,110 @@ func (c *Command) SetExecutor(executor Executor) *Command {
 	return cc
 }
 
+// GetExecutor returns the [Executor] of the command (excluding its middlewares, see [*Command.Use]).
+func (c *Command) GetExecutor() Executor {
+	return c.executor
+}
+
 // Run runs the command.
 func (c *Command) Run() error {
+	return c.RunContext(context.Background())
+}
+
+// MustRun is like [*Command.Run] but panics on error.
+func (c *Command) MustRun() {
+	errorz.MaybeMustWrap(c.Run())
+}
+
+// RunContext is like [*Command.Run] but terminates the command when the context is done.
+func (c *Command) RunContext(ctx context.Context) error {
 	c.maybeEcho(true)
-	cmd := c.newCmd()
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
 
-	if err := c.executor.ExecCmdRun(c, cmd); err != nil {
-		return NewExecutionError(err, c)
+	if c.isInteractive {
+		cc := c.clone()
+		cc.isProcessGroup = false
+
+		return cc.execute(ctx, func(ctx context.Context, cc *Command, cmd *exec.Cmd, capture *outputCapture, rd *redirections) error {
+			return cc.runInteractive(ctx, cmd, capture, rd)
+		})
 	}
 
-	return nil
+	return c.execute(ctx, func(ctx context.Context, cc *Command, cmd *exec.Cmd, capture *outputCapture, rd *redirections) error {
+		if pty := cc.attachPTY(cmd); pty != nil {
+			defer pty.close()
+			rd.apply(cmd, capture)
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+				_, _ = io.Copy(capture.tee(os.Stdout, StreamStdout), pty.getReader())
+			}()
+
+			err := cc.getExecutor().ExecCmdRun(ctx, cc, cmd)
+			pty.closeSlave()
+			<-done
+			return err
+		}
+
+		cmd.Stdout = capture.teeFile(os.Stdout, StreamStdout)
+		cmd.Stderr = capture.teeFile(os.Stderr, StreamStderr)
+		rd.apply(cmd, capture)
+		cc.maybeSetWaitDelay(cmd)
+		return ignoreWaitDelay(cc.getExecutor().ExecCmdRun(ctx, cc, cmd))
+	})
 }
 
-// MustRun is like [*Command.Run] but panics on error.
-func (c *Command) MustRun() {
-	errorz.MaybeMustWrap(c.Run())
+// MustRunContext is like [*Command.RunContext] but panics on error.
+func (c *Command) MustRunContext(ctx context.Context) {
+	errorz.MaybeMustWrap(c.RunContext(ctx))
 }
 
 // Output runs the command and returns a buffer containing the resulting standard output.
 // Standard error is not redirected.
 func (c *Command) Output(echoStderr bool) ([]byte, error) {
+	return c.OutputContext(context.Background(), echoStderr)
+}
+
+// MustOutput is like [*Command.Output] but panics on error.
+func (c *Command) MustOutput(echoStderr bool) []byte {
+	out, err := c.Output(echoStderr)
+	errorz.MaybeMustWrap(err)
+	return out
+}
+
+// OutputContext is like [*Command.Output] but terminates the command when the context is done.
+func (c *Command) OutputContext(ctx context.Context, echoStderr bool) ([]byte, error) {
 	c.maybeEcho(false)
-	cmd := c.newCmd()
+	var out []byte
 
-	if echoStderr {
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stderr = nil
-	}
+	err := c.execute(ctx, func(ctx context.Context, cc *Command, cmd *exec.Cmd, capture *outputCapture, rd *redirections) error {
+		if echoStderr {
+			cmd.Stderr = capture.teeFile(os.Stderr, StreamStderr)
+		} else {
+			cmd.Stderr = capture.tee(nil, StreamStderr)
+		}
 
-	out, err := c.executor.ExecCmdOutput(c, cmd)
+		rd.apply(cmd, capture)
+
+		if rd.isRedirected(StreamStdout) {
+			return cc.getExecutor().ExecCmdRun(ctx, cc, cmd)
+		}
+
+		var err error
+		out, err = cc.getExecutor().ExecCmdOutput(ctx, cc, cmd)
+		capture.write(out, StreamStdout)
+		return err
+	})
 	if err != nil {
-		return nil, NewExecutionError(err, c)
+		return nil, err
 	}
 
 	return out, nil
 }
 
-// MustOutput is like [*Command.Output] but panics on error.
-func (c *Command) MustOutput(echoStderr bool) []byte {
-	out, err := c.Output(echoStderr)
+// MustOutputContext is like [*Command.OutputContext] but panics on error.
+func (c *Command) MustOutputContext(ctx context.Context, echoStderr bool) []byte {
+	out, err := c.OutputContext(ctx, echoStderr)
 	errorz.MaybeMustWrap(err)
 	return out
 }
@@ -340,19 +620,49 @@ func (c *Command) MustOutputString(echoStderr bool) string {
 
 // CombinedOutput runs the command and returns a buffer containing the resulting combined standard output and error.
 func (c *Command) CombinedOutput() ([]byte, error) {
+	return c.CombinedOutputContext(context.Background())
+}
+
+// MustCombinedOutput is like [*Command.CombinedOutput] but panics on error.
+func (c *Command) MustCombinedOutput() []byte {
+	out, err := c.CombinedOutput()
+	errorz.MaybeMustWrap(err)
+	return out
+}
+
+// CombinedOutputContext is like [*Command.CombinedOutput] but terminates the command when the context is done.
+func (c *Command) CombinedOutputContext(ctx context.Context) ([]byte, error) {
 	c.maybeEcho(false)
+	var out []byte
+
+	err := c.execute(ctx, func(ctx context.Context, cc *Command, cmd *exec.Cmd, capture *outputCapture, rd *redirections) error {
+		if rd.isRedirected(StreamStdout) || rd.isRedirected(StreamStderr) {
+			buf := &bytes.Buffer{}
+			cmd.Stdout = buf
+			cmd.Stderr = buf
+			rd.apply(cmd, capture)
+
+			err := cc.getExecutor().ExecCmdRun(ctx, cc, cmd)
+			out = buf.Bytes()
+			capture.write(out, StreamStdout)
+			return err
+		}
 
-	out, err := c.executor.ExecCmdCombinedOutput(c, c.newCmd())
+		var err error
+		out, err = cc.getExecutor().ExecCmdCombinedOutput(ctx, cc, cmd)
+		capture.write(out, StreamStdout)
+		return err
+	})
 	if err != nil {
-		return nil, NewExecutionError(err, c)
+		return nil, err
 	}
 
 	return out, nil
 }
 
-// MustCombinedOutput is like [*Command.CombinedOutput] but panics on error.
-func (c *Command) MustCombinedOutput() []byte {
-	out, err := c.CombinedOutput()
+// MustCombinedOutputContext is like [*Command.CombinedOutputContext] but panics on error.
+func (c *Command) MustCombinedOutputContext(ctx context.Context) []byte {
+	out, err := c.CombinedOutputContext(ctx)
 	errorz.MaybeMustWrap(err)
 	return out
 }
@@ -376,55 +686,163 @@ func (c *Command) MustCombinedOutputString() string {
 
 // Lines runs the command and calls "lineFunc" with each line of output.
 func (c *Command) Lines(lineFunc func(string)) error {
+	return c.LinesContext(context.Background(), lineFunc)
+}
+
+// MustLines is like [*Command.Lines] but panics on error.
+func (c *Command) MustLines(lineFunc func(string)) {
+	errorz.MaybeMustWrap(c.Lines(lineFunc))
+}
+
+// LinesContext is like [*Command.Lines] but terminates the command when the context is done.
+func (c *Command) LinesContext(ctx context.Context, lineFunc func(string)) error {
+	return c.LinesExContext(ctx, nil, func(e *LineEvent) {
+		lineFunc(e.Text)
+	})
+}
+
+// MustLinesContext is like [*Command.LinesContext] but panics on error.
+func (c *Command) MustLinesContext(ctx context.Context, lineFunc func(string)) {
+	errorz.MaybeMustWrap(c.LinesContext(ctx, lineFunc))
+}
+
+// Stream identifies an output stream of a command.
+type Stream string
+
+// Known streams.
+const (
+	StreamStdout Stream = "stdout"
+	StreamStderr Stream = "stderr"
+)
+
+// LineEvent describes a line of output.
+type LineEvent struct {
+	Stream Stream
+	Text   string
+	Time   time.Time
+}
+
+// LinesOptions describes options for [*Command.LinesEx].
+type LinesOptions struct {
+	// StripANSI removes ANSI escape sequences (e.g. colors) from lines.
+	StripANSI bool
+
+	// MaxLineLength truncates lines longer than the given number of bytes (zero means no limit).
+	// The limit is applied as lines are read, before stripping ANSI escape sequences.
+	MaxLineLength int
+}
+
+var (
+	ansiRegexp = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+)
+
+// LinesEx runs the command and calls "eventFunc" with each line of output, tagged with its stream and arrival time.
+// Calls are serialized, and lines from the same stream are delivered in order. The options can be nil.
+func (c *Command) LinesEx(opts *LinesOptions, eventFunc func(*LineEvent)) error {
+	return c.LinesExContext(context.Background(), opts, eventFunc)
+}
+
+// MustLinesEx is like [*Command.LinesEx] but panics on error.
+func (c *Command) MustLinesEx(opts *LinesOptions, eventFunc func(*LineEvent)) {
+	errorz.MaybeMustWrap(c.LinesEx(opts, eventFunc))
+}
+
+// LinesExContext is like [*Command.LinesEx] but terminates the command when the context is done.
+func (c *Command) LinesExContext(ctx context.Context, opts *LinesOptions, eventFunc func(*LineEvent)) error {
 	c.maybeEcho(true)
-	cmd := c.newCmd()
 
-	outR, err := cmd.StdoutPipe()
-	errorz.MaybeMustWrap(err)
+	return c.execute(ctx, func(ctx context.Context, cc *Command, cmd *exec.Cmd, capture *outputCapture, rd *redirections) error {
+		m := &sync.Mutex{}
+		wg := &sync.WaitGroup{}
 
-	errR, err := cmd.StderrPipe()
-	errorz.MaybeMustWrap(err)
+		callEventFunc := func(e *LineEvent) {
+			m.Lock()
+			defer m.Unlock()
+			eventFunc(e)
+		}
 
-	m := &sync.Mutex{}
-	wg := &sync.WaitGroup{}
-	wg.Add(2)
+		pty := cc.attachPTY(cmd)
 
-	callLineFunc := func(line string) {
-		m.Lock()
-		defer m.Unlock()
-		lineFunc(line)
-	}
+		if pty != nil {
+			defer pty.close()
+			wg.Add(1)
+			go handleLines(wg, capture.teeReader(pty.getReader(), StreamStdout), StreamStdout, opts, callEventFunc)
+		} else {
+			for _, stream := range []Stream{StreamStdout, StreamStderr} {
+				if !rd.isRedirected(stream) {
+					wg.Add(1)
+					go handleLines(wg, capture.teeReader(newStreamPipe(cmd, stream), stream), stream, opts, callEventFunc)
+				}
+			}
+		}
 
-	go c.handleLines(wg, outR, callLineFunc)
-	go c.handleLines(wg, errR, callLineFunc)
+		rd.apply(cmd, capture)
+		err := cc.getExecutor().ExecCmdStart(ctx, cc, cmd)
+		pty.maybeCloseSlaveAfterStart(cmd)
 
-	if err := c.executor.ExecCmdStart(c, cmd); err != nil {
-		return NewExecutionError(err, c)
-	}
+		if err != nil {
+			return err
+		}
 
-	wg.Wait()
+		wg.Wait()
+		return cc.getExecutor().ExecCmdWait(ctx, cc, cmd)
+	})
+}
 
-	if err := c.executor.ExecCmdWait(c, cmd); err != nil {
-		return NewExecutionError(err, c)
+// MustLinesExContext is like [*Command.LinesExContext] but panics on error.
+func (c *Command) MustLinesExContext(ctx context.Context, opts *LinesOptions, eventFunc func(*LineEvent)) {
+	errorz.MaybeMustWrap(c.LinesExContext(ctx, opts, eventFunc))
+}
+
+// newStreamPipe returns a pipe that will be connected to the given output stream of "cmd" when it starts.
+func newStreamPipe(cmd *exec.Cmd, stream Stream) io.Reader {
+	newPipe := cmd.StdoutPipe
+
+	if stream == StreamStderr {
+		newPipe = cmd.StderrPipe
 	}
 
-	return nil
+	r, err := newPipe()
+	errorz.MaybeMustWrap(err)
+	return r
 }
 
-func (c *Command) handleLines(wg *sync.WaitGroup, r io.Reader, lineFunc func(string)) {
+func handleLines(wg *sync.WaitGroup, r io.Reader, stream Stream, opts *LinesOptions, eventFunc func(*LineEvent)) {
 	defer wg.Done()
 	defer func() { recover() }()
 
+	if opts == nil {
+		opts = &LinesOptions{}
+	}
+
 	br := bufio.NewReader(r)
 	s := &strings.Builder{}
 
+	emit := func() {
+		text := s.String()
+		s.Reset()
+
+		if opts.StripANSI {
+			text = ansiRegexp.ReplaceAllString(text, "")
+		}
+
+		eventFunc(&LineEvent{
+			Stream: stream,
+			Text:   text,
+			Time:   time.Now(),
+		})
+	}
+
 	for {
 		if buf, isPrefix, err := br.ReadLine(); err == nil {
+			if opts.MaxLineLength > 0 {
+				buf = buf[:min(len(buf), max(opts.MaxLineLength-s.Len(), 0))]
+			}
+
 			_, _ = s.Write(buf)
 
 			if !isPrefix {
-				lineFunc(s.String())
-				s.Reset()
+				emit()
 			}
 		} else {
 			break
@@ -432,32 +850,30 @@ func (c *Command) handleLines(wg *sync.WaitGroup, r io.Reader, lineFunc func(str
 	}
 
 	if s.Len() > 0 {
-		lineFunc(s.String())
-		s.Reset()
+		emit()
 	}
 }
 
-// MustLines is like [*Command.Lines] but panics on error.
-func (c *Command) MustLines(lineFunc func(string)) {
-	errorz.MaybeMustWrap(c.Lines(lineFunc))
-}
-
 // Exec the command (i.e. replace the current process).
 func (c *Command) Exec() error {
 	c.maybeEcho(true)
 
-	binFilePath, err := c.executor.ExecLookPath(c, c.cmd)
+	if c.hasRedirections() {
+		return NewExecutionError(errorz.Errorf("file redirections are not supported by Exec"), c)
+	}
+
+	binFilePath, err := c.getExecutor().ExecLookPath(c, c.cmd)
 	if err != nil {
 		return NewExecutionError(err, c)
 	}
 
 	if c.dir != "" {
-		if err := c.executor.OSChdir(c, c.dir); err != nil {
+		if err := c.getExecutor().OSChdir(c, c.dir); err != nil {
 			return NewExecutionError(err, c)
 		}
 	}
 
-	if err := c.executor.SyscallExec(c, binFilePath, append([]string{c.cmd}, c.params...), c.newEnviron()); err != nil {
+	if err := c.getExecutor().SyscallExec(c, binFilePath, append([]string{c.cmd}, c.params...), c.GetEffectiveEnv()); err != nil {
 		return NewExecutionError(err, c)
 	}
 
@@ -469,41 +885,181 @@ func (c *Command) MustExec() {
 	errorz.MaybeMustWrap(c.Exec())
 }
 
+// maybeEcho prints the command line if echo is enabled, unless in dry-run mode, as the [*DryRunExecutor] prints it.
 func (c *Command) maybeEcho(defaultEcho bool) {
-	if (c.echo == nil && !defaultEcho) || (c.echo != nil && !*c.echo) {
+	if (c.echo == nil && !defaultEcho) || (c.echo != nil && !*c.echo) || c.isDryRun() {
 		return
 	}
 
-	consolez.DefaultCLI.Command(c.cmd, c.params...)
+	if c.hasRedirections() {
+		consolez.DefaultCLI.RedirectedCommand(c.redact(c.cmd), c.getRedactedParams(), c.getRedirections()...)
+		return
+	}
+
+	consolez.DefaultCLI.Command(c.redact(c.cmd), c.getRedactedParams()...)
+}
+
+// execute runs "f" with a newly prepared [*exec.Cmd], applying timeouts and retries.
+// If "f" fails its error is converted to an [*ExecutionError], including any output captured by "capture".
+func (c *Command) execute(ctx context.Context, f func(ctx context.Context, cc *Command, cmd *exec.Cmd, capture *outputCapture, rd *redirections) error) error {
+	return c.retry(ctx, func(ctx context.Context, cc *Command) error {
+		ctx, cancel := cc.newContext(ctx)
+		defer cancel()
+
+		rd, err := cc.openRedirections()
+		if err != nil {
+			return NewExecutionError(err, cc)
+		}
+
+		cmd, cleanup := cc.newCmd(ctx, cancel)
+		defer cleanup()
+
+		obs := cc.beginObservation()
+		capture := newOutputCapture(cc.captureLimit, obs != nil || cc.getMaxOutputBytes() > 0)
+		capture.setOutputLimit(cc.getMaxOutputBytes(), cancel)
+		startTime := time.Now()
+
+		err = f(ctx, cc, cmd, capture, rd)
+		usage := newResourceUsage(cmd, startTime)
+
+		if err := rd.close(cc.checkLimits(cmd, capture, err)); err != nil {
+			eErr := cc.newLimitAwareExecutionError(ctx, err)
+			eErr.setProcessInfo(cmd, startTime, usage)
+			capture.apply(eErr, cc)
+			obs.end(eErr, capture, usage)
+			return eErr
+		}
+
+		obs.end(nil, capture, usage)
+		return nil
+	})
+}
+
+// newLimitAwareExecutionError is like [newContextExecutionError], but a command terminated because it exceeded a
+// resource limit is not reported as canceled.
+func (c *Command) newLimitAwareExecutionError(ctx context.Context, err error) *ExecutionError {
+	if _, ok := errorz.As[*LimitError](err); ok {
+		return NewExecutionError(err, c)
+	}
+
+	return newContextExecutionError(ctx, err, c)
+}
+
+func (c *Command) newContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+
+	return context.WithCancel(ctx)
 }
 
-func (c *Command) newCmd() *exec.Cmd {
-	cmd := exec.Command(c.cmd, c.params...)
+func (c *Command) newCmd(ctx context.Context, cancel context.CancelFunc) (*exec.Cmd, func()) {
+	cmd := exec.CommandContext(ctx, c.cmd, c.params...)
+	c.maybeWrapResourceLimits(cmd)
 	cmd.Dir = c.dir
-	cmd.Env = c.newEnviron()
+	cmd.Env = c.GetEffectiveEnv()
 	cmd.Stdin = c.in
-	return cmd
+
+	forwardedSig := &atomic.Value{}
+	killTimer := &atomic.Pointer[time.Timer]{}
+
+	cmd.Cancel = func() error {
+		sig := syscall.SIGTERM
+
+		if v, ok := forwardedSig.Load().(syscall.Signal); ok {
+			sig = v
+		}
+
+		if err := signalProcess(cmd, sig, c.isProcessGroup); err != nil {
+			return err
+		}
+
+		killTimer.Store(time.AfterFunc(c.gracePeriod, func() {
+			_ = signalProcess(cmd, syscall.SIGKILL, c.isProcessGroup)
+		}))
+
+		return nil
+	}
+
+	// The cleanup function is called once the command has been waited for: the kill timer must not fire after a
+	// graceful exit, as the process (group) ID may have been reused by then.
+	stopKillTimer := func() {
+		if t := killTimer.Load(); t != nil {
+			t.Stop()
+		}
+	}
+
+	if !c.isProcessGroup {
+		return cmd, stopKillTimer
+	}
+
+	setProcessGroup(cmd)
+
+	stopForwardSignals := forwardSignals(func(sig syscall.Signal) {
+		forwardedSig.CompareAndSwap(nil, sig)
+		cancel()
+	})
+
+	return cmd, func() {
+		stopKillTimer()
+		stopForwardSignals()
+
+		if cmd.Process != nil {
+			_ = signalProcessGroup(cmd, syscall.SIGKILL)
+		}
+	}
 }
 
-func (c *Command) newEnviron() []string {
-	env := os.Environ()
+// maybeSetWaitDelay configures "cmd" not to wait indefinitely for its output if it is written through a pipe (e.g. to
+// be captured), which would otherwise be held open by background processes that outlive the command.
+func (c *Command) maybeSetWaitDelay(cmd *exec.Cmd) {
+	_, isStdoutFile := cmd.Stdout.(*os.File)
+	_, isStderrFile := cmd.Stderr.(*os.File)
+
+	if !isStdoutFile || !isStderrFile {
+		cmd.WaitDelay = max(c.gracePeriod, minWaitDelay)
+	}
+}
 
-	for k, v := range c.env {
-		env = append(env, fmt.Sprintf("%v=%v", k, v))
+// ignoreWaitDelay returns nil if the command succeeded but its output pipes had to be closed by [exec.Cmd.WaitDelay]:
+// the output was streamed as it was written, so there is nothing left to report.
+func ignoreWaitDelay(err error) error {
+	if errors.Is(err, exec.ErrWaitDelay) {
+		return nil
 	}
 
-	return env
+	return err
 }
 
 func (c *Command) clone() *Command {
 	cc := &Command{
-		cmd:      c.cmd,
-		params:   memz.ShallowCopySlice(c.params),
-		dir:      c.dir,
-		env:      memz.ShallowCopyMap(c.env),
-		in:       c.in,
-		echo:     nil,
-		executor: c.executor,
+		cmd:            c.cmd,
+		params:         memz.ShallowCopySlice(c.params),
+		dir:            c.dir,
+		env:            memz.ShallowCopyMap(c.env),
+		envMode:        c.envMode,
+		envAllowlist:   memz.ShallowCopySlice(c.envAllowlist),
+		unsetEnv:       memz.ShallowCopyMap(c.unsetEnv),
+		in:             c.in,
+		stdinFile:      c.stdinFile,
+		stdoutFile:     c.stdoutFile,
+		stderrFile:     c.stderrFile,
+		echo:           nil,
+		timeout:        c.timeout,
+		gracePeriod:    c.gracePeriod,
+		isProcessGroup: c.isProcessGroup,
+		isPTY:          c.isPTY,
+		isInteractive:  c.isInteractive,
+		retryPolicy:    c.retryPolicy,
+		captureLimit:   c.captureLimit,
+		resourceLimits: c.resourceLimits,
+		secrets:        memz.ShallowCopySlice(c.secrets),
+		secretEnvKeys:  memz.ShallowCopyMap(c.secretEnvKeys),
+		observers:      memz.ShallowCopySlice(c.observers),
+		middlewares:    memz.ShallowCopySlice(c.middlewares),
+		cache:          c.cache,
+		cacheOptions:   c.cacheOptions,
+		executor:       c.executor,
 	}
 
 	if c.echo != nil {
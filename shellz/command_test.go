@@ -1,6 +1,7 @@
 package shellz_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,6 +9,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ibrt/golang-utils/errorz"
 	"github.com/ibrt/golang-utils/filez"
@@ -584,6 +586,234 @@ func (*CommandSuite) TestMustLines_Error(g *WithT) {
 	g.Expect(errBuf).To(BeEmpty())
 }
 
+func (*CommandSuite) TestLinesEx_Success(g *WithT) {
+	before := time.Now()
+	events := make([]*shellz.LineEvent, 0)
+
+	g.Expect(
+		shellz.NewCommand("sh", "-c", "echo o1; echo e1 >&2; sleep 0.1; echo o2; echo e2 >&2").
+			SetEcho(false).
+			LinesEx(nil, func(e *shellz.LineEvent) {
+				events = append(events, e)
+			})).
+		To(Succeed())
+
+	g.Expect(events).To(HaveLen(4))
+
+	stdout := make([]string, 0)
+	stderr := make([]string, 0)
+
+	for _, e := range events {
+		g.Expect(e.Time).To(BeTemporally(">=", before))
+		before = e.Time
+
+		switch e.Stream {
+		case shellz.StreamStdout:
+			stdout = append(stdout, e.Text)
+		case shellz.StreamStderr:
+			stderr = append(stderr, e.Text)
+		}
+	}
+
+	g.Expect(stdout).To(Equal([]string{"o1", "o2"}))
+	g.Expect(stderr).To(Equal([]string{"e1", "e2"}))
+}
+
+func (*CommandSuite) TestLinesEx_StripANSI(g *WithT) {
+	texts := make([]string, 0)
+
+	g.Expect(
+		shellz.NewCommand("cat").
+			SetIn(strings.NewReader("\x1b[1;31mred\x1b[0m text\n\x1b]0;title\x07last")).
+			SetEcho(false).
+			LinesEx(&shellz.LinesOptions{StripANSI: true}, func(e *shellz.LineEvent) {
+				texts = append(texts, e.Text)
+			})).
+		To(Succeed())
+
+	g.Expect(texts).To(Equal([]string{"red text", "last"}))
+}
+
+func (*CommandSuite) TestLinesEx_MaxLineLength(g *WithT) {
+	texts := make([]string, 0)
+
+	g.Expect(
+		shellz.NewCommand("cat").
+			SetIn(strings.NewReader(strings.Repeat("x", 8*1024)+"\nshort\n"+strings.Repeat("y", 8*1024))).
+			SetEcho(false).
+			LinesEx(&shellz.LinesOptions{MaxLineLength: 10}, func(e *shellz.LineEvent) {
+				texts = append(texts, e.Text)
+			})).
+		To(Succeed())
+
+	g.Expect(texts).To(Equal([]string{"xxxxxxxxxx", "short", "yyyyyyyyyy"}))
+}
+
+func (*CommandSuite) TestMustLinesEx_Error(g *WithT) {
+	g.Expect(func() {
+		shellz.NewCommand("false").SetEcho(false).MustLinesEx(nil, func(*shellz.LineEvent) {})
+	}).To(PanicWith(MatchError("execution error: exit status 1")))
+}
+
+func (*CommandSuite) TestLinesExContext_Canceled(g *WithT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g.Expect(func() {
+		shellz.NewCommand("true").SetEcho(false).MustLinesExContext(ctx, nil, func(*shellz.LineEvent) {})
+	}).To(Panic())
+}
+
+func (*CommandSuite) TestRunContext_Success(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	g.Expect(shellz.NewCommand("cat", "-b").SetIn(strings.NewReader("input")).RunContext(context.Background())).
+		To(Succeed())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(fmt.Sprintf("%v cat \x1b[2m-b\x1b[0m\n     1\tinput", consolez.IconRunner)))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*CommandSuite) TestRunContext_Canceled(g *WithT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	err := shellz.NewCommand("sleep", "10").SetEcho(false).RunContext(ctx)
+	g.Expect(err).To(HaveOccurred())
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.IsCanceled()).To(BeTrue())
+	g.Expect(eErr.IsTimedOut()).To(BeFalse())
+	g.Expect(eErr.GetExitCode()).To(Equal(-1))
+	g.Expect(eErr.Error()).To(Equal("execution error: canceled: signal: terminated"))
+}
+
+func (*CommandSuite) TestRunContext_AlreadyCanceled(g *WithT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := shellz.NewCommand("sleep", "10").SetEcho(false).RunContext(ctx)
+	g.Expect(err).To(MatchError("execution error: canceled: context canceled"))
+	g.Expect(err).To(MatchError(context.Canceled))
+}
+
+func (*CommandSuite) TestMustRunContext_Error(g *WithT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g.Expect(func() { shellz.NewCommand("sleep", "10").SetEcho(false).MustRunContext(ctx) }).
+		To(PanicWith(MatchError("execution error: canceled: context canceled")))
+}
+
+func (*CommandSuite) TestSetTimeout(g *WithT) {
+	cmd := shellz.NewCommand("sleep", "10").SetEcho(false).SetTimeout(100 * time.Millisecond)
+	g.Expect(cmd.GetTimeout()).To(Equal(100 * time.Millisecond))
+
+	start := time.Now()
+	err := cmd.Run()
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(err).To(MatchError("execution error: timed out after 100ms: signal: terminated"))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.IsTimedOut()).To(BeTrue())
+	g.Expect(eErr.IsCanceled()).To(BeFalse())
+}
+
+func (*CommandSuite) TestSetTimeout_ContextDeadline(g *WithT) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := shellz.NewCommand("sleep", "10").OutputContext(ctx, false)
+	g.Expect(err).To(MatchError("execution error: timed out: signal: terminated"))
+}
+
+func (*CommandSuite) TestSetGracePeriod(g *WithT) {
+	cmd := shellz.NewCommand("sh", "-c", `trap "" TERM; exec sleep 10`).
+		SetEcho(false).
+		SetTimeout(200 * time.Millisecond).
+		SetGracePeriod(100 * time.Millisecond)
+	g.Expect(cmd.GetGracePeriod()).To(Equal(100 * time.Millisecond))
+	g.Expect(shellz.NewCommand("cmd").GetGracePeriod()).To(Equal(shellz.DefaultGracePeriod))
+
+	start := time.Now()
+	_, err := cmd.CombinedOutput()
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(err).To(MatchError("execution error: timed out after 200ms: signal: killed"))
+}
+
+func (*CommandSuite) TestOutputContext_Success(g *WithT) {
+	out, err := shellz.NewCommand("cat").SetIn(strings.NewReader("input")).OutputContext(context.Background(), false)
+	g.Expect(err).To(Succeed())
+	g.Expect(string(out)).To(Equal("input"))
+
+	g.Expect(shellz.NewCommand("cat").SetIn(strings.NewReader("input")).MustOutputContext(context.Background(), false)).
+		To(Equal([]byte("input")))
+}
+
+func (*CommandSuite) TestCombinedOutputContext_Success(g *WithT) {
+	out, err := shellz.NewCommand("cat").SetIn(strings.NewReader("input")).CombinedOutputContext(context.Background())
+	g.Expect(err).To(Succeed())
+	g.Expect(string(out)).To(Equal("input"))
+
+	g.Expect(shellz.NewCommand("cat").SetIn(strings.NewReader("input")).MustCombinedOutputContext(context.Background())).
+		To(Equal([]byte("input")))
+}
+
+func (*CommandSuite) TestLinesContext_Timeout(g *WithT) {
+	m := &sync.Mutex{}
+	receivedLines := make([]string, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := shellz.NewCommand("sh", "-c", "echo 1; exec sleep 10").
+		SetEcho(false).
+		LinesContext(ctx, func(line string) {
+			m.Lock()
+			defer m.Unlock()
+			receivedLines = append(receivedLines, line)
+		})
+	g.Expect(err).To(MatchError("execution error: timed out: signal: terminated"))
+	g.Expect(receivedLines).To(Equal([]string{"1"}))
+
+	g.Expect(func() {
+		shellz.NewCommand("true").SetEcho(false).MustLinesContext(context.Background(), func(string) {})
+	}).ToNot(Panic())
+}
+
+func (*CommandSuite) TestSetProcessGroup(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	cmd := shellz.NewCommand("sh", "-c", "(sleep 0.3; touch marker) >/dev/null 2>&1 &").SetDir(dirPath).SetEcho(false)
+	g.Expect(cmd.GetProcessGroup()).To(BeFalse())
+	cmd = cmd.SetProcessGroup(true)
+	g.Expect(cmd.GetProcessGroup()).To(BeTrue())
+	g.Expect(cmd.Run()).To(Succeed())
+
+	time.Sleep(600 * time.Millisecond)
+	g.Expect(filepath.Join(dirPath, "marker")).ToNot(BeAnExistingFile())
+
+	g.Expect(cmd.SetProcessGroup(false).Run()).To(Succeed())
+	g.Eventually(filepath.Join(dirPath, "marker")).Should(BeAnExistingFile())
+}
+
+func (*CommandSuite) TestSetProcessGroup_Timeout(g *WithT) {
+	start := time.Now()
+
+	err := shellz.NewCommand("sh", "-c", "sleep 30; sleep 30").
+		SetEcho(false).
+		SetProcessGroup(true).
+		SetTimeout(100 * time.Millisecond).
+		Run()
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(err).To(MatchError("execution error: timed out after 100ms: signal: terminated"))
+}
+
 // TestExecExecutor is a mock shellz.Executor used by TestExec.
 type TestExecExecutor struct {
 	*shellz.RealExecutor
@@ -645,6 +875,7 @@ func (*CommandSuite) TestAddParams(g *WithT) {
 	defer outz.ResetOutputCapture()
 
 	cmd := shellz.NewCommand("cat").SetIn(strings.NewReader("input")).AddParams("-b")
+	g.Expect(cmd.GetCmd()).To(Equal("cat"))
 	g.Expect(cmd.GetParams()).To(Equal([]string{"-b"}))
 	g.Expect(cmd.Run()).To(Succeed())
 
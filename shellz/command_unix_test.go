@@ -0,0 +1,21 @@
+//go:build unix
+
+package shellz_test
+
+import (
+	"syscall"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func (*CommandSuite) TestSetProcessGroup_ForwardSignal(g *WithT) {
+	start := time.Now()
+
+	results := runHelperProcess(g, "process-group", func(pid int) {
+		mustSignal(g, pid, syscall.SIGINT)
+	})
+
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(results).To(Equal([]string{"execution error: canceled: signal: interrupt"}))
+}
@@ -0,0 +1,248 @@
+package shellz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+// SetDryRun enables or disables dry-run mode globally, by replacing [DefaultExecutor] with a new [*DryRunExecutor]
+// or restoring it. It only affects commands created after it is called.
+func SetDryRun(isDryRun bool) {
+	if isDryRun {
+		DefaultExecutor = NewDryRunExecutor()
+	} else {
+		RestoreDefaultExecutor()
+	}
+}
+
+// IsDryRun returns true if [DefaultExecutor] is a [*DryRunExecutor].
+func IsDryRun() bool {
+	_, ok := DefaultExecutor.(*DryRunExecutor)
+	return ok
+}
+
+// isDryRun returns true if the executor of the command is a [*DryRunExecutor].
+func (c *Command) isDryRun() bool {
+	_, ok := c.executor.(*DryRunExecutor)
+	return ok
+}
+
+// SimulatedExecutor is an optional interface for an [Executor] that may not run commands on the host, e.g. because it
+// is a [*DryRunExecutor] or a test fake, or because it wraps such an [Executor] (see [IsSimulated]).
+type SimulatedExecutor interface {
+	IsSimulated() bool
+}
+
+// IsSimulated returns true if the given [Executor] implements [SimulatedExecutor] and does not run commands on the host.
+// Middlewares can use it to skip work only needed by real processes.
+func IsSimulated(e Executor) bool {
+	s, ok := e.(SimulatedExecutor)
+	return ok && s.IsSimulated()
+}
+
+var (
+	_ Executor          = (*DryRunExecutor)(nil)
+	_ SimulatedExecutor = (*DryRunExecutor)(nil)
+)
+
+// DryRunExecutor implements the [Executor] interface without running anything.
+// It prints each command as it would run, including dir, env overrides and input size, and returns synthetic outputs.
+// Commands run with it are not echoed, as it prints them regardless of their echo configuration.
+type DryRunExecutor struct {
+	m             *sync.Mutex
+	outputs       []*dryRunOutput
+	defaultOutput string
+	pending       map[*exec.Cmd]chan struct{}
+}
+
+type dryRunOutput struct {
+	cmd    string
+	params []string
+	out    string
+}
+
+// NewDryRunExecutor initializes a new [*DryRunExecutor].
+func NewDryRunExecutor() *DryRunExecutor {
+	return &DryRunExecutor{
+		m:             &sync.Mutex{},
+		outputs:       make([]*dryRunOutput, 0),
+		defaultOutput: "",
+		pending:       make(map[*exec.Cmd]chan struct{}),
+	}
+}
+
+// SetOutput configures the synthetic output returned by commands that match "cmd" and start with "params".
+// When multiple outputs match, the last one configured wins.
+func (e *DryRunExecutor) SetOutput(out string, cmd string, params ...string) *DryRunExecutor {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	e.outputs = append(e.outputs, &dryRunOutput{
+		cmd:    cmd,
+		params: slices.Clone(params),
+		out:    out,
+	})
+
+	return e
+}
+
+// SetDefaultOutput configures the synthetic output returned by commands that don't match any other output.
+func (e *DryRunExecutor) SetDefaultOutput(out string) *DryRunExecutor {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	e.defaultOutput = out
+	return e
+}
+
+// IsSimulated implements the [SimulatedExecutor] interface.
+func (*DryRunExecutor) IsSimulated() bool {
+	return true
+}
+
+// ExecCmdCombinedOutput implements the [Executor] interface.
+func (e *DryRunExecutor) ExecCmdCombinedOutput(_ context.Context, c *Command, cmd *exec.Cmd) ([]byte, error) {
+	e.print(c, cmd)
+	return []byte(e.getOutput(c)), nil
+}
+
+// ExecCmdOutput implements the [Executor] interface.
+func (e *DryRunExecutor) ExecCmdOutput(_ context.Context, c *Command, cmd *exec.Cmd) ([]byte, error) {
+	e.print(c, cmd)
+	return []byte(e.getOutput(c)), nil
+}
+
+// ExecCmdRun implements the [Executor] interface.
+func (e *DryRunExecutor) ExecCmdRun(_ context.Context, c *Command, cmd *exec.Cmd) error {
+	e.print(c, cmd)
+	return nil
+}
+
+// ExecCmdStart implements the [Executor] interface.
+// The synthetic output is written asynchronously, then the output files (e.g. pipes) are closed.
+func (e *DryRunExecutor) ExecCmdStart(_ context.Context, c *Command, cmd *exec.Cmd) error {
+	e.print(c, cmd)
+
+	out := e.getOutput(c)
+	stdout, stderr := cmd.Stdout, cmd.Stderr
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		writeSyntheticOutput(stdout, out)
+		writeSyntheticOutput(stderr, "")
+	}()
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.pending[cmd] = done
+	return nil
+}
+
+// ExecCmdWait implements the [Executor] interface.
+func (e *DryRunExecutor) ExecCmdWait(_ context.Context, _ *Command, cmd *exec.Cmd) error {
+	e.m.Lock()
+	done, ok := e.pending[cmd]
+	delete(e.pending, cmd)
+	e.m.Unlock()
+
+	if ok {
+		<-done
+	}
+
+	return nil
+}
+
+// ExecLookPath implements the [Executor] interface.
+func (e *DryRunExecutor) ExecLookPath(_ *Command, file string) (string, error) {
+	return file, nil
+}
+
+// OSChdir implements the [Executor] interface.
+func (e *DryRunExecutor) OSChdir(_ *Command, _ string) error {
+	return nil
+}
+
+// SyscallExec implements the [Executor] interface.
+func (e *DryRunExecutor) SyscallExec(c *Command, _ string, _ []string, _ []string) error {
+	e.print(c, nil)
+	return nil
+}
+
+func (e *DryRunExecutor) getOutput(c *Command) string {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	for i := len(e.outputs) - 1; i >= 0; i-- {
+		if o := e.outputs[i]; o.cmd == c.cmd && len(c.params) >= len(o.params) && slices.Equal(o.params, c.params[:len(o.params)]) {
+			return o.out
+		}
+	}
+
+	return e.defaultOutput
+}
+
+func (e *DryRunExecutor) print(c *Command, cmd *exec.Cmd) {
+	details := make([]string, 0, 5)
+
+	if c.dir != "" {
+		details = append(details, fmt.Sprintf("(dir: %v)", consolez.ShellQuote(c.redact(c.dir))))
+	}
+
+	if len(c.env) > 0 {
+		env := make([]string, 0, len(c.env))
+
+		for k, v := range c.getRedactedEnv() {
+			env = append(env, fmt.Sprintf("%v=%v", k, consolez.ShellQuote(v)))
+		}
+
+		slices.Sort(env)
+		details = append(details, fmt.Sprintf("(env: %v)", strings.Join(env, " ")))
+	}
+
+	if c.envMode != EnvModeInherit {
+		details = append(details, fmt.Sprintf("(env mode: %v)", strings.Join(append([]string{c.envMode.String()}, c.envAllowlist...), " ")))
+	}
+
+	if len(c.unsetEnv) > 0 {
+		details = append(details, fmt.Sprintf("(unset env: %v)", strings.Join(c.GetUnsetEnv(), " ")))
+	}
+
+	if cmd != nil && cmd.Stdin != nil {
+		if _, ok := cmd.Stdin.(*os.File); ok {
+			details = append(details, "(stdin: stream)")
+		} else if in, err := io.ReadAll(cmd.Stdin); err == nil {
+			details = append(details, fmt.Sprintf("(stdin: %v bytes)", len(in)))
+		}
+	}
+
+	params := make([]string, 0, len(c.params)+1)
+	params = append(params, consolez.ShellQuote(c.cmd))
+
+	for _, p := range c.getRedactedParams() {
+		params = append(params, consolez.ShellQuote(p))
+	}
+
+	consolez.DefaultCLI.Notice("dry-run", strings.Join(params, " "), details...)
+}
+
+// writeSyntheticOutput writes the given output to "w", then closes it if it is a file other than the standard ones.
+func writeSyntheticOutput(w io.Writer, out string) {
+	if w == nil {
+		return
+	}
+
+	_, _ = io.WriteString(w, out)
+
+	if f, ok := w.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+		_ = f.Close()
+	}
+}
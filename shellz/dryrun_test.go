@@ -0,0 +1,110 @@
+package shellz_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type DryRunSuite struct {
+	// intentionally empty
+}
+
+func TestDryRunSuite(t *testing.T) {
+	fixturez.RunSuite(t, &DryRunSuite{})
+}
+
+func (*DryRunSuite) TestSetDryRun(g *WithT) {
+	defer shellz.RestoreDefaultExecutor()
+
+	g.Expect(shellz.IsDryRun()).To(BeFalse())
+	shellz.SetDryRun(true)
+	g.Expect(shellz.IsDryRun()).To(BeTrue())
+	g.Expect(shellz.DefaultExecutor).To(BeAssignableToTypeOf(&shellz.DryRunExecutor{}))
+	shellz.SetDryRun(false)
+	g.Expect(shellz.IsDryRun()).To(BeFalse())
+	g.Expect(shellz.DefaultExecutor).To(BeAssignableToTypeOf(&shellz.RealExecutor{}))
+}
+
+func (*DryRunSuite) TestIsSimulated(g *WithT) {
+	g.Expect(shellz.IsSimulated(&shellz.RealExecutor{})).To(BeFalse())
+	g.Expect(shellz.IsSimulated(shellz.NewDryRunExecutor())).To(BeTrue())
+	g.Expect(shellz.IsSimulated(shellz.NewRecordingExecutor(&shellz.RealExecutor{}))).To(BeFalse())
+	g.Expect(shellz.IsSimulated(shellz.NewRecordingExecutor(shellz.NewDryRunExecutor()))).To(BeTrue())
+
+	mw := func(call *shellz.ExecutorCall, next shellz.ExecutorHandler) *shellz.ExecutorResult {
+		return next(call)
+	}
+
+	g.Expect(shellz.IsSimulated(shellz.ChainExecutor(&shellz.RealExecutor{}, mw))).To(BeFalse())
+	g.Expect(shellz.IsSimulated(shellz.ChainExecutor(shellz.NewDryRunExecutor(), mw))).To(BeTrue())
+}
+
+func (*DryRunSuite) TestDryRunExecutor_Run(g *WithT) {
+	defer shellz.RestoreDefaultExecutor()
+	shellz.SetDryRun(true)
+
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	g.Expect(shellz.NewCommand("rm", "-rf", "my dir", "it's").
+		SetDir("/tmp/some dir").
+		SetEnv("B", "2").
+		SetEnv("A", "x y").
+		SetIn(strings.NewReader("input")).
+		Run()).To(Succeed())
+
+	g.Expect(shellz.NewCommand("rm", "").SetEcho(false).Run()).To(Succeed())
+	g.Expect(shellz.NewCommand("ls").Pipe(shellz.NewCommand("wc")).Run()).To(Succeed())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(
+		"[.................dry-run] rm -rf 'my dir' 'it'\\''s' (dir: '/tmp/some dir') (env: A='x y' B=2) (stdin: 5 bytes)\n" +
+			"[.................dry-run] rm ''\n" +
+			"[.................dry-run] ls\n" +
+			"[.................dry-run] wc (stdin: stream)\n"))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*DryRunSuite) TestDryRunExecutor_Outputs(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	e := shellz.NewDryRunExecutor().
+		SetDefaultOutput("default").
+		SetOutput("list", "go", "list").
+		SetOutput("list-m", "go", "list", "-m")
+
+	g.Expect(shellz.NewCommand("go", "list", "./...").SetExecutor(e).OutputString(false)).To(Equal("list"))
+	g.Expect(shellz.NewCommand("go", "list", "-m", "all").SetExecutor(e).CombinedOutputString()).To(Equal("list-m"))
+	g.Expect(shellz.NewCommand("go", "version").SetExecutor(e).OutputString(false)).To(Equal("default"))
+	g.Expect(shellz.NewCommand("go").SetExecutor(e).OutputString(false)).To(Equal("default"))
+
+	lines := make([]string, 0)
+	g.Expect(shellz.NewCommand("go", "list").SetExecutor(e).SetEcho(false).Lines(func(line string) { lines = append(lines, line) })).To(Succeed())
+	g.Expect(lines).To(Equal([]string{"list"}))
+
+	g.Expect(shellz.NewCommand("go", "version").SetExecutor(e).Pipe(shellz.NewCommand("go", "list").SetExecutor(e)).
+		OutputString(false)).To(Equal("list"))
+
+	g.Expect(shellz.NewCommand("go", "run").SetExecutor(e).SetEcho(false).Exec()).To(Succeed())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(strings.Join([]string{
+		"[.................dry-run] go list ./...",
+		"[.................dry-run] go list -m all",
+		"[.................dry-run] go version",
+		"[.................dry-run] go",
+		"[.................dry-run] go list",
+		"[.................dry-run] go version",
+		"[.................dry-run] go list (stdin: stream)",
+		"[.................dry-run] go run",
+		"",
+	}, "\n")))
+	g.Expect(errBuf).To(BeEmpty())
+}
@@ -0,0 +1,136 @@
+package shellz
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// EnvMode describes how the environment of a command is derived from the environment of the current process.
+type EnvMode int
+
+// Known env modes.
+const (
+	// EnvModeInherit inherits all the environment variables of the current process (default).
+	EnvModeInherit EnvMode = iota
+
+	// EnvModeClean doesn't inherit any environment variable of the current process.
+	EnvModeClean
+
+	// EnvModeAllowlist only inherits the allowlisted environment variables of the current process.
+	EnvModeAllowlist
+)
+
+// String implements the [fmt.Stringer] interface.
+func (m EnvMode) String() string {
+	switch m {
+	case EnvModeInherit:
+		return "inherit"
+	case EnvModeClean:
+		return "clean"
+	case EnvModeAllowlist:
+		return "allowlist"
+	default:
+		return fmt.Sprintf("EnvMode(%d)", int(m))
+	}
+}
+
+// SetEnvMode sets the env mode. For [EnvModeAllowlist], "allowlist" contains the names of the environment variables to
+// inherit: a trailing "*" matches any suffix (e.g. "LC_*").
+func (c *Command) SetEnvMode(mode EnvMode, allowlist ...string) *Command {
+	cc := c.clone()
+	cc.envMode = mode
+	cc.envAllowlist = slices.Clone(allowlist)
+	return cc
+}
+
+// GetEnvMode returns the current env mode.
+func (c *Command) GetEnvMode() EnvMode {
+	return c.envMode
+}
+
+// GetEnvAllowlist returns the current env allowlist.
+func (c *Command) GetEnvAllowlist() []string {
+	return slices.Clone(c.envAllowlist)
+}
+
+// UnsetEnv removes the given environment variables, both from the ones set on the command and the inherited ones.
+func (c *Command) UnsetEnv(keys ...string) *Command {
+	cc := c.clone()
+
+	for _, k := range keys {
+		delete(cc.env, k)
+		cc.unsetEnv[k] = struct{}{}
+	}
+
+	return cc
+}
+
+// GetUnsetEnv returns the names of the environment variables that are explicitly unset, sorted.
+func (c *Command) GetUnsetEnv() []string {
+	keys := make([]string, 0, len(c.unsetEnv))
+
+	for k := range c.unsetEnv {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+// GetEffectiveEnv returns the environment the command would run with, as a list of "KEY=value" entries sorted by key.
+// Each key appears only once.
+func (c *Command) GetEffectiveEnv() []string {
+	env := make(map[string]string)
+
+	if c.envMode != EnvModeClean {
+		for _, kv := range os.Environ() {
+			if k, v, ok := cutEnv(kv); ok && (c.envMode == EnvModeInherit || c.isEnvAllowed(k)) {
+				env[k] = v
+			}
+		}
+	}
+
+	for k := range c.unsetEnv {
+		delete(env, k)
+	}
+
+	for k, v := range c.env {
+		env[k] = v
+	}
+
+	keys := make([]string, 0, len(env))
+
+	for k := range env {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+	environ := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		environ = append(environ, k+"="+env[k])
+	}
+
+	return environ
+}
+
+func (c *Command) isEnvAllowed(k string) bool {
+	for _, a := range c.envAllowlist {
+		if prefix, ok := strings.CutSuffix(a, "*"); ok && strings.HasPrefix(k, prefix) || a == k {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cutEnv splits a "KEY=value" entry. Note that on Windows some keys start with "=" (e.g. "=C:=C:\").
+func cutEnv(kv string) (string, string, bool) {
+	if i := strings.Index(kv[min(len(kv), 1):], "="); i >= 0 {
+		return kv[:i+1], kv[i+2:], true
+	}
+
+	return "", "", false
+}
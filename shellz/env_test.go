@@ -0,0 +1,126 @@
+package shellz_test
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type EnvSuite struct {
+	// intentionally empty
+}
+
+func TestEnvSuite(t *testing.T) {
+	fixturez.RunSuite(t, &EnvSuite{})
+}
+
+func (*EnvSuite) TestEnvMode_String(g *WithT) {
+	g.Expect(shellz.EnvModeInherit.String()).To(Equal("inherit"))
+	g.Expect(shellz.EnvModeClean.String()).To(Equal("clean"))
+	g.Expect(shellz.EnvModeAllowlist.String()).To(Equal("allowlist"))
+	g.Expect(shellz.EnvMode(99).String()).To(Equal("EnvMode(99)"))
+}
+
+func (*EnvSuite) TestSetEnvMode(g *WithT) {
+	c := shellz.NewCommand("cmd")
+	g.Expect(c.GetEnvMode()).To(Equal(shellz.EnvModeInherit))
+	g.Expect(c.GetEnvAllowlist()).To(BeEmpty())
+
+	cc := c.SetEnvMode(shellz.EnvModeAllowlist, "PATH", "LC_*")
+	g.Expect(cc.GetEnvMode()).To(Equal(shellz.EnvModeAllowlist))
+	g.Expect(cc.GetEnvAllowlist()).To(Equal([]string{"PATH", "LC_*"}))
+	g.Expect(c.GetEnvMode()).To(Equal(shellz.EnvModeInherit))
+}
+
+func (*EnvSuite) TestGetEffectiveEnv_Inherit(g *WithT) {
+	g.Expect(os.Setenv("SHELLZ_TEST_A", "host")).To(Succeed())
+	defer func() { g.Expect(os.Unsetenv("SHELLZ_TEST_A")).To(Succeed()) }()
+
+	env := shellz.NewCommand("cmd").
+		SetEnv("SHELLZ_TEST_A", "override").
+		SetEnv("SHELLZ_TEST_B", "b").
+		GetEffectiveEnv()
+
+	g.Expect(env).To(ContainElements("SHELLZ_TEST_A=override", "SHELLZ_TEST_B=b"))
+	g.Expect(env).ToNot(ContainElement("SHELLZ_TEST_A=host"))
+	g.Expect(env).To(ContainElement("PATH=" + os.Getenv("PATH")))
+	g.Expect(slices.IsSorted(env)).To(BeTrue())
+}
+
+func (*EnvSuite) TestGetEffectiveEnv_Clean(g *WithT) {
+	g.Expect(shellz.NewCommand("cmd").
+		SetEnvMode(shellz.EnvModeClean).
+		SetEnv("B", "2").
+		SetEnv("A", "1").
+		GetEffectiveEnv()).To(Equal([]string{"A=1", "B=2"}))
+}
+
+func (*EnvSuite) TestGetEffectiveEnv_Allowlist(g *WithT) {
+	g.Expect(os.Setenv("SHELLZ_TEST_A", "a")).To(Succeed())
+	g.Expect(os.Setenv("SHELLZ_TEST_B1", "b1")).To(Succeed())
+	g.Expect(os.Setenv("SHELLZ_TEST_B2", "b2")).To(Succeed())
+
+	defer func() {
+		g.Expect(os.Unsetenv("SHELLZ_TEST_A")).To(Succeed())
+		g.Expect(os.Unsetenv("SHELLZ_TEST_B1")).To(Succeed())
+		g.Expect(os.Unsetenv("SHELLZ_TEST_B2")).To(Succeed())
+	}()
+
+	g.Expect(shellz.NewCommand("cmd").
+		SetEnvMode(shellz.EnvModeAllowlist, "SHELLZ_TEST_A", "SHELLZ_TEST_B*").
+		SetEnv("C", "c").
+		GetEffectiveEnv()).To(Equal([]string{"C=c", "SHELLZ_TEST_A=a", "SHELLZ_TEST_B1=b1", "SHELLZ_TEST_B2=b2"}))
+
+	g.Expect(shellz.NewCommand("cmd").
+		SetEnvMode(shellz.EnvModeAllowlist, "SHELLZ_TEST_B").
+		GetEffectiveEnv()).To(BeEmpty())
+}
+
+func (*EnvSuite) TestUnsetEnv(g *WithT) {
+	g.Expect(os.Setenv("SHELLZ_TEST_A", "a")).To(Succeed())
+	defer func() { g.Expect(os.Unsetenv("SHELLZ_TEST_A")).To(Succeed()) }()
+
+	c := shellz.NewCommand("cmd").SetEnv("SHELLZ_TEST_B", "b").UnsetEnv("SHELLZ_TEST_A", "SHELLZ_TEST_B")
+	g.Expect(c.GetEnv()).To(BeEmpty())
+	g.Expect(c.GetUnsetEnv()).To(Equal([]string{"SHELLZ_TEST_A", "SHELLZ_TEST_B"}))
+	g.Expect(c.GetEffectiveEnv()).ToNot(ContainElement(HavePrefix("SHELLZ_TEST_")))
+
+	g.Expect(c.SetEnv("SHELLZ_TEST_A", "override").GetEffectiveEnv()).To(ContainElement("SHELLZ_TEST_A=override"))
+}
+
+func (*EnvSuite) TestRun(g *WithT) {
+	g.Expect(os.Setenv("SHELLZ_TEST_A", "a")).To(Succeed())
+	defer func() { g.Expect(os.Unsetenv("SHELLZ_TEST_A")).To(Succeed()) }()
+
+	g.Expect(shellz.NewCommand("env").
+		SetEnvMode(shellz.EnvModeClean).
+		SetEnv("B", "2").
+		SetEnv("A", "1").
+		OutputString(false)).To(Equal("A=1\nB=2\n"))
+
+	g.Expect(shellz.NewCommand("sh", "-c", `echo "${SHELLZ_TEST_A:-unset}"`).
+		UnsetEnv("SHELLZ_TEST_A").
+		OutputString(false)).To(Equal("unset\n"))
+}
+
+func (*EnvSuite) TestDryRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	g.Expect(shellz.NewCommand("cmd").
+		SetExecutor(shellz.NewDryRunExecutor()).
+		SetEnvMode(shellz.EnvModeAllowlist, "PATH", "HOME").
+		UnsetEnv("B", "A").
+		SetEcho(false).
+		Run()).To(Succeed())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("[.................dry-run] cmd (env mode: allowlist PATH HOME) (unset env: A B)\n"))
+	g.Expect(errBuf).To(BeEmpty())
+}
@@ -0,0 +1,463 @@
+package shellz
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/memz"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+// GroupPolicy describes how a [*Group] reacts to a failing command.
+type GroupPolicy int
+
+// Known group policies.
+const (
+	// GroupPolicyFailFast cancels the running commands and skips the pending ones as soon as a command fails (default).
+	GroupPolicyFailFast GroupPolicy = iota
+
+	// GroupPolicyCollectAll runs all commands regardless of failures.
+	GroupPolicyCollectAll
+)
+
+// GroupStatus describes the outcome of a command in a [*Group].
+type GroupStatus int
+
+// Known group statuses.
+const (
+	// GroupStatusSucceeded means that the command succeeded.
+	GroupStatusSucceeded GroupStatus = iota
+
+	// GroupStatusFailed means that the command failed.
+	GroupStatusFailed
+
+	// GroupStatusCanceled means that the command was terminated because another command failed or the context was done.
+	GroupStatusCanceled
+
+	// GroupStatusSkipped means that the command was not started because another command failed or the context was done.
+	GroupStatusSkipped
+)
+
+// String implements the [fmt.Stringer] interface.
+func (s GroupStatus) String() string {
+	switch s {
+	case GroupStatusSucceeded:
+		return "succeeded"
+	case GroupStatusFailed:
+		return "failed"
+	case GroupStatusCanceled:
+		return "canceled"
+	case GroupStatusSkipped:
+		return "skipped"
+	default:
+		return fmt.Sprintf("GroupStatus(%d)", int(s))
+	}
+}
+
+// GroupResult describes the outcome of a command in a [*Group].
+type GroupResult struct {
+	// Name is the name of the command in the group.
+	Name string
+
+	// Command is the command.
+	Command *Command
+
+	// Status is the outcome of the command.
+	Status GroupStatus
+
+	// Duration is the run time of the command (zero if skipped).
+	Duration time.Duration
+
+	// ExitCode is the exit code of the command (-1 if skipped or not available).
+	ExitCode int
+
+	// ResourceUsage describes the resources used by the last execution of the command (nil if not available).
+	ResourceUsage *ResourceUsage
+
+	// Error is the error returned by the command, if any.
+	Error error
+}
+
+var (
+	_ error              = (*GroupError)(nil)
+	_ errorz.UnwrapMulti = (*GroupError)(nil)
+)
+
+// GroupError describes one or more failed commands in a [*Group], or a group that did not complete because its context
+// was done.
+type GroupError struct {
+	results []*GroupResult
+	err     error
+}
+
+// GetResults returns the results of all commands in the group, in the order they were added.
+func (e *GroupError) GetResults() []*GroupResult {
+	return memz.ShallowCopySlice(e.results)
+}
+
+// GetFailed returns the results of the failed commands, in the order they were added.
+func (e *GroupError) GetFailed() []*GroupResult {
+	failed := make([]*GroupResult, 0)
+
+	for _, r := range e.results {
+		if r.Status == GroupStatusFailed {
+			failed = append(failed, r)
+		}
+	}
+
+	return failed
+}
+
+// Error implements the error interface.
+func (e *GroupError) Error() string {
+	failed := e.GetFailed()
+
+	if len(failed) == 0 {
+		msg := fmt.Sprintf("group: %v/%v commands canceled or skipped", len(e.results)-e.countSucceeded(), len(e.results))
+
+		if e.err != nil {
+			msg += ": " + e.err.Error()
+		}
+
+		return msg
+	}
+
+	msgs := make([]string, 0, len(failed))
+
+	for _, r := range failed {
+		msgs = append(msgs, fmt.Sprintf("%v: %v", r.Name, r.Error.Error()))
+	}
+
+	return fmt.Sprintf("group: %v/%v commands failed (%v)", len(failed), len(e.results), strings.Join(msgs, "; "))
+}
+
+// Unwrap implements the [errorz.UnwrapMulti] interface.
+func (e *GroupError) Unwrap() []error {
+	errs := make([]error, 0)
+
+	for _, r := range e.GetFailed() {
+		errs = append(errs, r.Error)
+	}
+
+	if e.err != nil {
+		errs = append(errs, e.err)
+	}
+
+	return errs
+}
+
+func (e *GroupError) countSucceeded() int {
+	n := 0
+
+	for _, r := range e.results {
+		if r.Status == GroupStatusSucceeded {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Group describes a set of commands that run concurrently.
+// Each line of output is printed with a colored prefix identifying its command (see [consolez.CLI.Prefixed]).
+type Group struct {
+	members     []*groupMember
+	concurrency int
+	policy      GroupPolicy
+	echo        *bool
+	isSummary   bool
+}
+
+type groupMember struct {
+	name string
+	c    *Command
+}
+
+// NewGroup initializes a new, empty [*Group], with concurrency equal to the number of CPUs and fail-fast policy.
+func NewGroup() *Group {
+	return &Group{
+		members:     make([]*groupMember, 0),
+		concurrency: runtime.NumCPU(),
+		policy:      GroupPolicyFailFast,
+		echo:        nil,
+		isSummary:   true,
+	}
+}
+
+// Add adds a command to the group. The name is used as output prefix and in the summary.
+func (g *Group) Add(name string, c *Command) *Group {
+	gg := g.clone()
+	gg.members = append(gg.members, &groupMember{name: name, c: c})
+	return gg
+}
+
+// GetNames returns the names of the commands in the group, in the order they were added.
+func (g *Group) GetNames() []string {
+	names := make([]string, 0, len(g.members))
+
+	for _, m := range g.members {
+		names = append(names, m.name)
+	}
+
+	return names
+}
+
+// SetConcurrency sets the maximum number of commands that run at the same time (values lower than 1 are treated as 1).
+func (g *Group) SetConcurrency(concurrency int) *Group {
+	gg := g.clone()
+	gg.concurrency = max(concurrency, 1)
+	return gg
+}
+
+// GetConcurrency returns the current concurrency.
+func (g *Group) GetConcurrency() int {
+	return g.concurrency
+}
+
+// SetPolicy sets the policy.
+func (g *Group) SetPolicy(policy GroupPolicy) *Group {
+	gg := g.clone()
+	gg.policy = policy
+	return gg
+}
+
+// GetPolicy returns the current policy.
+func (g *Group) GetPolicy() GroupPolicy {
+	return g.policy
+}
+
+// SetEcho configures echo (the echo configuration of the individual commands is ignored).
+func (g *Group) SetEcho(echo bool) *Group {
+	gg := g.clone()
+	gg.echo = memz.Ptr(echo)
+	return gg
+}
+
+// GetEcho returns the current echo configuration.
+func (g *Group) GetEcho() *bool {
+	if g.echo == nil {
+		return nil
+	}
+	return memz.Ptr(*g.echo)
+}
+
+// SetSummary configures whether a summary table is printed after all commands complete (default true).
+func (g *Group) SetSummary(isSummary bool) *Group {
+	gg := g.clone()
+	gg.isSummary = isSummary
+	return gg
+}
+
+// GetSummary returns the current summary configuration.
+func (g *Group) GetSummary() bool {
+	return g.isSummary
+}
+
+// Run runs the commands and returns their results, in the order they were added.
+// If any command fails, it also returns a [*GroupError].
+// The [*GroupError] is also returned if any command was canceled or skipped (e.g. when using [*Group.RunContext]).
+func (g *Group) Run() ([]*GroupResult, error) {
+	return g.RunContext(context.Background())
+}
+
+// MustRun is like [*Group.Run] but panics on error.
+func (g *Group) MustRun() []*GroupResult {
+	results, err := g.Run()
+	errorz.MaybeMustWrap(err)
+	return results
+}
+
+// RunContext is like [*Group.Run] but terminates all commands when the context is done. In that case the returned
+// [*GroupError] wraps the error of the context.
+func (g *Group) RunContext(parentCtx context.Context) ([]*GroupResult, error) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	width := 4
+	for _, m := range g.members {
+		width = max(width, len([]rune(m.name)))
+	}
+
+	results := make([]*GroupResult, len(g.members))
+	sem := make(chan struct{}, g.concurrency)
+	wg := &sync.WaitGroup{}
+	failM := &sync.Mutex{}
+	isFailed := false
+
+	for i, m := range g.members {
+		results[i] = &GroupResult{
+			Name:     m.name,
+			Command:  m.c,
+			Status:   GroupStatusSkipped,
+			Duration: 0,
+			ExitCode: -1,
+			Error:    nil,
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+
+		if ctx.Err() != nil {
+			<-sem
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			g.runMember(ctx, i, width, m, results[i])
+
+			if results[i].Status == GroupStatusFailed && g.policy == GroupPolicyFailFast {
+				failM.Lock()
+				defer failM.Unlock()
+
+				if !isFailed {
+					isFailed = true
+					cancel()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if g.isSummary {
+		g.printSummary(results)
+	}
+
+	if parentCtx.Err() != nil {
+		return results, errorz.Wrap(&GroupError{results: results, err: parentCtx.Err()})
+	}
+
+	for _, r := range results {
+		if r.Status != GroupStatusSucceeded {
+			return results, errorz.Wrap(&GroupError{results: results})
+		}
+	}
+
+	return results, nil
+}
+
+// MustRunContext is like [*Group.RunContext] but panics on error.
+func (g *Group) MustRunContext(ctx context.Context) []*GroupResult {
+	results, err := g.RunContext(ctx)
+	errorz.MaybeMustWrap(err)
+	return results
+}
+
+func (g *Group) runMember(ctx context.Context, i, width int, m *groupMember, r *GroupResult) {
+	if g.echo == nil || *g.echo {
+		consolez.DefaultCLI.Prefixed(m.name, width, i, false, consolez.IconRunner+" "+m.c.String())
+	}
+
+	start := time.Now()
+	recorder := &resourceUsageRecorder{m: &sync.Mutex{}}
+
+	err := m.c.SetEcho(false).AddObserver(recorder).LinesExContext(ctx, nil, func(e *LineEvent) {
+		consolez.DefaultCLI.Prefixed(m.name, width, i, e.Stream == StreamStderr, e.Text)
+	})
+
+	r.Duration = time.Since(start)
+	r.ResourceUsage = recorder.get()
+	r.Error = err
+
+	switch eErr, ok := errorz.As[*ExecutionError](err); {
+	case err == nil:
+		r.Status = GroupStatusSucceeded
+		r.ExitCode = 0
+	case ok && eErr.IsCanceled() && ctx.Err() != nil:
+		r.Status = GroupStatusCanceled
+		r.ExitCode = eErr.GetExitCode()
+	case ok:
+		r.Status = GroupStatusFailed
+		r.ExitCode = eErr.GetExitCode()
+	default:
+		r.Status = GroupStatusFailed
+	}
+}
+
+func (g *Group) printSummary(results []*GroupResult) {
+	t := consolez.DefaultCLI.NewTable("Command", "Status", "Duration", "Exit Code", "CPU Time", "Peak RSS")
+
+	for _, r := range results {
+		exitCode := "-"
+
+		if r.ExitCode >= 0 {
+			exitCode = fmt.Sprintf("%v", r.ExitCode)
+		}
+
+		duration := "-"
+
+		if r.Status != GroupStatusSkipped {
+			duration = r.Duration.Round(time.Millisecond).String()
+		}
+
+		cpuTime, peakRSS := "-", "-"
+
+		if r.ResourceUsage != nil {
+			cpuTime = (r.ResourceUsage.UserTime + r.ResourceUsage.SystemTime).Round(time.Millisecond).String()
+
+			if r.ResourceUsage.PeakRSS >= 0 {
+				peakRSS = formatBytes(r.ResourceUsage.PeakRSS)
+			}
+		}
+
+		t.AddRow(r.Name, r.Status.String(), duration, exitCode, cpuTime, peakRSS)
+	}
+
+	t.Print()
+}
+
+// resourceUsageRecorder implements the [Observer] interface by recording the resource usage of the last execution.
+type resourceUsageRecorder struct {
+	m     *sync.Mutex
+	usage *ResourceUsage
+}
+
+// OnStart implements the [Observer] interface.
+func (r *resourceUsageRecorder) OnStart(_ *StartEvent) {
+	// intentionally empty
+}
+
+// OnExit implements the [Observer] interface.
+func (r *resourceUsageRecorder) OnExit(e *ExitEvent) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.usage = e.ResourceUsage
+}
+
+func (r *resourceUsageRecorder) get() *ResourceUsage {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.usage
+}
+
+func (g *Group) clone() *Group {
+	gg := &Group{
+		members:     memz.ShallowCopySlice(g.members),
+		concurrency: g.concurrency,
+		policy:      g.policy,
+		echo:        nil,
+		isSummary:   g.isSummary,
+	}
+
+	if g.echo != nil {
+		gg.echo = memz.Ptr(*g.echo)
+	}
+
+	return gg
+}
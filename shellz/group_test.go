@@ -0,0 +1,199 @@
+package shellz_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/consolez"
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type GroupSuite struct {
+	// intentionally empty
+}
+
+func TestGroupSuite(t *testing.T) {
+	fixturez.RunSuite(t, &GroupSuite{})
+}
+
+func (*GroupSuite) TestGroupStatus_String(g *WithT) {
+	g.Expect(shellz.GroupStatusSucceeded.String()).To(Equal("succeeded"))
+	g.Expect(shellz.GroupStatusFailed.String()).To(Equal("failed"))
+	g.Expect(shellz.GroupStatusCanceled.String()).To(Equal("canceled"))
+	g.Expect(shellz.GroupStatusSkipped.String()).To(Equal("skipped"))
+	g.Expect(shellz.GroupStatus(100).String()).To(Equal("GroupStatus(100)"))
+}
+
+func (*GroupSuite) TestBuilder(g *WithT) {
+	g1 := shellz.NewGroup()
+	g.Expect(g1.GetNames()).To(BeEmpty())
+	g.Expect(g1.GetConcurrency()).To(BeNumerically(">=", 1))
+	g.Expect(g1.GetPolicy()).To(Equal(shellz.GroupPolicyFailFast))
+	g.Expect(g1.GetEcho()).To(BeNil())
+	g.Expect(g1.GetSummary()).To(BeTrue())
+
+	g2 := g1.
+		Add("a", shellz.NewCommand("true")).
+		Add("b", shellz.NewCommand("true")).
+		SetConcurrency(0).
+		SetPolicy(shellz.GroupPolicyCollectAll).
+		SetEcho(false).
+		SetSummary(false)
+
+	g.Expect(g2.GetNames()).To(Equal([]string{"a", "b"}))
+	g.Expect(g2.GetConcurrency()).To(Equal(1))
+	g.Expect(g2.GetPolicy()).To(Equal(shellz.GroupPolicyCollectAll))
+	g.Expect(g2.GetEcho()).To(HaveValue(BeFalse()))
+	g.Expect(g2.GetSummary()).To(BeFalse())
+	g.Expect(g1.GetNames()).To(BeEmpty())
+}
+
+func (*GroupSuite) TestRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	results := shellz.NewGroup().
+		Add("first", shellz.NewCommand("sh", "-c", "echo out")).
+		Add("second-command", shellz.NewCommand("sh", "-c", "echo err >&2")).
+		MustRun()
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(errBuf).To(BeEmpty())
+
+	lines := strings.Split(strings.TrimSuffix(outBuf, "\n"), "\n")
+	g.Expect(lines[:4]).To(ConsistOf(
+		fmt.Sprintf("[.........first] %v sh -c 'echo out'", consolez.IconRunner),
+		"[.........first] out",
+		fmt.Sprintf("[second-command] %v sh -c 'echo err >&2'", consolez.IconRunner),
+		"[second-command] err"))
+	g.Expect(lines[4]).To(MatchRegexp(`^Command\s+Status\s+Duration\s+Exit Code\s+CPU Time\s+Peak RSS\s*$`))
+	g.Expect(lines[5]).To(MatchRegexp(`^first\s+succeeded\s+\d+(\.\d+)?[µm]?s\s+0\s+\d+(\.\d+)?[µm]?s\s+\d+\.\d [KMG]iB\s*$`))
+	g.Expect(lines[6]).To(MatchRegexp(`^second-command\s+succeeded\s+\d+(\.\d+)?[µm]?s\s+0\s+\d+(\.\d+)?[µm]?s\s+\d+\.\d [KMG]iB\s*$`))
+
+	g.Expect(results).To(HaveLen(2))
+	g.Expect(results[0].Name).To(Equal("first"))
+	g.Expect(results[0].Status).To(Equal(shellz.GroupStatusSucceeded))
+	g.Expect(results[0].ExitCode).To(Equal(0))
+	g.Expect(results[0].Error).To(Succeed())
+	g.Expect(results[0].ResourceUsage).ToNot(BeNil())
+	g.Expect(results[0].ResourceUsage.PeakRSS).To(BeNumerically(">", 0))
+	g.Expect(results[1].Name).To(Equal("second-command"))
+}
+
+func (*GroupSuite) TestRun_Concurrency(g *WithT) {
+	grp := shellz.NewGroup().SetEcho(false).SetSummary(false)
+
+	for i := 0; i < 4; i++ {
+		grp = grp.Add(fmt.Sprintf("c%v", i), shellz.NewCommand("sleep", "0.2"))
+	}
+
+	start := time.Now()
+	grp.SetConcurrency(4).MustRun()
+	g.Expect(time.Since(start)).To(BeNumerically("<", 600*time.Millisecond))
+
+	start = time.Now()
+	grp.SetConcurrency(2).MustRun()
+	g.Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+}
+
+func (*GroupSuite) TestRun_FailFast(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	results, err := shellz.NewGroup().
+		SetConcurrency(2).
+		SetEcho(false).
+		Add("slow", shellz.NewCommand("sleep", "5")).
+		Add("fail", shellz.NewCommand("sh", "-c", "sleep 0.1; exit 3")).
+		Add("pending", shellz.NewCommand("true")).
+		Run()
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(errBuf).To(BeEmpty())
+	g.Expect(outBuf).To(MatchRegexp(`(?m)^slow\s+canceled\s+\S+\s+-\s+\S+\s+\d+\.\d [KMG]iB\s*$`))
+	g.Expect(outBuf).To(MatchRegexp(`(?m)^fail\s+failed\s+\S+\s+3\s+\S+\s+\d+\.\d [KMG]iB\s*$`))
+	g.Expect(outBuf).To(MatchRegexp(`(?m)^pending\s+skipped\s+-\s+-\s+-\s+-\s*$`))
+
+	g.Expect(err).To(MatchError("group: 1/3 commands failed (fail: execution error: exit status 3)"))
+	gErr, ok := errorz.As[*shellz.GroupError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(gErr.GetResults()).To(Equal(results))
+	g.Expect(gErr.GetFailed()).To(Equal(results[1:2]))
+	g.Expect(gErr.Unwrap()).To(Equal([]error{results[1].Error}))
+
+	g.Expect(results[0].Status).To(Equal(shellz.GroupStatusCanceled))
+	g.Expect(results[1].Status).To(Equal(shellz.GroupStatusFailed))
+	g.Expect(results[1].ExitCode).To(Equal(3))
+	g.Expect(results[2].Status).To(Equal(shellz.GroupStatusSkipped))
+	g.Expect(results[2].ExitCode).To(Equal(-1))
+}
+
+func (*GroupSuite) TestRun_CollectAll(g *WithT) {
+	results, err := shellz.NewGroup().
+		SetConcurrency(1).
+		SetPolicy(shellz.GroupPolicyCollectAll).
+		SetEcho(false).
+		SetSummary(false).
+		Add("f1", shellz.NewCommand("sh", "-c", "exit 1")).
+		Add("ok", shellz.NewCommand("true")).
+		Add("f2", shellz.NewCommand("ed1c6a2e-5c46-4d6e-b5f4-bb5b8a2bd1c3")).
+		Run()
+
+	g.Expect(err).To(MatchError(`group: 2/3 commands failed (f1: execution error: exit status 1; f2: execution error: exec: "ed1c6a2e-5c46-4d6e-b5f4-bb5b8a2bd1c3": executable file not found in $PATH)`))
+	g.Expect(results[0].Status).To(Equal(shellz.GroupStatusFailed))
+	g.Expect(results[1].Status).To(Equal(shellz.GroupStatusSucceeded))
+	g.Expect(results[2].Status).To(Equal(shellz.GroupStatusFailed))
+	g.Expect(results[2].ExitCode).To(Equal(-1))
+
+	g.Expect(func() {
+		shellz.NewGroup().SetEcho(false).SetSummary(false).Add("f", shellz.NewCommand("false")).MustRun()
+	}).To(Panic())
+}
+
+func (*GroupSuite) TestRunContext_Canceled(g *WithT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	results, err := shellz.NewGroup().
+		SetConcurrency(1).
+		SetEcho(false).
+		SetSummary(false).
+		Add("sleep", shellz.NewCommand("sleep", "5")).
+		Add("pending", shellz.NewCommand("true")).
+		RunContext(ctx)
+
+	g.Expect(results[0].Status).To(Equal(shellz.GroupStatusCanceled))
+	g.Expect(results[1].Status).To(Equal(shellz.GroupStatusSkipped))
+	g.Expect(err).To(MatchError("group: 2/2 commands canceled or skipped: context canceled"))
+	g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+
+	gErr, ok := errorz.As[*shellz.GroupError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(gErr.GetResults()).To(Equal(results))
+	g.Expect(gErr.GetFailed()).To(BeEmpty())
+}
+
+func (*GroupSuite) TestRunContext_Done(g *WithT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := shellz.NewGroup().
+		SetEcho(false).
+		SetSummary(false).
+		Add("true", shellz.NewCommand("true")).
+		RunContext(ctx)
+
+	g.Expect(results[0].Status).To(Equal(shellz.GroupStatusSkipped))
+	g.Expect(err).To(MatchError("group: 1/1 commands canceled or skipped: context canceled"))
+	g.Expect(func() { shellz.NewGroup().SetEcho(false).SetSummary(false).MustRunContext(ctx) }).
+		To(PanicWith(MatchError("group: 0/0 commands canceled or skipped: context canceled")))
+}
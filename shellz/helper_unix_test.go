@@ -0,0 +1,126 @@
+//go:build unix
+
+package shellz_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/ibrt/golang-utils/errorz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+const (
+	helperProcessEnvKey  = "SHELLZ_TEST_HELPER_PROCESS"
+	helperProcessReady   = "ready"
+	helperProcessResult  = "result: "
+	helperProcessCommand = "echo " + helperProcessReady + " && exec sleep "
+)
+
+// helperProcesses are the scenarios that can be run by [TestHelperProcess].
+var helperProcesses = map[string]func(){
+	"process-group": func() {
+		err := shellz.NewCommand("sh", "-c", helperProcessCommand+"30").
+			SetEcho(false).
+			SetProcessGroup(true).
+			Run()
+
+		printHelperProcessResult(err)
+	},
+	"interactive": func() {
+		_, tty, err := pty.Open()
+		errorz.MaybeMustWrap(err)
+		os.Stdin = tty
+
+		for _, duration := range []string{"0.5", "30"} {
+			err := shellz.NewCommand("sh", "-c", helperProcessCommand+duration).
+				SetEcho(false).
+				SetInteractive(true).
+				Run()
+
+			printHelperProcessResult(err)
+		}
+	},
+	"interactive-early-signal": func() {
+		_, tty, err := pty.Open()
+		errorz.MaybeMustWrap(err)
+		os.Stdin = tty
+
+		err = shellz.NewCommand("sleep", "30").
+			SetEcho(false).
+			SetInteractive(true).
+			SetExecutor(shellz.ChainExecutor(&shellz.RealExecutor{}, newEarlySignalMiddleware())).
+			Run()
+
+		printHelperProcessResult(err)
+	},
+}
+
+// newEarlySignalMiddleware returns a middleware that sends SIGTERM to the current process right before the command is
+// started, and gives it time to be received.
+func newEarlySignalMiddleware() shellz.Middleware {
+	return func(call *shellz.ExecutorCall, next shellz.ExecutorHandler) *shellz.ExecutorResult {
+		if call.Method == shellz.ExecutorMethodExecCmdStart {
+			errorz.MaybeMustWrap(syscall.Kill(os.Getpid(), syscall.SIGTERM))
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		return next(call)
+	}
+}
+
+// TestHelperProcess is not a real test: it runs a scenario from [helperProcesses] in a child process started by
+// [runHelperProcess], so that tests can send signals to it instead of to the test binary.
+func TestHelperProcess(*testing.T) {
+	name := os.Getenv(helperProcessEnvKey)
+	if name == "" {
+		return
+	}
+
+	helperProcesses[name]()
+	os.Exit(0)
+}
+
+// runHelperProcess runs the given scenario (see [TestHelperProcess]), calling "onReady" with the pid of the helper
+// process each time the command run by the scenario is ready to be signaled. It returns the results printed by the
+// scenario.
+func runHelperProcess(g *WithT, name string, onReady func(pid int)) []string {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), helperProcessEnvKey+"="+name)
+	cmd.Stderr = os.Stderr
+
+	stdout, err := cmd.StdoutPipe()
+	g.Expect(err).To(Succeed())
+	g.Expect(cmd.Start()).To(Succeed())
+
+	results := make([]string, 0)
+	scanner := bufio.NewScanner(stdout)
+
+	for scanner.Scan() {
+		if line := scanner.Text(); line == helperProcessReady {
+			onReady(cmd.Process.Pid)
+		} else if result, ok := strings.CutPrefix(line, helperProcessResult); ok {
+			results = append(results, result)
+		}
+	}
+
+	g.Expect(cmd.Wait()).To(Succeed())
+	return results
+}
+
+func printHelperProcessResult(v any) {
+	fmt.Println(helperProcessResult + fmt.Sprint(v))
+}
+
+func mustSignal(g *WithT, pid int, sig syscall.Signal) {
+	g.Expect(syscall.Kill(pid, sig)).To(Succeed())
+}
@@ -0,0 +1,100 @@
+package shellz
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/mattn/go-isatty"
+)
+
+var (
+	errNoTerminal = errors.New("interactive mode requires standard input to be a terminal")
+)
+
+// SetInteractive configures the command to run attached to the terminal of the current process: its standard input,
+// output and error are the ones of the current process, so that interactive tools (e.g. "psql" or a shell) can be
+// driven by the user. It applies to [*Command.Run], and is ignored by the other modes.
+//
+// If standard input is not a terminal, the command fails immediately instead of waiting for input that will never
+// come (see [*ExecutionError.IsNoTerminal]). While the command runs, SIGTERM and SIGHUP received by the current process
+// are forwarded to it, while SIGINT and SIGQUIT are left to the command, which receives them from the terminal.
+// Output is not captured, counted or limited (see [ResourceLimits.MaxOutputBytes]), the input set with
+// [*Command.SetIn] and PTY mode (see [*Command.SetPTY]) are ignored, and the command is never started in its own
+// process group (see [*Command.SetProcessGroup]), as only the foreground process group can read from the terminal.
+func (c *Command) SetInteractive(isInteractive bool) *Command {
+	cc := c.clone()
+	cc.isInteractive = isInteractive
+	return cc
+}
+
+// GetInteractive returns the current interactive mode configuration.
+func (c *Command) GetInteractive() bool {
+	return c.isInteractive
+}
+
+// IsNoTerminal returns true if the command was not started because it is interactive (see [*Command.SetInteractive])
+// but standard input is not a terminal.
+func (e *ExecutionError) IsNoTerminal() bool {
+	return errors.Is(e.err, errNoTerminal)
+}
+
+// runInteractive runs "cmd" attached to the terminal of the current process.
+func (c *Command) runInteractive(ctx context.Context, cmd *exec.Cmd, capture *outputCapture, rd *redirections) error {
+	if !isTerminal(os.Stdin) {
+		return errNoTerminal
+	}
+
+	capture.setUncounted()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	rd.apply(cmd, capture)
+
+	// Signals received before the process starts are delivered as soon as it does, as the current process no longer
+	// terminates on them while they are being forwarded.
+	var (
+		m          sync.Mutex
+		isStarted  bool
+		pendingSig syscall.Signal
+	)
+
+	stopForwardSignals := forwardSignals(func(sig syscall.Signal) {
+		if sig == syscall.SIGINT || sig == syscall.SIGQUIT {
+			return // the terminal delivers these to the whole foreground process group
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		if !isStarted {
+			pendingSig = sig
+			return
+		}
+
+		_ = signalProcess(cmd, sig, false)
+	})
+	defer stopForwardSignals()
+
+	if err := c.getExecutor().ExecCmdStart(ctx, c, cmd); err != nil {
+		return err
+	}
+
+	m.Lock()
+	isStarted = true
+
+	if pendingSig != 0 && cmd.Process != nil {
+		_ = signalProcess(cmd, pendingSig, false)
+	}
+
+	m.Unlock()
+
+	return c.getExecutor().ExecCmdWait(ctx, c, cmd)
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
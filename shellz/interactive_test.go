@@ -0,0 +1,115 @@
+package shellz_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/creack/pty"
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type InteractiveSuite struct {
+	// intentionally empty
+}
+
+func TestInteractiveSuite(t *testing.T) {
+	fixturez.RunSuite(t, &InteractiveSuite{})
+}
+
+func (*InteractiveSuite) TestSetInteractive(g *WithT) {
+	c := shellz.NewCommand("cmd")
+	g.Expect(c.GetInteractive()).To(BeFalse())
+	g.Expect(c.SetInteractive(true).GetInteractive()).To(BeTrue())
+	g.Expect(c.GetInteractive()).To(BeFalse())
+}
+
+func (*InteractiveSuite) TestRun_NoTerminal(g *WithT) {
+	r, w, err := os.Pipe()
+	g.Expect(err).To(Succeed())
+	defer func() { _ = r.Close() }()
+	defer func() { _ = w.Close() }()
+	restore := swapStdin(r)
+	defer restore()
+
+	err = shellz.NewCommand("cat").SetEcho(false).SetInteractive(true).Run()
+	g.Expect(err).To(HaveOccurred())
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.IsNoTerminal()).To(BeTrue())
+	g.Expect(eErr.IsNotFound()).To(BeFalse())
+	g.Expect(eErr.GetExitCode()).To(Equal(-1))
+	g.Expect(eErr.GetDuration()).To(BeZero())
+	g.Expect(eErr.GetSummary()).To(Equal(`"cat" failed to start: interactive mode requires a terminal`))
+}
+
+func (*InteractiveSuite) TestRun_Terminal(g *WithT) {
+	ptmx, tty := mustOpenPTY(g)
+	defer func() { _ = ptmx.Close() }()
+	defer func() { _ = tty.Close() }()
+	restore := swapStdin(tty)
+	defer restore()
+
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	_, err := ptmx.Write([]byte("input\n"))
+	g.Expect(err).To(Succeed())
+
+	g.Expect(shellz.NewCommand("sh", "-c", `test -t 0 && echo tty; head -n 1; echo err >&2`).
+		SetEcho(false).
+		SetIn(nil).
+		SetProcessGroup(true).
+		SetInteractive(true).
+		Run()).To(Succeed())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("tty\ninput\n"))
+	g.Expect(errBuf).To(Equal("err\n"))
+}
+
+func (*InteractiveSuite) TestRun_Observed(g *WithT) {
+	ptmx, tty := mustOpenPTY(g)
+	defer func() { _ = ptmx.Close() }()
+	defer func() { _ = tty.Close() }()
+	restore := swapStdin(tty)
+	defer restore()
+
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	o := newTestObserver()
+
+	g.Expect(shellz.NewCommand("echo", "out").
+		SetEcho(false).
+		SetInteractive(true).
+		AddObserver(o).
+		Run()).To(Succeed())
+
+	g.Expect(o.exits).To(HaveLen(1))
+	g.Expect(o.exits[0].StdoutBytes).To(BeEquivalentTo(-1))
+	g.Expect(o.exits[0].StderrBytes).To(BeEquivalentTo(-1))
+}
+
+func mustOpenPTY(g *WithT) (*os.File, *os.File) {
+	ptmx, tty, err := pty.Open()
+	g.Expect(err).To(Succeed())
+	return ptmx, tty
+}
+
+func swapStdin(f *os.File) func() {
+	stdin := os.Stdin
+	os.Stdin = f
+	return func() { os.Stdin = stdin }
+}
+
+func swapStdoutStderr(f *os.File) func() {
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = f, f
+	return func() { os.Stdout, os.Stderr = stdout, stderr }
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package shellz_test
+
+import (
+	"syscall"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func (*InteractiveSuite) TestRun_Signals(g *WithT) {
+	start := time.Now()
+	signals := []syscall.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+	results := runHelperProcess(g, "interactive", func(pid int) {
+		mustSignal(g, pid, signals[0])
+		signals = signals[1:]
+	})
+
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(results).To(Equal([]string{
+		"<nil>", // SIGINT is left to the command, which receives it from the terminal
+		"execution error: signal: terminated",
+	}))
+}
+
+func (*InteractiveSuite) TestRun_EarlySignal(g *WithT) {
+	start := time.Now()
+
+	results := runHelperProcess(g, "interactive-early-signal", func(int) {
+		// intentionally empty: the signal is sent by the scenario itself
+	})
+
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(results).To(Equal([]string{"execution error: signal: terminated"}))
+}
@@ -0,0 +1,278 @@
+package shellz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+)
+
+// ExecutorMethod describes an [Executor] method.
+type ExecutorMethod string
+
+// Known executor methods.
+const (
+	ExecutorMethodExecCmdCombinedOutput ExecutorMethod = "ExecCmdCombinedOutput"
+	ExecutorMethodExecCmdOutput         ExecutorMethod = "ExecCmdOutput"
+	ExecutorMethodExecCmdRun            ExecutorMethod = "ExecCmdRun"
+	ExecutorMethodExecCmdStart          ExecutorMethod = "ExecCmdStart"
+	ExecutorMethodExecCmdWait           ExecutorMethod = "ExecCmdWait"
+	ExecutorMethodExecLookPath          ExecutorMethod = "ExecLookPath"
+	ExecutorMethodOSChdir               ExecutorMethod = "OSChdir"
+	ExecutorMethodSyscallExec           ExecutorMethod = "SyscallExec"
+)
+
+// ExecutorCall describes a call to an [Executor] method. Only the fields relevant to the method are set.
+type ExecutorCall struct {
+	// Method is the [Executor] method being called.
+	Method ExecutorMethod
+
+	// Context is the context (only for the "ExecCmd*" methods).
+	Context context.Context
+
+	// Command is the command.
+	Command *Command
+
+	// Cmd is the prepared [*exec.Cmd] (only for the "ExecCmd*" methods).
+	Cmd *exec.Cmd
+
+	// File is the file to look up (only for [ExecutorMethodExecLookPath]).
+	File string
+
+	// Dir is the directory to change to (only for [ExecutorMethodOSChdir]).
+	Dir string
+
+	// Argv0, Argv and Envv are the arguments to [syscall.Exec] (only for [ExecutorMethodSyscallExec]).
+	Argv0 string
+	Argv  []string
+	Envv  []string
+}
+
+// ExecutorResult describes the result of a call to an [Executor] method. Only the fields relevant to the method are set.
+type ExecutorResult struct {
+	// Output is the output (only for [ExecutorMethodExecCmdOutput] and [ExecutorMethodExecCmdCombinedOutput]).
+	Output []byte
+
+	// Path is the path found (only for [ExecutorMethodExecLookPath]).
+	Path string
+
+	// Err is the error, if the call failed.
+	Err error
+}
+
+// ExecutorHandler handles a call to an [Executor] method.
+type ExecutorHandler func(call *ExecutorCall) *ExecutorResult
+
+// Middleware intercepts calls to an [Executor] method. It can inspect or alter the call and the result, and normally
+// delegates to "next" (which calls the next middleware, or the base [Executor]). A single function handles all the
+// methods of the [Executor] interface (see [ExecutorCall.Method]), so that cross-cutting behaviors don't need a full
+// [Executor] implementation. Calls may be concurrent.
+type Middleware func(call *ExecutorCall, next ExecutorHandler) *ExecutorResult
+
+var (
+	_ Executor          = (*chainExecutor)(nil)
+	_ SimulatedExecutor = (*chainExecutor)(nil)
+)
+
+// chainExecutor implements the [Executor] interface by passing calls through a chain of middlewares.
+type chainExecutor struct {
+	base    Executor
+	handler ExecutorHandler
+}
+
+// ChainExecutor returns an [Executor] that passes each call through the given middlewares, in order (i.e. the first
+// middleware is the outermost one), and finally to "base". If there are no middlewares it returns "base" itself.
+func ChainExecutor(base Executor, mws ...Middleware) Executor {
+	if len(mws) == 0 {
+		return base
+	}
+
+	e := &chainExecutor{
+		base: base,
+	}
+
+	e.handler = e.callBase
+
+	for _, mw := range slices.Backward(mws) {
+		next := e.handler
+
+		e.handler = func(call *ExecutorCall) *ExecutorResult {
+			return mw(call, next)
+		}
+	}
+
+	return e
+}
+
+// IsSimulated implements the [SimulatedExecutor] interface.
+func (e *chainExecutor) IsSimulated() bool {
+	return IsSimulated(e.base)
+}
+
+// ExecCmdCombinedOutput implements the [Executor] interface.
+func (e *chainExecutor) ExecCmdCombinedOutput(ctx context.Context, c *Command, cmd *exec.Cmd) ([]byte, error) {
+	r := e.handler(&ExecutorCall{Method: ExecutorMethodExecCmdCombinedOutput, Context: ctx, Command: c, Cmd: cmd})
+	return r.Output, r.Err
+}
+
+// ExecCmdOutput implements the [Executor] interface.
+func (e *chainExecutor) ExecCmdOutput(ctx context.Context, c *Command, cmd *exec.Cmd) ([]byte, error) {
+	r := e.handler(&ExecutorCall{Method: ExecutorMethodExecCmdOutput, Context: ctx, Command: c, Cmd: cmd})
+	return r.Output, r.Err
+}
+
+// ExecCmdRun implements the [Executor] interface.
+func (e *chainExecutor) ExecCmdRun(ctx context.Context, c *Command, cmd *exec.Cmd) error {
+	return e.handler(&ExecutorCall{Method: ExecutorMethodExecCmdRun, Context: ctx, Command: c, Cmd: cmd}).Err
+}
+
+// ExecCmdStart implements the [Executor] interface.
+func (e *chainExecutor) ExecCmdStart(ctx context.Context, c *Command, cmd *exec.Cmd) error {
+	return e.handler(&ExecutorCall{Method: ExecutorMethodExecCmdStart, Context: ctx, Command: c, Cmd: cmd}).Err
+}
+
+// ExecCmdWait implements the [Executor] interface.
+func (e *chainExecutor) ExecCmdWait(ctx context.Context, c *Command, cmd *exec.Cmd) error {
+	return e.handler(&ExecutorCall{Method: ExecutorMethodExecCmdWait, Context: ctx, Command: c, Cmd: cmd}).Err
+}
+
+// ExecLookPath implements the [Executor] interface.
+func (e *chainExecutor) ExecLookPath(c *Command, file string) (string, error) {
+	r := e.handler(&ExecutorCall{Method: ExecutorMethodExecLookPath, Command: c, File: file})
+	return r.Path, r.Err
+}
+
+// OSChdir implements the [Executor] interface.
+func (e *chainExecutor) OSChdir(c *Command, dir string) error {
+	return e.handler(&ExecutorCall{Method: ExecutorMethodOSChdir, Command: c, Dir: dir}).Err
+}
+
+// SyscallExec implements the [Executor] interface.
+func (e *chainExecutor) SyscallExec(c *Command, argv0 string, argv []string, envv []string) error {
+	return e.handler(&ExecutorCall{Method: ExecutorMethodSyscallExec, Command: c, Argv0: argv0, Argv: argv, Envv: envv}).Err
+}
+
+// callBase dispatches the call to the base [Executor].
+func (e *chainExecutor) callBase(call *ExecutorCall) *ExecutorResult {
+	r := &ExecutorResult{}
+
+	switch call.Method {
+	case ExecutorMethodExecCmdCombinedOutput:
+		r.Output, r.Err = e.base.ExecCmdCombinedOutput(call.Context, call.Command, call.Cmd)
+	case ExecutorMethodExecCmdOutput:
+		r.Output, r.Err = e.base.ExecCmdOutput(call.Context, call.Command, call.Cmd)
+	case ExecutorMethodExecCmdRun:
+		r.Err = e.base.ExecCmdRun(call.Context, call.Command, call.Cmd)
+	case ExecutorMethodExecCmdStart:
+		r.Err = e.base.ExecCmdStart(call.Context, call.Command, call.Cmd)
+	case ExecutorMethodExecCmdWait:
+		r.Err = e.base.ExecCmdWait(call.Context, call.Command, call.Cmd)
+	case ExecutorMethodExecLookPath:
+		r.Path, r.Err = e.base.ExecLookPath(call.Command, call.File)
+	case ExecutorMethodOSChdir:
+		r.Err = e.base.OSChdir(call.Command, call.Dir)
+	case ExecutorMethodSyscallExec:
+		r.Err = e.base.SyscallExec(call.Command, call.Argv0, call.Argv, call.Envv)
+	default:
+		r.Err = errorz.Errorf("unknown executor method: %v", call.Method)
+	}
+
+	return r
+}
+
+// Use adds a middleware wrapping the [Executor] of this command (see [*Command.SetExecutor] and [ChainExecutor]).
+// Middlewares are applied in the order they are added (i.e. the first middleware is the outermost one), and are
+// preserved if the executor is changed later.
+func (c *Command) Use(mw Middleware) *Command {
+	cc := c.clone()
+	cc.middlewares = append(cc.middlewares, mw)
+	return cc
+}
+
+// GetMiddlewares returns the middlewares added to this command.
+func (c *Command) GetMiddlewares() []Middleware {
+	return slices.Clone(c.middlewares)
+}
+
+// getExecutor returns the [Executor] of this command, wrapped by its middlewares and cache (see [*Command.SetCache]).
+func (c *Command) getExecutor() Executor {
+	if c.cache == nil || c.isPTY || c.isDryRun() {
+		return ChainExecutor(c.executor, c.middlewares...)
+	}
+
+	return ChainExecutor(c.executor, append(slices.Clip(c.middlewares), c.cache.newMiddleware(c.cacheOptions))...)
+}
+
+// TimingFunc is called by the middleware returned by [NewTimingMiddleware].
+type TimingFunc func(call *ExecutorCall, d time.Duration, err error)
+
+// NewTimingMiddleware returns a [Middleware] that measures each call and reports it to "f". For calls to
+// [ExecutorMethodExecCmdWait], the duration is measured from the corresponding call to [ExecutorMethodExecCmdStart]
+// (if seen by the same middleware), so that it reflects the run time of the process.
+func NewTimingMiddleware(f TimingFunc) Middleware {
+	m := &sync.Mutex{}
+	startTimes := make(map[*exec.Cmd]time.Time)
+
+	return func(call *ExecutorCall, next ExecutorHandler) *ExecutorResult {
+		startTime := time.Now()
+		r := next(call)
+
+		switch call.Method {
+		case ExecutorMethodExecCmdStart:
+			if r.Err == nil {
+				m.Lock()
+				startTimes[call.Cmd] = startTime
+				m.Unlock()
+			}
+		case ExecutorMethodExecCmdWait:
+			m.Lock()
+			if t, ok := startTimes[call.Cmd]; ok {
+				startTime = t
+				delete(startTimes, call.Cmd)
+			}
+			m.Unlock()
+		}
+
+		f(call, time.Since(startTime), r.Err)
+		return r
+	}
+}
+
+// NewLoggingMiddleware returns a [Middleware] that writes a line to "w" for each call, after it returns, e.g.
+//
+//	[ExecCmdRun] go test ./... (ok, 1.234s)
+//	[ExecCmdOutput] git rev-parse HEAD (error: exit status 128, 12ms)
+//
+// Durations are measured like in [NewTimingMiddleware]. Secrets are redacted (see [*Command.AddSecretParams]).
+func NewLoggingMiddleware(w io.Writer) Middleware {
+	m := &sync.Mutex{}
+
+	return NewTimingMiddleware(func(call *ExecutorCall, d time.Duration, err error) {
+		status := "ok"
+
+		if err != nil {
+			status = "error: " + call.Command.redact(err.Error())
+		}
+
+		m.Lock()
+		defer m.Unlock()
+		_, _ = fmt.Fprintf(w, "[%v] %v (%v, %v)\n", call.Method, getCallTarget(call), status, d.Round(time.Millisecond))
+	})
+}
+
+// getCallTarget returns a human-readable description of the target of a call.
+func getCallTarget(call *ExecutorCall) string {
+	switch call.Method {
+	case ExecutorMethodExecLookPath:
+		return call.Command.redact(call.File)
+	case ExecutorMethodOSChdir:
+		return call.Command.redact(call.Dir)
+	default:
+		return call.Command.String()
+	}
+}
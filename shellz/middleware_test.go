@@ -0,0 +1,195 @@
+package shellz_test
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type MiddlewareSuite struct {
+	// intentionally empty
+}
+
+func TestMiddlewareSuite(t *testing.T) {
+	fixturez.RunSuite(t, &MiddlewareSuite{})
+}
+
+func newRecordingMiddleware(m *sync.Mutex, calls *[]string, name string) shellz.Middleware {
+	return func(call *shellz.ExecutorCall, next shellz.ExecutorHandler) *shellz.ExecutorResult {
+		m.Lock()
+		*calls = append(*calls, name+" before "+string(call.Method))
+		m.Unlock()
+
+		r := next(call)
+
+		m.Lock()
+		*calls = append(*calls, name+" after "+string(call.Method))
+		m.Unlock()
+
+		return r
+	}
+}
+
+func (*MiddlewareSuite) TestChainExecutor(g *WithT) {
+	base := &shellz.RealExecutor{}
+	g.Expect(shellz.ChainExecutor(base)).To(BeIdenticalTo(base))
+
+	m := &sync.Mutex{}
+	calls := make([]string, 0)
+
+	e := shellz.ChainExecutor(base,
+		newRecordingMiddleware(m, &calls, "a"),
+		newRecordingMiddleware(m, &calls, "b"))
+
+	g.Expect(shellz.NewCommand("echo", "out").SetEcho(false).SetExecutor(e).OutputString(false)).To(Equal("out\n"))
+	g.Expect(calls).To(Equal([]string{
+		"a before ExecCmdOutput",
+		"b before ExecCmdOutput",
+		"b after ExecCmdOutput",
+		"a after ExecCmdOutput",
+	}))
+}
+
+func (*MiddlewareSuite) TestChainExecutor_AllMethods(g *WithT) {
+	m := &sync.Mutex{}
+	calls := make([]string, 0)
+	mw := newRecordingMiddleware(m, &calls, "a")
+
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	c := shellz.NewCommand("sh", "-c", "echo out").SetEcho(false).Use(mw)
+	g.Expect(c.Run()).To(Succeed())
+	g.Expect(c.CombinedOutputString()).To(Equal("out\n"))
+	g.Expect(c.Lines(func(string) {})).To(Succeed())
+
+	g.Expect(calls).To(Equal([]string{
+		"a before ExecCmdRun",
+		"a after ExecCmdRun",
+		"a before ExecCmdCombinedOutput",
+		"a after ExecCmdCombinedOutput",
+		"a before ExecCmdStart",
+		"a after ExecCmdStart",
+		"a before ExecCmdWait",
+		"a after ExecCmdWait",
+	}))
+}
+
+func (*MiddlewareSuite) TestChainExecutor_Exec(g *WithT) {
+	calls := make([]*shellz.ExecutorCall, 0)
+
+	mw := func(call *shellz.ExecutorCall, next shellz.ExecutorHandler) *shellz.ExecutorResult {
+		calls = append(calls, call)
+
+		if call.Method == shellz.ExecutorMethodSyscallExec {
+			return &shellz.ExecutorResult{Err: errorz.Errorf("intercepted")}
+		}
+
+		return next(call)
+	}
+
+	err := shellz.NewCommand("ls", ".").SetDir(".").SetEnv("K", "V").SetEcho(false).Use(mw).Exec()
+	g.Expect(err).To(MatchError(ContainSubstring("intercepted")))
+	g.Expect(calls).To(HaveLen(3))
+
+	g.Expect(calls[0].Method).To(Equal(shellz.ExecutorMethodExecLookPath))
+	g.Expect(calls[0].File).To(Equal("ls"))
+	g.Expect(calls[1].Method).To(Equal(shellz.ExecutorMethodOSChdir))
+	g.Expect(calls[1].Dir).To(Equal("."))
+	g.Expect(calls[2].Method).To(Equal(shellz.ExecutorMethodSyscallExec))
+	g.Expect(calls[2].Argv0).To(HaveSuffix("ls"))
+	g.Expect(calls[2].Argv).To(Equal([]string{"ls", "."}))
+	g.Expect(calls[2].Envv).To(ContainElement("K=V"))
+}
+
+func (*MiddlewareSuite) TestUse(g *WithT) {
+	mw := func(call *shellz.ExecutorCall, next shellz.ExecutorHandler) *shellz.ExecutorResult {
+		if call.Method == shellz.ExecutorMethodExecCmdOutput {
+			return &shellz.ExecutorResult{Output: []byte("fake")}
+		}
+
+		return next(call)
+	}
+
+	c := shellz.NewCommand("echo", "out").SetEcho(false)
+	g.Expect(c.GetMiddlewares()).To(BeEmpty())
+
+	cc := c.Use(mw)
+	g.Expect(cc.GetMiddlewares()).To(HaveLen(1))
+	g.Expect(c.GetMiddlewares()).To(BeEmpty())
+	g.Expect(cc.OutputString(false)).To(Equal("fake"))
+	g.Expect(cc.SetExecutor(&shellz.RealExecutor{}).OutputString(false)).To(Equal("fake"))
+	g.Expect(c.OutputString(false)).To(Equal("out\n"))
+
+	e := shellz.NewDryRunExecutor()
+	g.Expect(c.GetExecutor()).To(BeIdenticalTo(shellz.DefaultExecutor))
+	g.Expect(c.SetExecutor(e).GetExecutor()).To(BeIdenticalTo(e))
+}
+
+func (*MiddlewareSuite) TestNewTimingMiddleware(g *WithT) {
+	m := &sync.Mutex{}
+	methods := make([]shellz.ExecutorMethod, 0)
+	durations := make([]time.Duration, 0)
+	errs := make([]error, 0)
+
+	mw := shellz.NewTimingMiddleware(func(call *shellz.ExecutorCall, d time.Duration, err error) {
+		m.Lock()
+		defer m.Unlock()
+		methods = append(methods, call.Method)
+		durations = append(durations, d)
+		errs = append(errs, err)
+	})
+
+	p, err := shellz.NewCommand("sleep", "0.2").SetEcho(false).Use(mw).Start()
+	g.Expect(err).To(Succeed())
+	time.Sleep(100 * time.Millisecond)
+	g.Expect(p.Wait()).To(Succeed())
+
+	g.Expect(shellz.NewCommand("sh", "-c", "exit 1").SetEcho(false).Use(mw).Run()).ToNot(Succeed())
+
+	g.Expect(methods).To(Equal([]shellz.ExecutorMethod{
+		shellz.ExecutorMethodExecCmdStart,
+		shellz.ExecutorMethodExecCmdWait,
+		shellz.ExecutorMethodExecCmdRun,
+	}))
+	g.Expect(durations[1]).To(BeNumerically(">=", 200*time.Millisecond))
+	g.Expect(errs[0]).To(Succeed())
+	g.Expect(errs[1]).To(Succeed())
+	g.Expect(errs[2]).To(MatchError("exit status 1"))
+}
+
+func (*MiddlewareSuite) TestNewLoggingMiddleware(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	buf := &bytes.Buffer{}
+	mw := shellz.NewLoggingMiddleware(buf)
+
+	g.Expect(shellz.NewCommand("echo", "s3cr3t").AddSecretParams("s3cr3t").SetEcho(false).Use(mw).Run()).To(Succeed())
+	g.Expect(shellz.NewCommand("sh", "-c", "exit 2").SetEcho(false).Use(mw).Output(false)).Error().To(HaveOccurred())
+
+	g.Expect(shellz.NewCommand("ls").SetDir(".").SetEcho(false).Use(mw).
+		Use(func(call *shellz.ExecutorCall, next shellz.ExecutorHandler) *shellz.ExecutorResult {
+			if call.Method == shellz.ExecutorMethodSyscallExec {
+				return &shellz.ExecutorResult{Err: errorz.Errorf("intercepted")}
+			}
+
+			return next(call)
+		}).Exec()).ToNot(Succeed())
+
+	g.Expect(buf.String()).To(MatchRegexp(`^` +
+		regexp.QuoteMeta(`[ExecCmdRun] echo '***' '***' (ok, `) + `[0-9.]+m?s\)\n` +
+		regexp.QuoteMeta(`[ExecCmdOutput] sh -c 'exit 2' (error: exit status 2, `) + `[0-9.]+m?s\)\n` +
+		regexp.QuoteMeta(`[ExecLookPath] ls (ok, `) + `[0-9.]+m?s\)\n` +
+		regexp.QuoteMeta(`[OSChdir] . (ok, `) + `[0-9.]+m?s\)\n` +
+		regexp.QuoteMeta(`[SyscallExec] cd . && ls (error: intercepted, `) + `[0-9.]+m?s\)\n$`))
+}
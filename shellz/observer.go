@@ -0,0 +1,172 @@
+package shellz
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	defaultObservers []Observer
+
+	lastExecutionID = &atomic.Uint64{}
+)
+
+// DefaultObservers are global observers notified of the executions of all commands, in addition to the observers
+// registered on the individual commands (see [*Command.AddObserver]).
+var (
+	DefaultObservers = slices.Clone(defaultObservers)
+)
+
+// RestoreDefaultObservers restores the default value of [DefaultObservers].
+func RestoreDefaultObservers() {
+	DefaultObservers = slices.Clone(defaultObservers)
+}
+
+// RegisterObserver adds an observer to [DefaultObservers].
+func RegisterObserver(observer Observer) {
+	DefaultObservers = append(slices.Clip(DefaultObservers), observer)
+}
+
+// Observer is notified when commands start and exit. Each retry attempt and each stage of a [*Pipeline] is a separate
+// execution. Calls may be concurrent, and must not block.
+//
+// Observing a command does not change where its output goes, so only output that passes through the current process is
+// counted: e.g. the output returned by [*Command.Output], written to files (see [*Command.SetStdoutFile]) or to a
+// pseudo-terminal (see [*Command.SetPTY]). Output that the process writes directly to the terminal or to an inherited
+// file (e.g. with [*Command.Run], unless output is captured or limited, or in interactive mode) is not counted, and its
+// byte counts are reported as -1 (see [ExitEvent]).
+type Observer interface {
+	OnStart(e *StartEvent)
+	OnExit(e *ExitEvent)
+}
+
+// StartEvent describes the start of an execution.
+type StartEvent struct {
+	// ID uniquely identifies the execution within the current process.
+	ID uint64
+
+	// Command is the command being executed.
+	Command *Command
+
+	// Time is the start time.
+	Time time.Time
+}
+
+// ExitEvent describes the end of an execution.
+type ExitEvent struct {
+	// ID uniquely identifies the execution within the current process.
+	ID uint64
+
+	// Command is the command being executed.
+	Command *Command
+
+	// StartTime is the start time.
+	StartTime time.Time
+
+	// Time is the exit time.
+	Time time.Time
+
+	// Duration is the run time.
+	Duration time.Duration
+
+	// ExitCode is the exit code (-1 if not available, e.g. if the command failed to start or was killed by a signal).
+	ExitCode int
+
+	// StdoutBytes is the number of bytes written to standard output (-1 if not available, e.g. for pipeline stages,
+	// or with [*Command.Run] unless output is captured or limited, as the command then writes directly to the terminal).
+	StdoutBytes int64
+
+	// StderrBytes is the number of bytes written to standard error (-1 if not available, e.g. for pipeline stages,
+	// or with [*Command.Run] unless output is captured or limited, as the command then writes directly to the terminal).
+	StderrBytes int64
+
+	// ResourceUsage describes the resources used by the process (nil if not available, e.g. if the command failed to
+	// start or was not run by a real process).
+	ResourceUsage *ResourceUsage
+
+	// Error is the error, if the execution failed.
+	Error *ExecutionError
+}
+
+// AddObserver adds an observer notified of the executions of this command, in addition to [DefaultObservers].
+func (c *Command) AddObserver(observer Observer) *Command {
+	cc := c.clone()
+	cc.observers = append(cc.observers, observer)
+	return cc
+}
+
+// GetObservers returns the observers added to this command (excluding [DefaultObservers]).
+func (c *Command) GetObservers() []Observer {
+	return slices.Clone(c.observers)
+}
+
+func (c *Command) getAllObservers() []Observer {
+	return append(slices.Clone(DefaultObservers), c.observers...)
+}
+
+// observation tracks an execution for the benefit of observers.
+// A nil *observation is valid and notifies nobody.
+type observation struct {
+	c         *Command
+	observers []Observer
+	id        uint64
+	startTime time.Time
+}
+
+// beginObservation notifies the observers of the command (if any) that an execution is starting.
+func (c *Command) beginObservation() *observation {
+	observers := c.getAllObservers()
+	if len(observers) == 0 {
+		return nil
+	}
+
+	o := &observation{
+		c:         c,
+		observers: observers,
+		id:        lastExecutionID.Add(1),
+		startTime: time.Now(),
+	}
+
+	e := &StartEvent{
+		ID:      o.id,
+		Command: c,
+		Time:    o.startTime,
+	}
+
+	for _, observer := range observers {
+		observer.OnStart(e)
+	}
+
+	return o
+}
+
+// end notifies the observers that the execution has ended.
+func (o *observation) end(err *ExecutionError, capture *outputCapture, usage *ResourceUsage) {
+	if o == nil {
+		return
+	}
+
+	now := time.Now()
+
+	e := &ExitEvent{
+		ID:            o.id,
+		Command:       o.c,
+		StartTime:     o.startTime,
+		Time:          now,
+		Duration:      now.Sub(o.startTime),
+		ExitCode:      0,
+		StdoutBytes:   capture.getBytes(StreamStdout),
+		StderrBytes:   capture.getBytes(StreamStderr),
+		ResourceUsage: usage,
+		Error:         err,
+	}
+
+	if err != nil {
+		e.ExitCode = err.GetExitCode()
+	}
+
+	for _, observer := range o.observers {
+		observer.OnExit(e)
+	}
+}
@@ -0,0 +1,193 @@
+package shellz_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type testObserver struct {
+	m      *sync.Mutex
+	starts []*shellz.StartEvent
+	exits  []*shellz.ExitEvent
+}
+
+func newTestObserver() *testObserver {
+	return &testObserver{
+		m:      &sync.Mutex{},
+		starts: make([]*shellz.StartEvent, 0),
+		exits:  make([]*shellz.ExitEvent, 0),
+	}
+}
+
+func (o *testObserver) OnStart(e *shellz.StartEvent) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.starts = append(o.starts, e)
+}
+
+func (o *testObserver) OnExit(e *shellz.ExitEvent) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.exits = append(o.exits, e)
+}
+
+type ObserverSuite struct {
+	// intentionally empty
+}
+
+func TestObserverSuite(t *testing.T) {
+	fixturez.RunSuite(t, &ObserverSuite{})
+}
+
+func (*ObserverSuite) TestRegisterObserver(g *WithT) {
+	defer shellz.RestoreDefaultObservers()
+
+	o1 := newTestObserver()
+	o2 := newTestObserver()
+	shellz.RegisterObserver(o1)
+	g.Expect(shellz.DefaultObservers).To(Equal([]shellz.Observer{o1}))
+
+	c := shellz.NewCommand("sh", "-c", "printf abc; printf de >&2").SetEcho(false).AddObserver(o2)
+	g.Expect(c.GetObservers()).To(Equal([]shellz.Observer{o2}))
+	g.Expect(shellz.NewCommand("cmd").GetObservers()).To(BeEmpty())
+
+	g.Expect(c.Output(false)).To(Equal([]byte("abc")))
+
+	for _, o := range []*testObserver{o1, o2} {
+		g.Expect(o.starts).To(HaveLen(1))
+		g.Expect(o.exits).To(HaveLen(1))
+		g.Expect(o.starts[0].ID).To(Equal(o.exits[0].ID))
+		g.Expect(o.starts[0].Command).To(Equal(c))
+		g.Expect(o.exits[0].StartTime).To(Equal(o.starts[0].Time))
+		g.Expect(o.exits[0].Duration).To(Equal(o.exits[0].Time.Sub(o.exits[0].StartTime)))
+		g.Expect(o.exits[0].ExitCode).To(Equal(0))
+		g.Expect(o.exits[0].StdoutBytes).To(Equal(int64(3)))
+		g.Expect(o.exits[0].StderrBytes).To(Equal(int64(2)))
+		g.Expect(o.exits[0].Error).To(BeNil())
+	}
+
+	shellz.RestoreDefaultObservers()
+	g.Expect(shellz.DefaultObservers).To(BeEmpty())
+}
+
+func (*ObserverSuite) TestModes(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	o := newTestObserver()
+	c := shellz.NewCommand("sh", "-c", "printf abc; printf de >&2; exit 3").SetEcho(false).AddObserver(o)
+
+	g.Expect(c.Run()).ToNot(Succeed())
+	_, err := c.Output(true)
+	g.Expect(err).ToNot(Succeed())
+	_, err = c.CombinedOutput()
+	g.Expect(err).ToNot(Succeed())
+	g.Expect(c.Lines(func(string) {})).ToNot(Succeed())
+	g.Expect(c.MustStart().Wait()).ToNot(Succeed())
+
+	g.Expect(o.exits).To(HaveLen(5))
+
+	for i, e := range o.exits {
+		g.Expect(e.ExitCode).To(Equal(3))
+		g.Expect(e.Error).ToNot(BeNil())
+		g.Expect(e.Error.GetExitCode()).To(Equal(3))
+
+		switch i {
+		case 0:
+			g.Expect(e.StdoutBytes).To(Equal(int64(-1)))
+			g.Expect(e.StderrBytes).To(Equal(int64(-1)))
+		case 1:
+			g.Expect(e.StdoutBytes).To(Equal(int64(3)))
+			g.Expect(e.StderrBytes).To(Equal(int64(-1)))
+		case 2:
+			g.Expect(e.StdoutBytes).To(Equal(int64(5)))
+			g.Expect(e.StderrBytes).To(Equal(int64(0)))
+		default:
+			g.Expect(e.StdoutBytes).To(Equal(int64(3)))
+			g.Expect(e.StderrBytes).To(Equal(int64(2)))
+		}
+	}
+}
+
+func (*ObserverSuite) TestRun_Terminal(g *WithT) {
+	ptmx, tty := mustOpenPTY(g)
+	defer func() { _ = ptmx.Close() }()
+	defer func() { _ = tty.Close() }()
+	restore := swapStdoutStderr(tty)
+	defer restore()
+
+	o := newTestObserver()
+	g.Expect(shellz.NewCommand("sh", "-c", "[ -t 1 ] && [ -t 2 ]").SetEcho(false).AddObserver(o).Run()).To(Succeed())
+	g.Expect(o.exits[0].StdoutBytes).To(Equal(int64(-1)))
+	g.Expect(o.exits[0].StderrBytes).To(Equal(int64(-1)))
+
+	err := shellz.NewCommand("sh", "-c", "[ -t 1 ] && [ -t 2 ]").SetEcho(false).AddObserver(o).SetCaptureLimit(10).Run()
+	g.Expect(err).To(MatchError("execution error: exit status 1"))
+	g.Expect(o.exits[1].StdoutBytes).To(Equal(int64(0)))
+}
+
+func (*ObserverSuite) TestDiscardedStderr(g *WithT) {
+	o := newTestObserver()
+
+	_, err := shellz.NewCommand("sh", "-c", "echo err >&2; exit 1").SetEcho(false).AddObserver(o).Output(false)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(o.exits[0].Error.GetCapturedStderr()).To(Equal("err\n"))
+	g.Expect(o.exits[0].StderrBytes).To(Equal(int64(4)))
+}
+
+func (*ObserverSuite) TestRetry(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	o := newTestObserver()
+
+	g.Expect(shellz.NewCommand("false").
+		SetEcho(false).
+		SetRetry(&shellz.RetryPolicy{MaxAttempts: 3}).
+		AddObserver(o).
+		Run()).ToNot(Succeed())
+
+	g.Expect(o.starts).To(HaveLen(3))
+	g.Expect(o.exits).To(HaveLen(3))
+	g.Expect(o.exits[0].ID).ToNot(Equal(o.exits[1].ID))
+}
+
+func (*ObserverSuite) TestStartError(g *WithT) {
+	o := newTestObserver()
+
+	_, err := shellz.NewCommand("ed1c6a2e-5c46-4d6e-b5f4-bb5b8a2bd1c3").SetEcho(false).AddObserver(o).Start()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(o.exits).To(HaveLen(1))
+	g.Expect(o.exits[0].ExitCode).To(Equal(-1))
+	g.Expect(o.exits[0].Error.Error()).To(ContainSubstring("executable file not found"))
+}
+
+func (*ObserverSuite) TestPipeline(g *WithT) {
+	o := newTestObserver()
+
+	_, err := shellz.NewCommand("echo", "a").AddObserver(o).
+		Pipe(shellz.NewCommand("sh", "-c", "cat; exit 2").AddObserver(o)).
+		SetEcho(false).
+		Output(false)
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(o.starts).To(HaveLen(2))
+	g.Expect(o.exits).To(HaveLen(2))
+
+	for _, e := range o.exits {
+		g.Expect(e.StdoutBytes).To(Equal(int64(-1)))
+		g.Expect(e.StderrBytes).To(Equal(int64(-1)))
+
+		if e.Command.GetParams()[0] == "a" {
+			g.Expect(e.ExitCode).To(Equal(0))
+		} else {
+			g.Expect(e.ExitCode).To(Equal(2))
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package shellz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ibrt/golang-utils/errorz"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+const (
+	outputExcerptRadius = 40
+)
+
+var (
+	_ error                  = (*OutputParseError)(nil)
+	_ errorz.UnwrapSingle    = (*OutputParseError)(nil)
+	_ consolez.DetailedError = (*OutputParseError)(nil)
+)
+
+// OutputParseError describes command output that could not be parsed.
+type OutputParseError struct {
+	shortName   string
+	commandLine string
+	format      string
+	offset      int64
+	excerpt     string
+	err         error
+}
+
+func newOutputParseError(c *Command, format string, out []byte, offset int64, err error) *OutputParseError {
+	return &OutputParseError{
+		shortName:   c.getShortName(),
+		commandLine: c.String(),
+		format:      format,
+		offset:      offset,
+		excerpt:     c.redact(getOutputExcerpt(out, offset)),
+		err:         err,
+	}
+}
+
+// GetOffset returns the byte offset in the output at which parsing failed.
+func (e *OutputParseError) GetOffset() int64 {
+	return e.offset
+}
+
+// GetExcerpt returns an excerpt of the output around the offset at which parsing failed.
+// Secrets are redacted (see [*Command.AddSecretParams]).
+func (e *OutputParseError) GetExcerpt() string {
+	return e.excerpt
+}
+
+// GetSummary implements the [consolez.DetailedError] interface.
+func (e *OutputParseError) GetSummary() string {
+	return fmt.Sprintf("%q produced output that is not valid %v", e.shortName, e.format)
+}
+
+// GetDetails implements the [consolez.DetailedError] interface.
+func (e *OutputParseError) GetDetails() []*consolez.ErrorDetail {
+	return []*consolez.ErrorDetail{
+		{Label: "Command", Value: e.commandLine},
+		{Label: "Error", Value: e.err.Error()},
+		{Label: "Offset", Value: fmt.Sprintf("%v", e.offset)},
+		{Label: "Excerpt", Value: e.excerpt},
+	}
+}
+
+// Error implements the error interface.
+func (e *OutputParseError) Error() string {
+	return fmt.Sprintf("parse error: %v output of %q at byte %v: %v (near %q)", e.format, e.shortName, e.offset, e.err.Error(), e.excerpt)
+}
+
+// Unwrap implements the [errorz.UnwrapSingle] interface.
+func (e *OutputParseError) Unwrap() error {
+	return e.err
+}
+
+// OutputJSON runs the command and unmarshals its standard output as a single JSON value (see [*Command.Output]).
+// If the output cannot be unmarshaled, it returns an [*OutputParseError].
+func OutputJSON[T any](c *Command, echoStderr bool) (T, error) {
+	return OutputJSONContext[T](context.Background(), c, echoStderr)
+}
+
+// MustOutputJSON is like [OutputJSON] but panics on error.
+func MustOutputJSON[T any](c *Command, echoStderr bool) T {
+	v, err := OutputJSON[T](c, echoStderr)
+	errorz.MaybeMustWrap(err)
+	return v
+}
+
+// OutputJSONContext is like [OutputJSON] but terminates the command when the context is done.
+func OutputJSONContext[T any](ctx context.Context, c *Command, echoStderr bool) (T, error) {
+	var v T
+
+	out, err := c.OutputContext(ctx, echoStderr)
+	if err != nil {
+		return v, err
+	}
+
+	if err := json.Unmarshal(out, &v); err != nil {
+		return v, newOutputParseError(c, "JSON", out, getJSONErrorOffset(err, int64(len(out))), err)
+	}
+
+	return v, nil
+}
+
+// MustOutputJSONContext is like [OutputJSONContext] but panics on error.
+func MustOutputJSONContext[T any](ctx context.Context, c *Command, echoStderr bool) T {
+	v, err := OutputJSONContext[T](ctx, c, echoStderr)
+	errorz.MaybeMustWrap(err)
+	return v
+}
+
+// OutputJSONStream runs the command and unmarshals its standard output as a sequence of JSON values, either
+// concatenated or line-delimited (see [*Command.Output]). As a convenience for tools that switched between the two
+// formats (e.g. "docker compose ps --format json"), a single top-level JSON array is also accepted and flattened,
+// unless "T" is itself a slice, array or interface type. If the output cannot be unmarshaled, it returns an
+// [*OutputParseError].
+func OutputJSONStream[T any](c *Command, echoStderr bool) ([]T, error) {
+	return OutputJSONStreamContext[T](context.Background(), c, echoStderr)
+}
+
+// MustOutputJSONStream is like [OutputJSONStream] but panics on error.
+func MustOutputJSONStream[T any](c *Command, echoStderr bool) []T {
+	vs, err := OutputJSONStream[T](c, echoStderr)
+	errorz.MaybeMustWrap(err)
+	return vs
+}
+
+// OutputJSONStreamContext is like [OutputJSONStream] but terminates the command when the context is done.
+func OutputJSONStreamContext[T any](ctx context.Context, c *Command, echoStderr bool) ([]T, error) {
+	out, err := c.OutputContext(ctx, echoStderr)
+	if err != nil {
+		return nil, err
+	}
+
+	if isFlattenedJSONArray[T](out) {
+		vs := make([]T, 0)
+
+		if err := json.Unmarshal(out, &vs); err != nil {
+			return nil, newOutputParseError(c, "JSON", out, getJSONErrorOffset(err, int64(len(out))), err)
+		}
+
+		return vs, nil
+	}
+
+	vs := make([]T, 0)
+	dec := json.NewDecoder(bytes.NewReader(out))
+
+	for {
+		var v T
+
+		if err := dec.Decode(&v); err != nil {
+			if errors.Is(err, io.EOF) {
+				return vs, nil
+			}
+
+			return nil, newOutputParseError(c, "JSON", out, getJSONErrorOffset(err, dec.InputOffset()), err)
+		}
+
+		vs = append(vs, v)
+	}
+}
+
+// MustOutputJSONStreamContext is like [OutputJSONStreamContext] but panics on error.
+func MustOutputJSONStreamContext[T any](ctx context.Context, c *Command, echoStderr bool) []T {
+	vs, err := OutputJSONStreamContext[T](ctx, c, echoStderr)
+	errorz.MaybeMustWrap(err)
+	return vs
+}
+
+// OutputLinesOptions describes options for [*Command.OutputLines].
+type OutputLinesOptions struct {
+	// StripANSI removes ANSI escape sequences (e.g. colors) from lines.
+	StripANSI bool
+
+	// TrimSpace removes leading and trailing white space from lines.
+	TrimSpace bool
+
+	// SkipEmpty omits empty lines (after the other transformations are applied).
+	SkipEmpty bool
+
+	// Filter, if not nil, omits lines for which it returns false (after the other transformations are applied).
+	Filter func(line string) bool
+}
+
+// OutputLines runs the command and returns its standard output split into lines (see [*Command.Output]).
+// A trailing newline does not produce an empty last line, and "\r\n" line endings are supported. The options can be nil.
+func (c *Command) OutputLines(echoStderr bool, opts *OutputLinesOptions) ([]string, error) {
+	return c.OutputLinesContext(context.Background(), echoStderr, opts)
+}
+
+// MustOutputLines is like [*Command.OutputLines] but panics on error.
+func (c *Command) MustOutputLines(echoStderr bool, opts *OutputLinesOptions) []string {
+	lines, err := c.OutputLines(echoStderr, opts)
+	errorz.MaybeMustWrap(err)
+	return lines
+}
+
+// OutputLinesContext is like [*Command.OutputLines] but terminates the command when the context is done.
+func (c *Command) OutputLinesContext(ctx context.Context, echoStderr bool, opts *OutputLinesOptions) ([]string, error) {
+	out, err := c.OutputContext(ctx, echoStderr)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		opts = &OutputLinesOptions{}
+	}
+
+	lines := make([]string, 0)
+
+	if len(out) == 0 {
+		return lines, nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if opts.StripANSI {
+			line = ansiRegexp.ReplaceAllString(line, "")
+		}
+
+		if opts.TrimSpace {
+			line = strings.TrimSpace(line)
+		}
+
+		if (opts.SkipEmpty && line == "") || (opts.Filter != nil && !opts.Filter(line)) {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// MustOutputLinesContext is like [*Command.OutputLinesContext] but panics on error.
+func (c *Command) MustOutputLinesContext(ctx context.Context, echoStderr bool, opts *OutputLinesOptions) []string {
+	lines, err := c.OutputLinesContext(ctx, echoStderr, opts)
+	errorz.MaybeMustWrap(err)
+	return lines
+}
+
+// isFlattenedJSONArray returns true if "out" is a top-level JSON array that should be flattened into a []T.
+func isFlattenedJSONArray[T any](out []byte) bool {
+	trimmed := bytes.TrimSpace(out)
+
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return false
+	}
+
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Slice, reflect.Array, reflect.Interface:
+		return false
+	default:
+		return true
+	}
+}
+
+// getJSONErrorOffset returns the offset at which a JSON error occurred, or "defaultOffset" if not available.
+func getJSONErrorOffset(err error, defaultOffset int64) int64 {
+	if sErr, ok := errorz.As[*json.SyntaxError](err); ok {
+		return sErr.Offset
+	}
+
+	if tErr, ok := errorz.As[*json.UnmarshalTypeError](err); ok {
+		return tErr.Offset
+	}
+
+	return defaultOffset
+}
+
+// getOutputExcerpt returns the output around the given offset.
+func getOutputExcerpt(out []byte, offset int64) string {
+	start := max(0, min(int(offset), len(out))-outputExcerptRadius)
+	end := min(len(out), start+2*outputExcerptRadius)
+	excerpt := strings.ToValidUTF8(string(out[start:end]), "�")
+
+	if start > 0 {
+		excerpt = "..." + excerpt
+	}
+
+	if end < len(out) {
+		excerpt += "..."
+	}
+
+	return excerpt
+}
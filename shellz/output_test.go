@@ -0,0 +1,166 @@
+package shellz_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/consolez"
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type OutputSuite struct {
+	// intentionally empty
+}
+
+func TestOutputSuite(t *testing.T) {
+	fixturez.RunSuite(t, &OutputSuite{})
+}
+
+type outputTestValue struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+func newPrintfCommand(s string) *shellz.Command {
+	return shellz.NewCommand("printf", "%s", s).SetEcho(false)
+}
+
+func (*OutputSuite) TestOutputJSON(g *WithT) {
+	v, err := shellz.OutputJSON[*outputTestValue](newPrintfCommand(`{"name": "a", "value": 1}`), false)
+	g.Expect(err).To(Succeed())
+	g.Expect(v).To(Equal(&outputTestValue{Name: "a", Value: 1}))
+
+	g.Expect(shellz.MustOutputJSON[map[string]int](newPrintfCommand(`{"a": 1}`), false)).To(Equal(map[string]int{"a": 1}))
+	g.Expect(shellz.MustOutputJSONContext[[]int](context.Background(), newPrintfCommand(`[1, 2]`), false)).To(Equal([]int{1, 2}))
+}
+
+func (*OutputSuite) TestOutputJSON_ParseError(g *WithT) {
+	out := strings.Repeat("x", 50) + `{"name": "a", "value": "s3cr3t"}` + strings.Repeat("y", 50)
+
+	_, err := shellz.OutputJSON[*outputTestValue](shellz.NewCommand("sh", "-c", `printf '{"name": "a", "value": "%s"}' "$0"`).
+		AddSecretParams("s3cr3t").
+		SetEcho(false), false)
+	g.Expect(err).To(HaveOccurred())
+
+	pErr, ok := errorz.As[*shellz.OutputParseError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pErr.GetOffset()).To(BeEquivalentTo(31))
+	g.Expect(pErr.GetExcerpt()).To(Equal(`{"name": "a", "value": "***"}`))
+	g.Expect(pErr.Error()).To(HavePrefix(`parse error: JSON output of "sh" at byte 31: json: cannot unmarshal string into Go struct field `))
+	g.Expect(pErr.Error()).To(HaveSuffix(`value of type int (near "{\"name\": \"a\", \"value\": \"***\"}")`))
+
+	_, ok = errorz.As[*json.UnmarshalTypeError](err)
+	g.Expect(ok).To(BeTrue())
+
+	_, err = shellz.OutputJSON[*outputTestValue](newPrintfCommand(out), false)
+	pErr, ok = errorz.As[*shellz.OutputParseError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pErr.GetOffset()).To(BeEquivalentTo(1))
+	g.Expect(pErr.GetExcerpt()).To(Equal(out[:80] + "..."))
+	g.Expect(pErr.GetSummary()).To(Equal(`"printf %s" produced output that is not valid JSON`))
+	g.Expect(pErr.GetDetails()[2]).To(Equal(&consolez.ErrorDetail{Label: "Offset", Value: "1"}))
+
+	_, err = shellz.OutputJSON[*outputTestValue](newPrintfCommand(strings.Repeat(" ", 99)+"x"), false)
+	pErr, ok = errorz.As[*shellz.OutputParseError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pErr.GetOffset()).To(BeEquivalentTo(100))
+	g.Expect(pErr.GetExcerpt()).To(Equal("..." + strings.Repeat(" ", 39) + "x"))
+
+	_, err = shellz.OutputJSON[*outputTestValue](newPrintfCommand(""), false)
+	pErr, ok = errorz.As[*shellz.OutputParseError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pErr.GetOffset()).To(BeEquivalentTo(0))
+	g.Expect(pErr.GetExcerpt()).To(Equal(""))
+
+	g.Expect(func() { shellz.MustOutputJSON[int](newPrintfCommand("x"), false) }).To(PanicWith(MatchError(
+		`parse error: JSON output of "printf %s" at byte 1: invalid character 'x' looking for beginning of value (near "x")`)))
+}
+
+func (*OutputSuite) TestOutputJSON_ExecutionError(g *WithT) {
+	_, err := shellz.OutputJSON[int](shellz.NewCommand("sh", "-c", "exit 2").SetEcho(false), false)
+	_, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+
+	_, err = shellz.OutputJSONStream[int](shellz.NewCommand("sh", "-c", "exit 2").SetEcho(false), false)
+	_, ok = errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+
+	_, err = shellz.NewCommand("sh", "-c", "exit 2").SetEcho(false).OutputLines(false, nil)
+	_, ok = errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+}
+
+func (*OutputSuite) TestOutputJSONStream(g *WithT) {
+	expected := []*outputTestValue{{Name: "a", Value: 1}, {Name: "b", Value: 2}}
+
+	for _, out := range []string{
+		`{"name": "a", "value": 1}{"name": "b", "value": 2}`,
+		"{\"name\": \"a\", \"value\": 1}\n{\"name\": \"b\", \"value\": 2}\n",
+		`[{"name": "a", "value": 1}, {"name": "b", "value": 2}]`,
+	} {
+		vs, err := shellz.OutputJSONStream[*outputTestValue](newPrintfCommand(out), false)
+		g.Expect(err).To(Succeed())
+		g.Expect(vs).To(Equal(expected))
+	}
+
+	g.Expect(shellz.MustOutputJSONStream[[]int](newPrintfCommand("[1]\n[2, 3]\n"), false)).To(Equal([][]int{{1}, {2, 3}}))
+	g.Expect(shellz.MustOutputJSONStream[any](newPrintfCommand("[1]"), false)).To(Equal([]any{[]any{float64(1)}}))
+	g.Expect(shellz.MustOutputJSONStreamContext[int](context.Background(), newPrintfCommand(""), false)).To(BeEmpty())
+}
+
+func (*OutputSuite) TestOutputJSONStream_ParseError(g *WithT) {
+	_, err := shellz.OutputJSONStream[*outputTestValue](newPrintfCommand("{\"name\": \"a\"}\n{\"name\": \"b\", }\n"), false)
+	pErr, ok := errorz.As[*shellz.OutputParseError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pErr.GetOffset()).To(BeEquivalentTo(29))
+	g.Expect(pErr.GetExcerpt()).To(Equal("{\"name\": \"a\"}\n{\"name\": \"b\", }\n"))
+
+	_, err = shellz.OutputJSONStream[*outputTestValue](newPrintfCommand(`[{"name": 1}]`), false)
+	pErr, ok = errorz.As[*shellz.OutputParseError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pErr.Error()).To(ContainSubstring("cannot unmarshal number"))
+
+	g.Expect(func() { shellz.MustOutputJSONStream[int](newPrintfCommand("1 x"), false) }).To(Panic())
+}
+
+func (*OutputSuite) TestOutputLines(g *WithT) {
+	out := " a \r\n\n\x1b[31mb\x1b[0m\n# comment\nc"
+
+	g.Expect(newPrintfCommand(out).MustOutputLines(false, nil)).To(Equal([]string{" a ", "", "\x1b[31mb\x1b[0m", "# comment", "c"}))
+	g.Expect(newPrintfCommand(out+"\n").MustOutputLines(false, nil)).To(Equal([]string{" a ", "", "\x1b[31mb\x1b[0m", "# comment", "c"}))
+	g.Expect(newPrintfCommand("").MustOutputLines(false, nil)).To(BeEmpty())
+	g.Expect(newPrintfCommand("\n").MustOutputLines(false, nil)).To(Equal([]string{""}))
+
+	lines, err := newPrintfCommand(out).OutputLines(false, &shellz.OutputLinesOptions{
+		StripANSI: true,
+		TrimSpace: true,
+		SkipEmpty: true,
+		Filter: func(line string) bool {
+			return !strings.HasPrefix(line, "#")
+		},
+	})
+	g.Expect(err).To(Succeed())
+	g.Expect(lines).To(Equal([]string{"a", "b", "c"}))
+
+	g.Expect(newPrintfCommand(out).MustOutputLinesContext(context.Background(), false, &shellz.OutputLinesOptions{SkipEmpty: true})).
+		To(HaveLen(4))
+}
+
+func (*OutputSuite) TestOutputLines_EchoStderr(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	lines := shellz.NewCommand("sh", "-c", "echo out; echo err >&2").SetEcho(false).MustOutputLines(true, nil)
+	g.Expect(lines).To(Equal([]string{"out"}))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(BeEmpty())
+	g.Expect(errBuf).To(Equal("err\n"))
+}
@@ -0,0 +1,453 @@
+package shellz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/memz"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+var (
+	_ error               = (*PipelineError)(nil)
+	_ errorz.UnwrapSingle = (*PipelineError)(nil)
+)
+
+// PipelineError describes an error in a stage of a [*Pipeline].
+type PipelineError struct {
+	stage     int
+	numStages int
+	err       *ExecutionError
+}
+
+// GetStage returns the (zero-based) index of the failing stage.
+func (e *PipelineError) GetStage() int {
+	return e.stage
+}
+
+// GetNumStages returns the number of stages in the originating pipeline.
+func (e *PipelineError) GetNumStages() int {
+	return e.numStages
+}
+
+// GetExecutionError returns the [*ExecutionError] of the failing stage.
+func (e *PipelineError) GetExecutionError() *ExecutionError {
+	return e.err
+}
+
+// Error implements the error interface.
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("pipeline stage %v/%v (%v) failed: %v", e.stage+1, e.numStages, e.err.GetCommand(), e.err.Error())
+}
+
+// Unwrap implements the [errorz.UnwrapSingle] interface.
+func (e *PipelineError) Unwrap() error {
+	return e.err
+}
+
+// Pipeline describes a sequence of commands, each streaming its standard output into the standard input of the next.
+// Stages cannot have a retry policy, a cache, file redirections, PTY or interactive mode, a capture limit or an output
+// limit (see [ResourceLimits.MaxOutputBytes]): pipelines fail without starting any stage if they do. The other settings
+// of the stages apply, except for echo (see [*Pipeline.SetEcho]).
+type Pipeline struct {
+	stages []*Command
+	echo   *bool
+}
+
+// Pipe creates a [*Pipeline] that streams the standard output of this command into the standard input of "next".
+func (c *Command) Pipe(next *Command) *Pipeline {
+	return &Pipeline{
+		stages: []*Command{c, next},
+	}
+}
+
+// Pipe appends a stage to the pipeline.
+func (p *Pipeline) Pipe(next *Command) *Pipeline {
+	pp := p.clone()
+	pp.stages = append(pp.stages, next)
+	return pp
+}
+
+// GetStages returns the current stages.
+func (p *Pipeline) GetStages() []*Command {
+	return memz.ShallowCopySlice(p.stages)
+}
+
+// SetEcho configures echo (the echo configuration of the individual stages is ignored).
+func (p *Pipeline) SetEcho(echo bool) *Pipeline {
+	pp := p.clone()
+	pp.echo = memz.Ptr(echo)
+	return pp
+}
+
+// GetEcho returns the current echo configuration.
+func (p *Pipeline) GetEcho() *bool {
+	if p.echo == nil {
+		return nil
+	}
+	return memz.Ptr(*p.echo)
+}
+
+// Run runs the pipeline.
+// The standard output of the last stage and the standard error of all stages are sent to the terminal.
+func (p *Pipeline) Run() error {
+	return p.RunContext(context.Background())
+}
+
+// MustRun is like [*Pipeline.Run] but panics on error.
+func (p *Pipeline) MustRun() {
+	errorz.MaybeMustWrap(p.Run())
+}
+
+// RunContext is like [*Pipeline.Run] but terminates all stages when the context is done.
+func (p *Pipeline) RunContext(ctx context.Context) error {
+	return p.execute(ctx, true, func(stages []*pipelineStage) func(bool) {
+		for _, s := range stages {
+			s.cmd.Stderr = os.Stderr
+		}
+
+		stages[len(stages)-1].cmd.Stdout = os.Stdout
+		return func(bool) {}
+	})
+}
+
+// MustRunContext is like [*Pipeline.RunContext] but panics on error.
+func (p *Pipeline) MustRunContext(ctx context.Context) {
+	errorz.MaybeMustWrap(p.RunContext(ctx))
+}
+
+// Output runs the pipeline and returns a buffer containing the resulting standard output of the last stage.
+func (p *Pipeline) Output(echoStderr bool) ([]byte, error) {
+	return p.OutputContext(context.Background(), echoStderr)
+}
+
+// MustOutput is like [*Pipeline.Output] but panics on error.
+func (p *Pipeline) MustOutput(echoStderr bool) []byte {
+	out, err := p.Output(echoStderr)
+	errorz.MaybeMustWrap(err)
+	return out
+}
+
+// OutputContext is like [*Pipeline.Output] but terminates all stages when the context is done.
+func (p *Pipeline) OutputContext(ctx context.Context, echoStderr bool) ([]byte, error) {
+	outBuf := &bytes.Buffer{}
+
+	err := p.execute(ctx, false, func(stages []*pipelineStage) func(bool) {
+		for _, s := range stages {
+			if echoStderr {
+				s.cmd.Stderr = os.Stderr
+			} else {
+				s.stderr = &bytes.Buffer{}
+				s.cmd.Stderr = s.stderr
+			}
+		}
+
+		stages[len(stages)-1].cmd.Stdout = outBuf
+		return func(bool) {}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return outBuf.Bytes(), nil
+}
+
+// MustOutputContext is like [*Pipeline.OutputContext] but panics on error.
+func (p *Pipeline) MustOutputContext(ctx context.Context, echoStderr bool) []byte {
+	out, err := p.OutputContext(ctx, echoStderr)
+	errorz.MaybeMustWrap(err)
+	return out
+}
+
+// OutputString is like [*Pipeline.Output] but returns a string.
+func (p *Pipeline) OutputString(echoStderr bool) (string, error) {
+	buf, err := p.Output(echoStderr)
+	if err != nil {
+		return "", errorz.Wrap(err)
+	}
+
+	return string(buf), nil
+}
+
+// MustOutputString is like [*Pipeline.OutputString] but panics on error.
+func (p *Pipeline) MustOutputString(echoStderr bool) string {
+	buf, err := p.OutputString(echoStderr)
+	errorz.MaybeMustWrap(err)
+	return buf
+}
+
+// Lines runs the pipeline and calls "lineFunc" with each line of standard output of the last stage,
+// and each line of standard error of all stages.
+func (p *Pipeline) Lines(lineFunc func(string)) error {
+	return p.LinesContext(context.Background(), lineFunc)
+}
+
+// MustLines is like [*Pipeline.Lines] but panics on error.
+func (p *Pipeline) MustLines(lineFunc func(string)) {
+	errorz.MaybeMustWrap(p.Lines(lineFunc))
+}
+
+// LinesContext is like [*Pipeline.Lines] but terminates all stages when the context is done.
+func (p *Pipeline) LinesContext(ctx context.Context, lineFunc func(string)) error {
+	return p.execute(ctx, true, func(stages []*pipelineStage) func(bool) {
+		m := &sync.Mutex{}
+		wg := &sync.WaitGroup{}
+		readers := make([]io.ReadCloser, 0, len(stages)+1)
+
+		callEventFunc := func(e *LineEvent) {
+			m.Lock()
+			defer m.Unlock()
+			lineFunc(e.Text)
+		}
+
+		wg.Add(len(stages) + 1)
+
+		for _, s := range stages {
+			errR, err := s.cmd.StderrPipe()
+			errorz.MaybeMustWrap(err)
+			readers = append(readers, errR)
+			go handleLines(wg, errR, StreamStderr, nil, callEventFunc)
+		}
+
+		outR, err := stages[len(stages)-1].cmd.StdoutPipe()
+		errorz.MaybeMustWrap(err)
+		readers = append(readers, outR)
+		go handleLines(wg, outR, StreamStdout, nil, callEventFunc)
+
+		return func(isAborted bool) {
+			if isAborted {
+				for _, r := range readers {
+					_ = r.Close()
+				}
+			}
+
+			wg.Wait()
+		}
+	})
+}
+
+// MustLinesContext is like [*Pipeline.LinesContext] but panics on error.
+func (p *Pipeline) MustLinesContext(ctx context.Context, lineFunc func(string)) {
+	errorz.MaybeMustWrap(p.LinesContext(ctx, lineFunc))
+}
+
+type pipelineStage struct {
+	c         *Command
+	ctx       context.Context
+	cmd       *exec.Cmd
+	stderr    *bytes.Buffer
+	obs       *observation
+	startTime time.Time
+}
+
+// execute runs the pipeline: "configure" sets up the standard output of the last stage and the standard error of
+// all stages, and returns a function that blocks until all the output has been consumed. If "isAborted" is true, the
+// function must stop consuming the output first, as the stages that failed to start will never close their outputs.
+func (p *Pipeline) execute(ctx context.Context, defaultEcho bool, configure func(stages []*pipelineStage) func(isAborted bool)) error {
+	errorz.Assertf(len(p.stages) >= 2, "pipeline must have at least two stages")
+
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p.maybeEcho(defaultEcho)
+
+	stages := make([]*pipelineStage, len(p.stages))
+
+	for i, c := range p.stages {
+		sCtx, sCancel := c.newContext(ctx)
+		defer sCancel()
+
+		cmd, cleanup := c.newCmd(sCtx, cancel)
+		defer cleanup()
+
+		stages[i] = &pipelineStage{
+			c:   c,
+			ctx: sCtx,
+			cmd: cmd,
+		}
+	}
+
+	pipeFiles := make([]*os.File, 0, 2*(len(stages)-1))
+
+	defer func() {
+		for _, f := range pipeFiles {
+			_ = f.Close()
+		}
+	}()
+
+	for i := 0; i < len(stages)-1; i++ {
+		r, w, err := os.Pipe()
+		errorz.MaybeMustWrap(err)
+		pipeFiles = append(pipeFiles, r, w)
+		stages[i].cmd.Stdout = w
+		stages[i+1].cmd.Stdin = r
+	}
+
+	waitOutput := configure(stages)
+
+	for i, s := range stages {
+		s.obs = s.c.beginObservation()
+		s.startTime = time.Now()
+
+		if err := s.c.getExecutor().ExecCmdStart(s.ctx, s.c, s.cmd); err != nil {
+			pErr := p.newError(i, newContextExecutionError(s.ctx, err, s.c))
+			s.obs.end(pErr.err, nil, nil)
+			cancel()
+
+			for _, f := range pipeFiles {
+				_ = f.Close()
+			}
+
+			waitOutput(true)
+
+			for _, ps := range stages[:i] {
+				err := ps.c.getExecutor().ExecCmdWait(ps.ctx, ps.c, ps.cmd)
+				usage := newResourceUsage(ps.cmd, ps.startTime)
+
+				if err := ps.c.checkLimits(ps.cmd, nil, err); err != nil {
+					eErr := ps.c.newLimitAwareExecutionError(ps.ctx, err)
+					eErr.setProcessInfo(ps.cmd, ps.startTime, usage)
+					ps.obs.end(eErr, nil, usage)
+				} else {
+					ps.obs.end(nil, nil, usage)
+				}
+			}
+
+			return pErr
+		}
+
+		// The child processes have their own copies of the pipe ends.
+		if i > 0 {
+			_ = s.cmd.Stdin.(*os.File).Close()
+		}
+		if i < len(stages)-1 {
+			_ = s.cmd.Stdout.(*os.File).Close()
+		}
+	}
+
+	waitOutput(false)
+
+	var pErr *PipelineError
+
+	for i, s := range stages {
+		err := s.c.getExecutor().ExecCmdWait(s.ctx, s.c, s.cmd)
+		usage := newResourceUsage(s.cmd, s.startTime)
+
+		if err := s.c.checkLimits(s.cmd, nil, err); err != nil {
+			eErr := s.c.newLimitAwareExecutionError(s.ctx, err)
+			eErr.setProcessInfo(s.cmd, s.startTime, usage)
+
+			if s.stderr != nil {
+				eErr.capturedStderr = s.c.redact(s.stderr.String())
+			}
+
+			s.obs.end(eErr, nil, usage)
+			pErr = p.newError(i, eErr) // pipefail: the rightmost failing stage is reported
+		} else {
+			s.obs.end(nil, nil, usage)
+		}
+	}
+
+	if pErr != nil {
+		return pErr
+	}
+
+	return nil
+}
+
+// validate returns an error if any stage is configured with a feature that pipelines do not support.
+func (p *Pipeline) validate() error {
+	for i, c := range p.stages {
+		var err error
+
+		switch {
+		case c.retryPolicy != nil:
+			err = errorz.Errorf("retry policies are not supported by pipelines")
+		case c.cache != nil:
+			err = errorz.Errorf("caching is not supported by pipelines")
+		case c.hasRedirections():
+			err = errorz.Errorf("file redirections are not supported by pipelines")
+		case c.isPTY:
+			err = errorz.Errorf("PTY mode is not supported by pipelines")
+		case c.isInteractive:
+			err = errorz.Errorf("interactive mode is not supported by pipelines")
+		case c.captureLimit > 0:
+			err = errorz.Errorf("capture limits are not supported by pipelines")
+		case c.getMaxOutputBytes() > 0:
+			err = errorz.Errorf("output limits are not supported by pipelines")
+		}
+
+		if err != nil {
+			return p.newError(i, NewExecutionError(err, c))
+		}
+	}
+
+	return nil
+}
+
+func (p *Pipeline) newError(stage int, err *ExecutionError) *PipelineError {
+	return &PipelineError{
+		stage:     stage,
+		numStages: len(p.stages),
+		err:       err,
+	}
+}
+
+// maybeEcho prints the pipeline if echo is enabled, unless all stages are in dry-run mode, as the [*DryRunExecutor]
+// prints them.
+func (p *Pipeline) maybeEcho(defaultEcho bool) {
+	if (p.echo == nil && !defaultEcho) || (p.echo != nil && !*p.echo) || !slices.ContainsFunc(p.stages, isNotDryRun) {
+		return
+	}
+
+	// Each stage is redacted with the secrets of all stages, as the same secret may be passed to several of them.
+	secrets := make([]string, 0)
+
+	for _, c := range p.stages {
+		secrets = append(secrets, c.secrets...)
+	}
+
+	cmds := make([][]string, 0, len(p.stages))
+
+	for _, c := range p.stages {
+		cmd := []string{redactSecrets(c.cmd, secrets)}
+
+		for _, param := range c.params {
+			cmd = append(cmd, redactSecrets(param, secrets))
+		}
+
+		cmds = append(cmds, cmd)
+	}
+
+	consolez.DefaultCLI.Pipeline(cmds...)
+}
+
+func isNotDryRun(c *Command) bool {
+	return !c.isDryRun()
+}
+
+func (p *Pipeline) clone() *Pipeline {
+	pp := &Pipeline{
+		stages: memz.ShallowCopySlice(p.stages),
+		echo:   nil,
+	}
+
+	if p.echo != nil {
+		pp.echo = memz.Ptr(*p.echo)
+	}
+
+	return pp
+}
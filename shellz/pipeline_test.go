@@ -0,0 +1,270 @@
+package shellz_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	"github.com/ibrt/golang-dev/consolez"
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type PipelineSuite struct {
+	// intentionally empty
+}
+
+func TestPipelineSuite(t *testing.T) {
+	fixturez.RunSuite(t, &PipelineSuite{})
+}
+
+func (*PipelineSuite) TestRun_Success(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	g.Expect(
+		shellz.NewCommand("cat").SetIn(strings.NewReader("b\na\nc\n")).
+			Pipe(shellz.NewCommand("sort")).
+			Pipe(shellz.NewCommand("cat", "-b")).
+			Run()).
+		To(Succeed())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(fmt.Sprintf("%v cat \x1b[2m\x1b[0m | sort \x1b[2m\x1b[0m | cat \x1b[2m-b\x1b[0m\n     1\ta\n     2\tb\n     3\tc\n", consolez.IconRunner)))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*PipelineSuite) TestRun_Error(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	err := shellz.NewCommand("cat", "cae0e988-f55b-4803-a471-a877b686d1a8").
+		Pipe(shellz.NewCommand("cat")).
+		SetEcho(false).
+		Run()
+	g.Expect(err).To(MatchError("pipeline stage 1/2 (cat) failed: execution error: exit status 1"))
+
+	pErr, ok := errorz.As[*shellz.PipelineError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pErr.GetStage()).To(Equal(0))
+	g.Expect(pErr.GetNumStages()).To(Equal(2))
+	g.Expect(pErr.GetExecutionError().GetExitCode()).To(Equal(1))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetParams()).To(Equal([]string{"cae0e988-f55b-4803-a471-a877b686d1a8"}))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(BeEmpty())
+	g.Expect(errBuf).To(Equal("cat: cae0e988-f55b-4803-a471-a877b686d1a8: No such file or directory\n"))
+}
+
+func (*PipelineSuite) TestRun_Error_Pipefail(g *WithT) {
+	err := shellz.NewCommand("sh", "-c", "exit 3").
+		Pipe(shellz.NewCommand("sh", "-c", "cat; exit 4")).
+		Pipe(shellz.NewCommand("cat")).
+		SetEcho(false).
+		Run()
+	g.Expect(err).To(MatchError("pipeline stage 2/3 (sh) failed: execution error: exit status 4"))
+}
+
+func (*PipelineSuite) TestRun_Error_Start(g *WithT) {
+	start := time.Now()
+
+	err := shellz.NewCommand("sleep", "30").
+		Pipe(shellz.NewCommand("cae0e988-f55b-4803-a471-a877b686d1a8")).
+		SetEcho(false).
+		Run()
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(err).To(MatchError(`pipeline stage 2/2 (cae0e988-f55b-4803-a471-a877b686d1a8) failed: execution error: exec: "cae0e988-f55b-4803-a471-a877b686d1a8": executable file not found in $PATH`))
+}
+
+func (*PipelineSuite) TestRun_Error_Unsupported(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	for msg, c := range map[string]*shellz.Command{
+		"retry policies are not supported by pipelines":    shellz.NewCommand("cat").SetRetry(&shellz.RetryPolicy{MaxAttempts: 2}),
+		"caching is not supported by pipelines":            shellz.NewCommand("cat").SetCache(shellz.NewCache(dirPath), nil),
+		"file redirections are not supported by pipelines": shellz.NewCommand("cat").SetDir(dirPath).SetStdoutFile("out.txt", false),
+		"PTY mode is not supported by pipelines":           shellz.NewCommand("cat").SetPTY(true),
+		"interactive mode is not supported by pipelines":   shellz.NewCommand("cat").SetInteractive(true),
+		"capture limits are not supported by pipelines":    shellz.NewCommand("cat").SetCaptureLimit(10),
+		"output limits are not supported by pipelines":     shellz.NewCommand("cat").SetResourceLimits(&shellz.ResourceLimits{MaxOutputBytes: 10}),
+	} {
+		err := shellz.NewCommand("echo", "1").Pipe(c).SetEcho(false).Run()
+		g.Expect(err).To(MatchError("pipeline stage 2/2 (cat) failed: execution error: " + msg))
+	}
+
+	g.Expect(listDir(dirPath)).To(BeEmpty())
+}
+
+func (*PipelineSuite) TestMustRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	g.Expect(func() {
+		shellz.NewCommand("echo", "x").Pipe(shellz.NewCommand("cat")).SetEcho(false).MustRun()
+	}).ToNot(Panic())
+
+	g.Expect(func() {
+		shellz.NewCommand("false").Pipe(shellz.NewCommand("cat")).SetEcho(false).MustRun()
+	}).To(PanicWith(MatchError("pipeline stage 1/2 (false) failed: execution error: exit status 1")))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("x\n"))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*PipelineSuite) TestRunContext_Timeout(g *WithT) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	err := shellz.NewCommand("sleep", "30").
+		Pipe(shellz.NewCommand("cat")).
+		SetEcho(false).
+		RunContext(ctx)
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(err).To(MatchError("pipeline stage 2/2 (cat) failed: execution error: timed out: signal: terminated"))
+
+	g.Expect(func() {
+		shellz.NewCommand("true").Pipe(shellz.NewCommand("true")).SetEcho(false).MustRunContext(ctx)
+	}).To(Panic())
+}
+
+func (*PipelineSuite) TestOutput(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	out, err := shellz.NewCommand("echo", "input").
+		Pipe(shellz.NewCommand("tr", "a-z", "A-Z")).
+		Output(false)
+	g.Expect(err).To(Succeed())
+	g.Expect(string(out)).To(Equal("INPUT\n"))
+
+	g.Expect(shellz.NewCommand("echo", "input").Pipe(shellz.NewCommand("cat")).MustOutputString(true)).
+		To(Equal("input\n"))
+
+	g.Expect(shellz.NewCommand("echo", "input").Pipe(shellz.NewCommand("cat")).MustOutput(true)).
+		To(Equal([]byte("input\n")))
+
+	g.Expect(shellz.NewCommand("echo", "input").Pipe(shellz.NewCommand("cat")).MustOutputContext(context.Background(), true)).
+		To(Equal([]byte("input\n")))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(BeEmpty())
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*PipelineSuite) TestOutput_Error(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	out, err := shellz.NewCommand("echo", "input").
+		Pipe(shellz.NewCommand("cat", "cae0e988-f55b-4803-a471-a877b686d1a8")).
+		Output(false)
+	g.Expect(out).To(BeNil())
+	g.Expect(err).To(MatchError("pipeline stage 2/2 (cat) failed: execution error: exit status 1"))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetCapturedStderr()).To(Equal("cat: cae0e988-f55b-4803-a471-a877b686d1a8: No such file or directory\n"))
+
+	_, err = shellz.NewCommand("false").Pipe(shellz.NewCommand("cat")).OutputString(false)
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(func() {
+		shellz.NewCommand("false").Pipe(shellz.NewCommand("cat")).MustOutputString(false)
+	}).To(Panic())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(BeEmpty())
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*PipelineSuite) TestLines(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	m := &sync.Mutex{}
+	receivedLines := make([]string, 0)
+
+	lineFunc := func(line string) {
+		m.Lock()
+		defer m.Unlock()
+		receivedLines = append(receivedLines, line)
+	}
+
+	g.Expect(
+		shellz.NewCommand("sh", "-c", "echo 1; echo 2; echo e1 >&2").
+			Pipe(shellz.NewCommand("sh", "-c", "cat; echo e2 >&2")).
+			Lines(lineFunc)).
+		To(Succeed())
+
+	g.Expect(func() {
+		shellz.NewCommand("echo", "3").Pipe(shellz.NewCommand("cat")).SetEcho(false).MustLines(lineFunc)
+	}).ToNot(Panic())
+
+	g.Expect(func() {
+		shellz.NewCommand("echo", "4").Pipe(shellz.NewCommand("cat")).SetEcho(false).MustLinesContext(context.Background(), lineFunc)
+	}).ToNot(Panic())
+
+	sort.Strings(receivedLines)
+	g.Expect(receivedLines).To(Equal([]string{"1", "2", "3", "4", "e1", "e2"}))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(fmt.Sprintf("%v sh \x1b[2m-c 'echo 1; echo 2; echo e1 >&2'\x1b[0m | sh \x1b[2m-c 'cat; echo e2 >&2'\x1b[0m\n", consolez.IconRunner)))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*PipelineSuite) TestLines_Error(g *WithT) {
+	err := shellz.NewCommand("echo", "1").
+		Pipe(shellz.NewCommand("false")).
+		SetEcho(false).
+		Lines(func(string) {})
+	g.Expect(err).To(MatchError("pipeline stage 2/2 (false) failed: execution error: exit status 1"))
+
+	g.Expect(func() {
+		shellz.NewCommand("echo", "1").Pipe(shellz.NewCommand("false")).SetEcho(false).MustLines(func(string) {})
+	}).To(Panic())
+}
+
+func (*PipelineSuite) TestLines_Error_Start(g *WithT) {
+	numGoroutines := runtime.NumGoroutine()
+
+	err := shellz.NewCommand("sh", "-c", "echo 1; sleep 30").
+		Pipe(shellz.NewCommand("cae0e988-f55b-4803-a471-a877b686d1a8")).
+		Pipe(shellz.NewCommand("cat")).
+		SetEcho(false).
+		Lines(func(string) {})
+	g.Expect(err).To(MatchError(ContainSubstring("pipeline stage 2/3 (cae0e988-f55b-4803-a471-a877b686d1a8) failed")))
+	g.Eventually(runtime.NumGoroutine).Should(BeNumerically("<=", numGoroutines))
+}
+
+func (*PipelineSuite) TestGetters(g *WithT) {
+	c1 := shellz.NewCommand("c1")
+	c2 := shellz.NewCommand("c2")
+	c3 := shellz.NewCommand("c3")
+
+	p := c1.Pipe(c2)
+	g.Expect(p.GetStages()).To(HaveExactElements(c1, c2))
+	g.Expect(p.Pipe(c3).GetStages()).To(HaveExactElements(c1, c2, c3))
+	g.Expect(p.GetStages()).To(HaveExactElements(c1, c2))
+
+	g.Expect(p.GetEcho()).To(BeNil())
+	g.Expect(p.SetEcho(true).GetEcho()).To(PointTo(BeTrue()))
+	g.Expect(p.SetEcho(false).Pipe(c3).GetEcho()).To(PointTo(BeFalse()))
+}
@@ -0,0 +1,379 @@
+package shellz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+)
+
+const (
+	// ProcessLineHistorySize is the number of output lines retained by a [*Process] for late subscribers.
+	ProcessLineHistorySize = 1000
+
+	probeInterval = 100 * time.Millisecond
+)
+
+// Process is a handle to a command started in the background.
+type Process struct {
+	c       *Command
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	done    chan struct{}
+	err     error
+	usage   *ResourceUsage
+	m       *sync.Mutex
+	history []*LineEvent
+	subs    map[int]func(*LineEvent)
+	nextSub int
+}
+
+// Start starts the command in the background and returns a [*Process] handle.
+// Its output is not sent to the terminal: use [*Process.Subscribe] to consume it. Retries are not applied.
+func (c *Command) Start() (*Process, error) {
+	return c.StartContext(context.Background())
+}
+
+// MustStart is like [*Command.Start] but panics on error.
+func (c *Command) MustStart() *Process {
+	p, err := c.Start()
+	errorz.MaybeMustWrap(err)
+	return p
+}
+
+// StartContext is like [*Command.Start] but terminates the command when the context is done.
+func (c *Command) StartContext(ctx context.Context) (*Process, error) {
+	c.maybeEcho(true)
+
+	rd, err := c.openRedirections()
+	if err != nil {
+		return nil, NewExecutionError(err, c)
+	}
+
+	ctx, cancel := c.newContext(ctx)
+	cmd, cleanup := c.newCmd(ctx, cancel)
+	obs := c.beginObservation()
+	capture := newOutputCapture(c.captureLimit, obs != nil || c.getMaxOutputBytes() > 0)
+	capture.setOutputLimit(c.getMaxOutputBytes(), cancel)
+
+	p := &Process{
+		c:       c,
+		cmd:     cmd,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		m:       &sync.Mutex{},
+		history: make([]*LineEvent, 0),
+		subs:    make(map[int]func(*LineEvent)),
+	}
+
+	wg := &sync.WaitGroup{}
+	pty := c.attachPTY(cmd)
+
+	if pty != nil {
+		wg.Add(1)
+		go handleLines(wg, capture.teeReader(pty.getReader(), StreamStdout), StreamStdout, nil, p.handleLine)
+	} else {
+		for _, stream := range []Stream{StreamStdout, StreamStderr} {
+			if !rd.isRedirected(stream) {
+				wg.Add(1)
+				go handleLines(wg, capture.teeReader(newStreamPipe(cmd, stream), stream), stream, nil, p.handleLine)
+			}
+		}
+	}
+
+	rd.apply(cmd, capture)
+
+	closePTY := func() {
+		if pty != nil {
+			pty.close()
+		}
+	}
+
+	startTime := time.Now()
+	err = c.getExecutor().ExecCmdStart(ctx, c, cmd)
+	pty.maybeCloseSlaveAfterStart(cmd)
+
+	if err != nil {
+		_ = rd.close(err)
+		eErr := newContextExecutionError(ctx, err, c)
+		obs.end(eErr, capture, nil)
+		closePTY()
+		cleanup()
+		cancel()
+		return nil, eErr
+	}
+
+	go func() {
+		defer close(p.done)
+		defer cancel()
+		defer cleanup()
+		defer closePTY()
+
+		wg.Wait()
+
+		err := c.getExecutor().ExecCmdWait(ctx, c, cmd)
+		p.usage = newResourceUsage(cmd, startTime)
+
+		if err := rd.close(c.checkLimits(cmd, capture, err)); err != nil {
+			eErr := c.newLimitAwareExecutionError(ctx, err)
+			eErr.setProcessInfo(cmd, startTime, p.usage)
+			capture.apply(eErr, c)
+			obs.end(eErr, capture, p.usage)
+			p.err = eErr
+		} else {
+			obs.end(nil, capture, p.usage)
+		}
+	}()
+
+	return p, nil
+}
+
+// MustStartContext is like [*Command.StartContext] but panics on error.
+func (c *Command) MustStartContext(ctx context.Context) *Process {
+	p, err := c.StartContext(ctx)
+	errorz.MaybeMustWrap(err)
+	return p
+}
+
+// GetCommand returns the originating command.
+func (p *Process) GetCommand() *Command {
+	return p.c
+}
+
+// GetPID returns the process ID (or -1 if not available, e.g. with a fake [Executor]).
+func (p *Process) GetPID() int {
+	if p.cmd.Process == nil {
+		return -1
+	}
+
+	return p.cmd.Process.Pid
+}
+
+// Done returns a channel that is closed when the process exits and all its output has been consumed.
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Wait waits for the process to exit.
+func (p *Process) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// MustWait is like [*Process.Wait] but panics on error.
+func (p *Process) MustWait() {
+	errorz.MaybeMustWrap(p.Wait())
+}
+
+// GetResourceUsage returns the resources used by the process, or nil if it has not exited yet or no real process was run.
+func (p *Process) GetResourceUsage() *ResourceUsage {
+	select {
+	case <-p.done:
+		return p.usage
+	default:
+		return nil
+	}
+}
+
+// Signal sends a signal to the process (or to its process group, see [*Command.SetProcessGroup]).
+func (p *Process) Signal(sig os.Signal) error {
+	sSig, ok := sig.(syscall.Signal)
+	if !ok {
+		return errorz.Errorf("unsupported signal: %v", sig)
+	}
+
+	if p.cmd.Process == nil {
+		return errorz.Errorf("process not available")
+	}
+
+	return errorz.MaybeWrap(signalProcess(p.cmd, sSig, p.c.isProcessGroup))
+}
+
+// Stop asks the process to terminate by sending SIGTERM, then sends SIGKILL if it is still running after the given
+// grace period. It blocks until the process exits, but does not return its exit error (use [*Process.Wait] for that).
+func (p *Process) Stop(grace time.Duration) error {
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+
+	if p.cmd.Process == nil {
+		p.cancel()
+		<-p.done
+		return nil
+	}
+
+	if err := p.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return errorz.Wrap(err)
+	}
+
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(grace):
+	}
+
+	if err := p.Signal(syscall.SIGKILL); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return errorz.Wrap(err)
+	}
+
+	<-p.done
+	return nil
+}
+
+// Subscribe calls "eventFunc" with each line of output of the process, starting with up to [ProcessLineHistorySize]
+// lines already received. Calls are serialized, and "eventFunc" must not call back into the process.
+// The returned function cancels the subscription.
+func (p *Process) Subscribe(eventFunc func(*LineEvent)) func() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for _, e := range p.history {
+		eventFunc(e)
+	}
+
+	id := p.nextSub
+	p.nextSub++
+	p.subs[id] = eventFunc
+
+	return func() {
+		p.m.Lock()
+		defer p.m.Unlock()
+		delete(p.subs, id)
+	}
+}
+
+// WaitForLine waits until a line of output matches the given regexp, and returns it.
+// It fails if the process exits or the timeout expires first.
+func (p *Process) WaitForLine(re *regexp.Regexp, timeout time.Duration) (*LineEvent, error) {
+	ready := make(chan *LineEvent, 1)
+
+	unsubscribe := p.Subscribe(func(e *LineEvent) {
+		if re.MatchString(e.Text) {
+			select {
+			case ready <- e:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case e := <-ready:
+		return e, nil
+	case <-p.done:
+		select {
+		case e := <-ready:
+			return e, nil
+		default:
+			return nil, p.newProbeError(fmt.Sprintf("line matching %q", re.String()))
+		}
+	case <-timer.C:
+		return nil, errorz.Errorf("readiness probe (line matching %q) timed out after %v", re.String(), timeout)
+	}
+}
+
+// MustWaitForLine is like [*Process.WaitForLine] but panics on error.
+func (p *Process) MustWaitForLine(re *regexp.Regexp, timeout time.Duration) *LineEvent {
+	e, err := p.WaitForLine(re, timeout)
+	errorz.MaybeMustWrap(err)
+	return e
+}
+
+// WaitForTCP waits until the given address (e.g. "localhost:8080") accepts TCP connections.
+// It fails if the process exits or the timeout expires first.
+func (p *Process) WaitForTCP(address string, timeout time.Duration) error {
+	return p.poll("TCP "+address, timeout, func(ctx context.Context) bool {
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return false
+		}
+
+		_ = conn.Close()
+		return true
+	})
+}
+
+// MustWaitForTCP is like [*Process.WaitForTCP] but panics on error.
+func (p *Process) MustWaitForTCP(address string, timeout time.Duration) {
+	errorz.MaybeMustWrap(p.WaitForTCP(address, timeout))
+}
+
+// WaitForHTTP waits until a GET request to the given URL returns a 2xx status code.
+// It fails if the process exits or the timeout expires first.
+func (p *Process) WaitForHTTP(url string, timeout time.Duration) error {
+	return p.poll("HTTP "+url, timeout, func(ctx context.Context) bool {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return false
+		}
+
+		_ = resp.Body.Close()
+		return resp.StatusCode >= 200 && resp.StatusCode <= 299
+	})
+}
+
+// MustWaitForHTTP is like [*Process.WaitForHTTP] but panics on error.
+func (p *Process) MustWaitForHTTP(url string, timeout time.Duration) {
+	errorz.MaybeMustWrap(p.WaitForHTTP(url, timeout))
+}
+
+func (p *Process) poll(description string, timeout time.Duration, check func(ctx context.Context) bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for {
+		if check(ctx) {
+			return nil
+		}
+
+		select {
+		case <-p.done:
+			return p.newProbeError(description)
+		case <-ctx.Done():
+			return errorz.Errorf("readiness probe (%v) timed out after %v", description, timeout)
+		case <-time.After(probeInterval):
+		}
+	}
+}
+
+func (p *Process) newProbeError(description string) error {
+	if p.err != nil {
+		return errorz.Errorf("readiness probe (%v) failed: process exited: %v", description, p.err.Error())
+	}
+
+	return errorz.Errorf("readiness probe (%v) failed: process exited", description)
+}
+
+func (p *Process) handleLine(e *LineEvent) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if len(p.history) >= ProcessLineHistorySize {
+		p.history = append(p.history[:0], p.history[1:]...)
+	}
+
+	p.history = append(p.history, e)
+
+	for _, f := range p.subs {
+		f(e)
+	}
+}
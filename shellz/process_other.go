@@ -0,0 +1,59 @@
+//go:build !unix
+
+package shellz
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+const (
+	isResourceLimitWrapperSupported = false
+)
+
+func setProcessGroup(_ *exec.Cmd) {
+	// intentionally empty: process groups are not supported on this platform
+}
+
+func setControllingTerminal(_ *exec.Cmd) {
+	// intentionally empty: controlling terminals are not supported on this platform
+}
+
+func signalProcess(cmd *exec.Cmd, sig syscall.Signal, _ bool) error {
+	return cmd.Process.Signal(sig)
+}
+
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	return cmd.Process.Signal(sig)
+}
+
+func forwardSignals(_ func(sig syscall.Signal)) func() {
+	return func() {
+		// intentionally empty: process groups are not supported on this platform
+	}
+}
+
+func watchWindowSize(_ func()) func() {
+	return func() {
+		// intentionally empty: window size change notifications are not supported on this platform
+	}
+}
+
+func dupFile(_ *os.File) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func getPeakRSS(_ *os.ProcessState) int64 {
+	return -1
+}
+
+func isCPUTimeLimitExceeded(_ *os.ProcessState, _ time.Duration) bool {
+	return false
+}
+
+func getSignal(_ *os.ProcessState) os.Signal {
+	return nil
+}
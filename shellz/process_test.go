@@ -0,0 +1,228 @@
+package shellz_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type ProcessSuite struct {
+	// intentionally empty
+}
+
+func TestProcessSuite(t *testing.T) {
+	fixturez.RunSuite(t, &ProcessSuite{})
+}
+
+func (*ProcessSuite) TestStart_Wait(g *WithT) {
+	p, err := shellz.NewCommand("sh", "-c", "echo out; echo err >&2").SetEcho(false).Start()
+	g.Expect(err).To(Succeed())
+	g.Expect(p.GetPID()).To(BeNumerically(">", 0))
+	g.Expect(p.GetCommand().GetParams()).To(Equal([]string{"-c", "echo out; echo err >&2"}))
+	g.Expect(p.Wait()).To(Succeed())
+
+	<-p.Done()
+	m := &sync.Mutex{}
+	lines := make(map[shellz.Stream][]string)
+
+	p.Subscribe(func(e *shellz.LineEvent) {
+		m.Lock()
+		defer m.Unlock()
+		lines[e.Stream] = append(lines[e.Stream], e.Text)
+	})()
+
+	g.Expect(lines).To(Equal(map[shellz.Stream][]string{
+		shellz.StreamStdout: {"out"},
+		shellz.StreamStderr: {"err"},
+	}))
+}
+
+func (*ProcessSuite) TestStart_Error(g *WithT) {
+	p, err := shellz.NewCommand("ed1c6a2e-5c46-4d6e-b5f4-bb5b8a2bd1c3").SetEcho(false).Start()
+	g.Expect(p).To(BeNil())
+	g.Expect(err).To(MatchError(`execution error: exec: "ed1c6a2e-5c46-4d6e-b5f4-bb5b8a2bd1c3": executable file not found in $PATH`))
+
+	g.Expect(func() {
+		shellz.NewCommand("ed1c6a2e-5c46-4d6e-b5f4-bb5b8a2bd1c3").SetEcho(false).MustStart()
+	}).To(Panic())
+}
+
+func (*ProcessSuite) TestMustWait_Error(g *WithT) {
+	p := shellz.NewCommand("sh", "-c", "echo boom >&2; exit 3").
+		SetEcho(false).
+		SetCaptureLimit(shellz.DefaultCaptureLimit).
+		MustStart()
+
+	g.Expect(func() { p.MustWait() }).To(PanicWith(MatchError("execution error: exit status 3")))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](p.Wait())
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetCapturedStderr()).To(Equal("boom\n"))
+}
+
+func (*ProcessSuite) TestStartContext_Canceled(g *WithT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := shellz.NewCommand("sleep", "5").SetEcho(false).MustStartContext(ctx)
+	cancel()
+
+	err := p.Wait()
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.IsCanceled()).To(BeTrue())
+}
+
+func (*ProcessSuite) TestSubscribe(g *WithT) {
+	p := shellz.NewCommand("sh", "-c", "echo 1; sleep 0.2; echo 2").SetEcho(false).MustStart()
+
+	ch := make(chan string, 10)
+	unsubscribe := p.Subscribe(func(e *shellz.LineEvent) { ch <- e.Text })
+	g.Expect(p.Wait()).To(Succeed())
+	unsubscribe()
+	close(ch)
+
+	lines := make([]string, 0)
+
+	for line := range ch {
+		lines = append(lines, line)
+	}
+
+	g.Expect(lines).To(Equal([]string{"1", "2"}))
+}
+
+func (*ProcessSuite) TestSignal(g *WithT) {
+	p := shellz.NewCommand("sleep", "5").SetEcho(false).MustStart()
+	g.Expect(p.Signal(syscall.SIGTERM)).To(Succeed())
+	g.Expect(p.Wait()).To(MatchError("execution error: signal: terminated"))
+	g.Expect(p.Signal(syscall.SIGTERM)).To(MatchError("os: process already finished"))
+}
+
+func (*ProcessSuite) TestStop(g *WithT) {
+	p := shellz.NewCommand("sleep", "5").SetEcho(false).MustStart()
+	start := time.Now()
+	g.Expect(p.Stop(5 * time.Second)).To(Succeed())
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	g.Expect(p.Wait()).To(MatchError("execution error: signal: terminated"))
+	g.Expect(p.Stop(5 * time.Second)).To(Succeed())
+}
+
+func (*ProcessSuite) TestStop_Kill(g *WithT) {
+	p := shellz.NewCommand("sh", "-c", `trap "" TERM; echo ready; sleep 5`).
+		SetEcho(false).
+		SetProcessGroup(true).
+		MustStart()
+
+	p.MustWaitForLine(regexp.MustCompile("^ready$"), 5*time.Second)
+	start := time.Now()
+	g.Expect(p.Stop(200 * time.Millisecond)).To(Succeed())
+	g.Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+	g.Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+	g.Expect(p.Wait()).To(MatchError("execution error: signal: killed"))
+}
+
+func (*ProcessSuite) TestWaitForLine(g *WithT) {
+	p := shellz.NewCommand("sh", "-c", "echo starting; sleep 0.1; echo listening on :8080 >&2; sleep 5").SetEcho(false).MustStart()
+	defer func() { g.Expect(p.Stop(time.Second)).To(Succeed()) }()
+
+	e, err := p.WaitForLine(regexp.MustCompile(`listening on (:\d+)`), 5*time.Second)
+	g.Expect(err).To(Succeed())
+	g.Expect(e.Stream).To(Equal(shellz.StreamStderr))
+	g.Expect(e.Text).To(Equal("listening on :8080"))
+
+	e, err = p.WaitForLine(regexp.MustCompile(`^starting$`), time.Second)
+	g.Expect(err).To(Succeed())
+	g.Expect(e.Text).To(Equal("starting"))
+
+	_, err = p.WaitForLine(regexp.MustCompile(`never`), 100*time.Millisecond)
+	g.Expect(err).To(MatchError(`readiness probe (line matching "never") timed out after 100ms`))
+}
+
+func (*ProcessSuite) TestWaitForLine_Exited(g *WithT) {
+	p := shellz.NewCommand("sh", "-c", "echo starting; exit 3").SetEcho(false).MustStart()
+
+	_, err := p.WaitForLine(regexp.MustCompile(`ready`), 5*time.Second)
+	g.Expect(err).To(MatchError(`readiness probe (line matching "ready") failed: process exited: execution error: exit status 3`))
+
+	g.Expect(func() {
+		p.MustWaitForLine(regexp.MustCompile(`ready`), 5*time.Second)
+	}).To(Panic())
+}
+
+func (*ProcessSuite) TestWaitForTCP(g *WithT) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(Succeed())
+	address := l.Addr().String()
+	g.Expect(l.Close()).To(Succeed())
+
+	p := shellz.NewCommand("sleep", "5").SetEcho(false).MustStart()
+	defer func() { g.Expect(p.Stop(time.Second)).To(Succeed()) }()
+
+	g.Expect(p.WaitForTCP(address, 200*time.Millisecond)).
+		To(MatchError("readiness probe (TCP " + address + ") timed out after 200ms"))
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		l, err := net.Listen("tcp", address)
+		if err == nil {
+			time.Sleep(2 * time.Second)
+			_ = l.Close()
+		}
+	}()
+
+	p.MustWaitForTCP(address, 5*time.Second)
+}
+
+func (*ProcessSuite) TestWaitForHTTP(g *WithT) {
+	isReady := &atomic.Bool{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if isReady.Load() {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	p := shellz.NewCommand("sleep", "5").SetEcho(false).MustStart()
+	defer func() { g.Expect(p.Stop(time.Second)).To(Succeed()) }()
+
+	g.Expect(p.WaitForHTTP(srv.URL, 200*time.Millisecond)).
+		To(MatchError("readiness probe (HTTP " + srv.URL + ") timed out after 200ms"))
+
+	time.AfterFunc(200*time.Millisecond, func() { isReady.Store(true) })
+	p.MustWaitForHTTP(srv.URL, 5*time.Second)
+}
+
+func (*ProcessSuite) TestWaitForHTTP_Exited(g *WithT) {
+	p := shellz.NewCommand("true").SetEcho(false).MustStart()
+
+	g.Expect(func() {
+		p.MustWaitForHTTP("http://127.0.0.1:1", 5*time.Second)
+	}).To(PanicWith(MatchError("readiness probe (HTTP http://127.0.0.1:1) failed: process exited")))
+}
+
+func (*ProcessSuite) TestFakeExecutor(g *WithT) {
+	p := shellz.NewCommand("cmd").
+		SetEcho(false).
+		SetExecutor(shellz.NewDryRunExecutor().SetDefaultOutput("ready")).
+		MustStart()
+
+	g.Expect(p.GetPID()).To(Equal(-1))
+	g.Expect(p.Signal(syscall.SIGTERM)).To(MatchError("process not available"))
+	p.MustWaitForLine(regexp.MustCompile("ready"), time.Second)
+	g.Expect(p.Stop(time.Second)).To(Succeed())
+	g.Expect(p.Wait()).To(Succeed())
+}
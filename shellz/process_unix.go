@@ -0,0 +1,151 @@
+//go:build unix
+
+package shellz
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+const (
+	isResourceLimitWrapperSupported = true
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+	cmd.SysProcAttr.Pgid = 0
+}
+
+// setControllingTerminal starts "cmd" in a new session, with its standard output (the terminal side of a
+// pseudo-terminal) as controlling terminal. The session leader is also the leader of a new process group, so
+// [setProcessGroup] is superseded.
+func setControllingTerminal(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 1
+}
+
+func signalProcess(cmd *exec.Cmd, sig syscall.Signal, isProcessGroup bool) error {
+	if isProcessGroup {
+		return signalProcessGroup(cmd, sig)
+	}
+
+	return cmd.Process.Signal(sig)
+}
+
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	if err := syscall.Kill(-cmd.Process.Pid, sig); err != nil {
+		if err == syscall.ESRCH {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+
+	return nil
+}
+
+func forwardSignals(f func(sig syscall.Signal)) func() {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				f(sig.(syscall.Signal))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+func dupFile(f *os.File) (*os.File, error) {
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		return nil, err
+	}
+
+	return os.NewFile(uintptr(fd), f.Name()), nil
+}
+
+func watchWindowSize(f func()) func() {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGWINCH)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				f()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+func getPeakRSS(state *os.ProcessState) int64 {
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return -1
+	}
+
+	// The unit of ru_maxrss is bytes on Darwin, kilobytes elsewhere.
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		return int64(ru.Maxrss)
+	}
+
+	return int64(ru.Maxrss) * 1024
+}
+
+func isCPUTimeLimitExceeded(state *os.ProcessState, limit time.Duration) bool {
+	if state == nil || limit <= 0 {
+		return false
+	}
+
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return false
+	}
+
+	return ws.Signal() == syscall.SIGXCPU ||
+		(ws.Signal() == syscall.SIGKILL && state.UserTime()+state.SystemTime() >= time.Duration(getCPUTimeLimitSeconds(limit))*time.Second)
+}
+
+func getSignal(state *os.ProcessState) os.Signal {
+	if state == nil {
+		return nil
+	}
+
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ws.Signal()
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package shellz
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+var (
+	defaultPTYSize = &pty.Winsize{Rows: 24, Cols: 80}
+)
+
+// SetPTY configures the command to run with its standard output and error attached to a pseudo-terminal, so that tools
+// which check for a terminal keep their colors and progress output. It applies to [*Command.Run], [*Command.Lines],
+// [*Command.LinesEx] and [*Command.Start], and is ignored by the other modes (while pipelines fail, see [*Pipeline]).
+//
+// Standard output and error are merged and reported as [StreamStdout], and "\r\n" line endings are normalized to "\n".
+// The window size is inherited from the terminal of the current process (80x24 if none) and kept in sync with it.
+// The command runs in its own session with the pseudo-terminal as controlling terminal (so e.g. "/dev/tty" refers to it),
+// which also puts it in its own process group: signals from the terminal of the current process don't reach it.
+// If pseudo-terminals are not available on this platform, the command runs as if PTY mode was disabled.
+func (c *Command) SetPTY(isPTY bool) *Command {
+	cc := c.clone()
+	cc.isPTY = isPTY
+	return cc
+}
+
+// GetPTY returns the current PTY mode configuration.
+func (c *Command) GetPTY() bool {
+	return c.isPTY
+}
+
+// ptyAttachment is a pseudo-terminal attached to the standard output and error of a command.
+type ptyAttachment struct {
+	master    *os.File
+	slave     *os.File
+	closeOnce *sync.Once
+	stopWatch func()
+}
+
+// attachPTY attaches a new pseudo-terminal to the standard output and error of "cmd".
+// It returns nil if PTY mode is disabled or pseudo-terminals are not available.
+func (c *Command) attachPTY(cmd *exec.Cmd) *ptyAttachment {
+	if !c.isPTY {
+		return nil
+	}
+
+	master, slave, err := pty.Open()
+	if err != nil {
+		return nil
+	}
+
+	a := &ptyAttachment{
+		master:    master,
+		slave:     slave,
+		closeOnce: &sync.Once{},
+	}
+
+	a.resize()
+	a.stopWatch = watchWindowSize(a.resize)
+
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	setControllingTerminal(cmd)
+	return a
+}
+
+// resize applies the window size of the terminal of the current process (if any) to the pseudo-terminal.
+func (a *ptyAttachment) resize() {
+	for _, f := range []*os.File{os.Stdout, os.Stderr, os.Stdin} {
+		if size, err := pty.GetsizeFull(f); err == nil {
+			_ = pty.Setsize(a.master, size)
+			return
+		}
+	}
+
+	_ = pty.Setsize(a.master, defaultPTYSize)
+}
+
+// getReader returns a reader for the output of the command. It must be called at most once.
+func (a *ptyAttachment) getReader() io.Reader {
+	return &ptyReader{
+		r:       a.master,
+		buf:     make([]byte, 32*1024),
+		pending: make([]byte, 0),
+	}
+}
+
+// maybeCloseSlaveAfterStart closes the parent's copy of the terminal side of the pseudo-terminal if a process was
+// started, so that reading its output terminates when it exits. Like with pipes, an [Executor] that doesn't start a
+// process is responsible for closing the output files it is given.
+func (a *ptyAttachment) maybeCloseSlaveAfterStart(cmd *exec.Cmd) {
+	if a != nil && cmd.Process != nil {
+		a.closeSlave()
+	}
+}
+
+// closeSlave closes the parent's copy of the terminal side of the pseudo-terminal.
+func (a *ptyAttachment) closeSlave() {
+	a.closeOnce.Do(func() {
+		_ = a.slave.Close()
+	})
+}
+
+// close releases the pseudo-terminal.
+func (a *ptyAttachment) close() {
+	a.stopWatch()
+	a.closeSlave()
+	_ = a.master.Close()
+}
+
+// ptyReader reads from the controller side of a pseudo-terminal, normalizing "\r\n" to "\n" and converting the EIO
+// error returned once the terminal side is closed into [io.EOF].
+type ptyReader struct {
+	r           io.Reader
+	buf         []byte
+	pending     []byte
+	isPendingCR bool
+	err         error
+}
+
+// Read implements the [io.Reader] interface.
+func (r *ptyReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 && r.err == nil {
+		n, err := r.r.Read(r.buf)
+
+		for _, b := range r.buf[:n] {
+			if r.isPendingCR && b != '\n' {
+				r.pending = append(r.pending, '\r')
+			}
+
+			if r.isPendingCR = b == '\r'; !r.isPendingCR {
+				r.pending = append(r.pending, b)
+			}
+		}
+
+		if err != nil {
+			if r.isPendingCR {
+				r.pending = append(r.pending, '\r')
+				r.isPendingCR = false
+			}
+
+			if errors.Is(err, syscall.EIO) || errors.Is(err, os.ErrClosed) {
+				err = io.EOF
+			}
+
+			r.err = err
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[:copy(r.pending, r.pending[n:])]
+
+	if len(r.pending) == 0 && r.err != nil {
+		return n, r.err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,145 @@
+package shellz_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type PTYSuite struct {
+	// intentionally empty
+}
+
+func TestPTYSuite(t *testing.T) {
+	fixturez.RunSuite(t, &PTYSuite{})
+}
+
+func (*PTYSuite) TestSetPTY(g *WithT) {
+	c := shellz.NewCommand("cmd")
+	g.Expect(c.GetPTY()).To(BeFalse())
+	g.Expect(c.SetPTY(true).GetPTY()).To(BeTrue())
+	g.Expect(c.GetPTY()).To(BeFalse())
+}
+
+func (*PTYSuite) TestLinesEx(g *WithT) {
+	events := make([]*shellz.LineEvent, 0)
+
+	g.Expect(shellz.NewCommand("sh", "-c", `test -t 1 && echo tty-out; test -t 2 && echo tty-err >&2; printf 'a\r\nb\rc\n\r'`).
+		SetEcho(false).
+		SetPTY(true).
+		LinesEx(nil, func(e *shellz.LineEvent) { events = append(events, e) })).To(Succeed())
+
+	texts := make([]string, 0)
+
+	for _, e := range events {
+		g.Expect(e.Stream).To(Equal(shellz.StreamStdout))
+		texts = append(texts, e.Text)
+	}
+
+	g.Expect(texts).To(Equal([]string{"tty-out", "tty-err", "a", "b\rc", "\r"}))
+
+	lines := make([]string, 0)
+
+	g.Expect(shellz.NewCommand("sh", "-c", `test -t 1 || echo no-tty`).
+		SetEcho(false).
+		Lines(func(line string) { lines = append(lines, line) })).To(Succeed())
+	g.Expect(lines).To(Equal([]string{"no-tty"}))
+}
+
+func (*PTYSuite) TestWindowSize(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	lines := make([]string, 0)
+
+	g.Expect(shellz.NewCommand("sh", "-c", "stty size <&1").
+		SetEcho(false).
+		SetPTY(true).
+		Lines(func(line string) { lines = append(lines, line) })).To(Succeed())
+	g.Expect(lines).To(HaveLen(1))
+	g.Expect(lines[0]).To(MatchRegexp(`^\d+ \d+$`))
+}
+
+func (*PTYSuite) TestControllingTerminal(g *WithT) {
+	for _, isProcessGroup := range []bool{false, true} {
+		lines := make([]string, 0)
+
+		g.Expect(shellz.NewCommand("sh", "-c", "tty </dev/tty && stty size </dev/tty").
+			SetEcho(false).
+			SetPTY(true).
+			SetProcessGroup(isProcessGroup).
+			Lines(func(line string) { lines = append(lines, line) })).To(Succeed())
+		g.Expect(lines).To(HaveLen(2))
+		g.Expect(lines[0]).To(HavePrefix("/dev/"))
+		g.Expect(lines[1]).To(MatchRegexp(`^\d+ \d+$`))
+	}
+}
+
+func (*PTYSuite) TestRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	g.Expect(shellz.NewCommand("sh", "-c", "test -t 1 && echo tty-out; test -t 2 && echo tty-err >&2").
+		SetEcho(false).
+		SetPTY(true).
+		Run()).To(Succeed())
+
+	err := shellz.NewCommand("sh", "-c", "echo failed >&2; exit 3").
+		SetEcho(false).
+		SetPTY(true).
+		SetCaptureLimit(shellz.DefaultCaptureLimit).
+		Run()
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("tty-out\ntty-err\nfailed\n"))
+	g.Expect(errBuf).To(BeEmpty())
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetExitCode()).To(Equal(3))
+	g.Expect(eErr.GetCapturedStdout()).To(Equal("failed\n"))
+	g.Expect(eErr.GetCapturedStderr()).To(BeEmpty())
+}
+
+func (*PTYSuite) TestStart(g *WithT) {
+	p := shellz.NewCommand("sh", "-c", "test -t 1 && echo ready; exec sleep 5").
+		SetEcho(false).
+		SetPTY(true).
+		MustStart()
+
+	p.MustWaitForLine(regexp.MustCompile("^ready$"), 5*time.Second)
+	g.Expect(p.Stop(time.Second)).To(Succeed())
+	g.Expect(p.Wait()).To(MatchError("execution error: signal: terminated"))
+}
+
+func (*PTYSuite) TestStart_Error(g *WithT) {
+	_, err := shellz.NewCommand("ed1c6a2e-5c46-4d6e-b5f4-bb5b8a2bd1c3").SetEcho(false).SetPTY(true).Start()
+	g.Expect(err).To(HaveOccurred())
+
+	err = shellz.NewCommand("ed1c6a2e-5c46-4d6e-b5f4-bb5b8a2bd1c3").SetEcho(false).SetPTY(true).Lines(func(string) {})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func (*PTYSuite) TestDryRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	e := shellz.NewDryRunExecutor().SetDefaultOutput("out\n")
+	lines := make([]string, 0)
+
+	g.Expect(shellz.NewCommand("cmd").SetEcho(false).SetPTY(true).SetExecutor(e).Run()).To(Succeed())
+	g.Expect(shellz.NewCommand("cmd").SetEcho(false).SetPTY(true).SetExecutor(e).Lines(func(line string) {
+		lines = append(lines, line)
+	})).To(Succeed())
+	g.Expect(lines).To(Equal([]string{"out"}))
+
+	outBuf, _ := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("[.................dry-run] cmd\n[.................dry-run] cmd\n"))
+}
@@ -0,0 +1,317 @@
+package shellz
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ibrt/golang-utils/errorz"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+var (
+	_ error = (*ParseError)(nil)
+)
+
+// ParseError describes an error parsing a command line (see [ParseCommand]).
+type ParseError struct {
+	commandLine string
+	pos         int
+	message     string
+}
+
+// GetCommandLine returns the command line being parsed.
+func (e *ParseError) GetCommandLine() string {
+	return e.commandLine
+}
+
+// GetPos returns the (zero-based) byte offset of the error in the command line.
+func (e *ParseError) GetPos() int {
+	return e.pos
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at position %v: %v", e.pos, e.message)
+}
+
+// String returns the command line as it would be typed in a POSIX shell, quoting each word if needed.
+// It is prefixed by "cd <dir> &&" if a dir is set, then by the env overrides as "KEY=value" assignments. If the env
+// mode is not [EnvModeInherit] the environment is rendered through "env -i", and unset variables through "env -u".
+// Variables inherited through [EnvModeAllowlist] are listed, but their values are always redacted, since they may hold
+// host credentials unrelated to the command (e.g. "AWS_*"). File redirections (see [*Command.SetStdoutFile]) are
+// appended. Secrets are redacted (see [*Command.AddSecretParams]).
+func (c *Command) String() string {
+	words := make([]string, 0)
+
+	if c.dir != "" {
+		words = append(words, "cd", consolez.ShellQuote(c.redact(c.dir)), "&&")
+	}
+
+	env := c.getRedactedEnv()
+
+	switch {
+	case c.envMode == EnvModeAllowlist:
+		words = append(words, "env", "-i")
+		env = make(map[string]string)
+
+		for _, kv := range c.GetEffectiveEnv() {
+			if k, _, ok := cutEnv(kv); ok {
+				env[k] = RedactedPlaceholder
+			}
+		}
+
+		for k, v := range c.getRedactedEnv() {
+			env[k] = v
+		}
+	case c.envMode == EnvModeClean:
+		words = append(words, "env", "-i")
+	case len(c.unsetEnv) > 0:
+		words = append(words, "env")
+
+		for _, k := range c.GetUnsetEnv() {
+			words = append(words, "-u", consolez.ShellQuote(k))
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		words = append(words, k+"="+consolez.ShellQuote(env[k]))
+	}
+
+	words = append(words, consolez.ShellQuote(c.redact(c.cmd)))
+
+	for _, p := range c.getRedactedParams() {
+		words = append(words, consolez.ShellQuote(p))
+	}
+
+	for _, r := range c.getRedirections() {
+		words = append(words, r.Operator)
+
+		if r.FilePath != "" {
+			words = append(words, consolez.ShellQuote(r.FilePath))
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// ParseCommand parses a POSIX shell command line into a [*Command], splitting it into words like a shell would.
+//
+// Single quotes, double quotes and backslash escapes are supported. Leading "KEY=value" assignments become env
+// overrides, and a leading "cd <dir> &&" sets the dir. A leading "env" with "-i" and/or "-u KEY" flags is also
+// understood, so that the output of [*Command.String] can be parsed back (unless it has file redirections). Otherwise
+// "env" is parsed as the command (e.g. "env" or "FOO=1 env").
+// Expansions (e.g. "$HOME") and operators (e.g. pipes and redirections) are not supported and cause an error.
+func ParseCommand(commandLine string) (*Command, error) {
+	words, err := splitCommandLine(commandLine)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	dir := ""
+
+	if len(words) >= 3 && !words[0].isOperator && words[0].text == "cd" && !words[1].isOperator && words[2].isOperator {
+		dir = words[1].text
+		words = words[3:]
+	}
+
+	for _, w := range words {
+		if w.isOperator {
+			return nil, errorz.Wrap(&ParseError{commandLine: commandLine, pos: w.pos, message: "unsupported operator '&&'"})
+		}
+	}
+
+	envMode := EnvModeInherit
+	unsetEnv := make([]string, 0)
+
+	if isEnvPrefix(words) {
+		words = words[1:]
+
+	envFlags:
+		for len(words) > 0 {
+			switch words[0].text {
+			case "-i":
+				envMode = EnvModeClean
+				words = words[1:]
+			case "-u":
+				if len(words) < 2 {
+					return nil, errorz.Wrap(&ParseError{commandLine: commandLine, pos: words[0].pos, message: "missing argument for 'env -u'"})
+				}
+
+				unsetEnv = append(unsetEnv, words[1].text)
+				words = words[2:]
+			default:
+				break envFlags
+			}
+		}
+	}
+
+	env := make(map[string]string)
+
+	for len(words) > 0 && words[0].assignIdx > 0 {
+		env[words[0].text[:words[0].assignIdx]] = words[0].text[words[0].assignIdx+1:]
+		words = words[1:]
+	}
+
+	if len(words) == 0 {
+		return nil, errorz.Wrap(&ParseError{commandLine: commandLine, pos: len(commandLine), message: "missing command"})
+	}
+
+	var params []string
+
+	for _, w := range words[1:] {
+		params = append(params, w.text)
+	}
+
+	return NewCommand(words[0].text, params...).
+		SetDir(dir).
+		SetEnvMode(envMode).
+		UnsetEnv(unsetEnv...).
+		MergeEnv(env), nil
+}
+
+// MustParseCommand is like [ParseCommand] but panics on error.
+func MustParseCommand(commandLine string) *Command {
+	c, err := ParseCommand(commandLine)
+	errorz.MaybeMustWrap(err)
+	return c
+}
+
+type commandLineWord struct {
+	text       string
+	pos        int
+	assignIdx  int
+	isOperator bool
+}
+
+// splitCommandLine splits a command line into words. The only supported operator is "&&".
+func splitCommandLine(commandLine string) ([]*commandLineWord, error) {
+	words := make([]*commandLineWord, 0)
+	b := &strings.Builder{}
+	var w *commandLineWord
+
+	newParseError := func(pos int, message string) error {
+		return &ParseError{commandLine: commandLine, pos: pos, message: message}
+	}
+
+	begin := func(pos int) {
+		if w == nil {
+			w = &commandLineWord{pos: pos, assignIdx: -1}
+		}
+	}
+
+	end := func() {
+		if w != nil {
+			w.text = b.String()
+
+			if w.assignIdx > 0 && !isEnvName(w.text[:w.assignIdx]) {
+				w.assignIdx = -1
+			}
+
+			words = append(words, w)
+			b.Reset()
+			w = nil
+		}
+	}
+
+	isQuoted := false
+
+	for i := 0; i < len(commandLine); i++ {
+		switch ch := commandLine[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			end()
+			isQuoted = false
+		case ch == '#' && w == nil:
+			i = len(commandLine)
+		case ch == '&' && strings.HasPrefix(commandLine[i:], "&&"):
+			end()
+			isQuoted = false
+			words = append(words, &commandLineWord{text: "&&", pos: i, assignIdx: -1, isOperator: true})
+			i++
+		case strings.IndexByte("|&;<>()`", ch) >= 0:
+			return nil, newParseError(i, "unsupported operator '"+string(ch)+"'")
+		case ch == '$':
+			return nil, newParseError(i, "unsupported expansion")
+		case ch == '\\':
+			if i+1 >= len(commandLine) {
+				return nil, newParseError(i, "unterminated escape")
+			}
+
+			if i++; commandLine[i] != '\n' {
+				begin(i - 1)
+				b.WriteByte(commandLine[i])
+				isQuoted = true
+			}
+		case ch == '\'':
+			begin(i)
+			j := strings.IndexByte(commandLine[i+1:], '\'')
+
+			if j < 0 {
+				return nil, newParseError(i, "unterminated single quote")
+			}
+
+			b.WriteString(commandLine[i+1 : i+1+j])
+			i += j + 1
+			isQuoted = true
+		case ch == '"':
+			begin(i)
+			start := i
+
+			for i++; ; i++ {
+				if i >= len(commandLine) {
+					return nil, newParseError(start, "unterminated double quote")
+				}
+
+				if c := commandLine[i]; c == '"' {
+					break
+				} else if c == '$' || c == '`' {
+					return nil, newParseError(i, "unsupported expansion")
+				} else if c == '\\' && i+1 < len(commandLine) && strings.IndexByte("$`\"\\\n", commandLine[i+1]) >= 0 {
+					i++
+
+					if commandLine[i] != '\n' {
+						b.WriteByte(commandLine[i])
+					}
+				} else {
+					b.WriteByte(c)
+				}
+			}
+
+			isQuoted = true
+		default:
+			begin(i)
+
+			if ch == '=' && w.assignIdx < 0 && !isQuoted {
+				w.assignIdx = b.Len()
+			}
+
+			b.WriteByte(ch)
+		}
+	}
+
+	end()
+	return words, nil
+}
+
+// isEnvPrefix returns true if the words start with an "env" command that only sets up the environment of the following
+// command, i.e. it is followed by "-i", "-u" or an assignment. Otherwise "env" is the command itself (e.g. "env" alone,
+// which prints the environment).
+func isEnvPrefix(words []*commandLineWord) bool {
+	if len(words) < 2 || words[0].text != "env" || words[0].assignIdx >= 0 {
+		return false
+	}
+
+	return words[1].text == "-i" || words[1].text == "-u" || words[1].assignIdx > 0
+}
+
+func isEnvName(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+
+	return s != ""
+}
@@ -0,0 +1,153 @@
+package shellz_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type QuoteSuite struct {
+	// intentionally empty
+}
+
+func TestQuoteSuite(t *testing.T) {
+	fixturez.RunSuite(t, &QuoteSuite{})
+}
+
+func (*QuoteSuite) TestString(g *WithT) {
+	g.Expect(shellz.NewCommand("ls").String()).To(Equal("ls"))
+	g.Expect(shellz.NewCommand("go", "test", "-run", "X Y", "./...").String()).To(Equal("go test -run 'X Y' ./..."))
+	g.Expect(shellz.NewCommand("/bin/my cmd", "", "it's", "$HOME").String()).To(Equal(`'/bin/my cmd' '' 'it'\''s' '$HOME'`))
+
+	g.Expect(shellz.NewCommand("go", "test").
+		SetDir("/my dir").
+		SetEnv("FOO", "1").
+		SetEnv("BAR", "a b").
+		String()).To(Equal("cd '/my dir' && BAR='a b' FOO=1 go test"))
+
+	g.Expect(shellz.NewCommand("cmd").
+		UnsetEnv("B", "A").
+		SetEnv("K", "V").
+		String()).To(Equal("env -u A -u B K=V cmd"))
+
+	g.Expect(shellz.NewCommand("cmd").
+		SetEnvMode(shellz.EnvModeClean).
+		UnsetEnv("A").
+		SetEnv("K", "V").
+		String()).To(Equal("env -i K=V cmd"))
+
+	g.Expect(shellz.NewCommand("cmd", "-p").
+		AddSecretParams("s3cr3t").
+		SetSecretEnv("TOKEN", "t0k3n").
+		String()).To(Equal("TOKEN='***' cmd -p '***'"))
+}
+
+func (*QuoteSuite) TestString_Allowlist(g *WithT) {
+	g.Expect(os.Setenv("SHELLZ_TEST_A", "a")).To(Succeed())
+	defer func() { g.Expect(os.Unsetenv("SHELLZ_TEST_A")).To(Succeed()) }()
+
+	g.Expect(shellz.NewCommand("cmd").
+		SetEnvMode(shellz.EnvModeAllowlist, "SHELLZ_TEST_A").
+		SetSecretEnv("TOKEN", "t0k3n").
+		String()).To(Equal("env -i SHELLZ_TEST_A='***' TOKEN='***' cmd"))
+
+	g.Expect(os.Setenv("SHELLZ_TEST_SECRET_ACCESS_KEY", "hunter2")).To(Succeed())
+	defer func() { g.Expect(os.Unsetenv("SHELLZ_TEST_SECRET_ACCESS_KEY")).To(Succeed()) }()
+
+	c := shellz.NewCommand("cmd").
+		SetEnvMode(shellz.EnvModeAllowlist, "SHELLZ_TEST_*").
+		SetEnv("K", "V")
+
+	g.Expect(c.String()).To(Equal("env -i K=V SHELLZ_TEST_A='***' SHELLZ_TEST_SECRET_ACCESS_KEY='***' cmd"))
+	g.Expect(c.String()).ToNot(ContainSubstring("hunter2"))
+	g.Expect(c.GetEffectiveEnv()).To(ContainElement("SHELLZ_TEST_SECRET_ACCESS_KEY=hunter2"))
+}
+
+func (*QuoteSuite) TestParseCommand(g *WithT) {
+	c, err := shellz.ParseCommand("FOO=1 go test -run 'X Y' ./...")
+	g.Expect(err).To(Succeed())
+	g.Expect(c.GetEnv()).To(Equal(map[string]string{"FOO": "1"}))
+	g.Expect(c.String()).To(Equal("FOO=1 go test -run 'X Y' ./..."))
+	g.Expect(c.GetParams()).To(Equal([]string{"test", "-run", "X Y", "./..."}))
+
+	c = shellz.MustParseCommand(`  cmd  "a \"b\" \\ \$ \x"  a\ b 'it'\''s' "" x=y --k="v w" # comment`)
+	g.Expect(c.GetParams()).To(Equal([]string{`a "b" \ $ \x`, "a b", "it's", "", "x=y", "--k=v w"}))
+	g.Expect(c.GetEnv()).To(BeEmpty())
+
+	c = shellz.MustParseCommand("cmd \\\n  a\\\nb")
+	g.Expect(c.GetParams()).To(Equal([]string{"ab"}))
+
+	c = shellz.MustParseCommand(`'A'=1 A-B=2 =3 cmd`)
+	g.Expect(c.GetEnv()).To(BeEmpty())
+	g.Expect(c.GetParams()).To(Equal([]string{"A-B=2", "=3", "cmd"}))
+
+	c = shellz.MustParseCommand(`cd '/my dir' && env -i -u A K='v w' cmd`)
+	g.Expect(c.GetDir()).To(Equal("/my dir"))
+	g.Expect(c.GetEnvMode()).To(Equal(shellz.EnvModeClean))
+	g.Expect(c.GetUnsetEnv()).To(Equal([]string{"A"}))
+	g.Expect(c.GetEnv()).To(Equal(map[string]string{"K": "v w"}))
+
+	c = shellz.MustParseCommand("env")
+	g.Expect(c.GetCmd()).To(Equal("env"))
+	g.Expect(c.GetParams()).To(BeEmpty())
+
+	c = shellz.MustParseCommand("FOO=1 env")
+	g.Expect(c.GetCmd()).To(Equal("env"))
+	g.Expect(c.GetEnv()).To(Equal(map[string]string{"FOO": "1"}))
+
+	c = shellz.MustParseCommand("env --help")
+	g.Expect(c.GetCmd()).To(Equal("env"))
+	g.Expect(c.GetParams()).To(Equal([]string{"--help"}))
+
+	c = shellz.MustParseCommand("env FOO=1 cmd")
+	g.Expect(c.GetCmd()).To(Equal("cmd"))
+	g.Expect(c.GetEnv()).To(Equal(map[string]string{"FOO": "1"}))
+}
+
+func (*QuoteSuite) TestParseCommand_RoundTrip(g *WithT) {
+	for _, c := range []*shellz.Command{
+		shellz.NewCommand("cmd", "", " ", "'", `"`, `\`, "$HOME", "a\nb", "#", "&&", "|", "é"),
+		shellz.NewCommand("cmd").SetDir("/d").SetEnv("K", "v'w").UnsetEnv("U"),
+		shellz.NewCommand("cmd").SetEnvMode(shellz.EnvModeClean).SetEnv("K", ""),
+	} {
+		g.Expect(shellz.MustParseCommand(c.String()).String()).To(Equal(c.String()))
+		g.Expect(shellz.MustParseCommand(c.String()).GetParams()).To(Equal(c.GetParams()))
+	}
+}
+
+func (*QuoteSuite) TestParseCommand_Errors(g *WithT) {
+	for commandLine, message := range map[string]string{
+		"":            "parse error at position 0: missing command",
+		"# comment":   "parse error at position 9: missing command",
+		"A=1":         "parse error at position 3: missing command",
+		"cmd 'a":      "parse error at position 4: unterminated single quote",
+		`cmd "a`:      "parse error at position 4: unterminated double quote",
+		`cmd a\`:      "parse error at position 5: unterminated escape",
+		"cmd $HOME":   "parse error at position 4: unsupported expansion",
+		`cmd "$HOME"`: "parse error at position 5: unsupported expansion",
+		"cmd | cat":   "parse error at position 4: unsupported operator '|'",
+		"cmd > f":     "parse error at position 4: unsupported operator '>'",
+		"cmd; cat":    "parse error at position 3: unsupported operator ';'",
+		"cmd && cat":  "parse error at position 4: unsupported operator '&&'",
+		"cd && cat":   "parse error at position 3: unsupported operator '&&'",
+		"env -u":      "parse error at position 4: missing argument for 'env -u'",
+	} {
+		_, err := shellz.ParseCommand(commandLine)
+		g.Expect(err).To(MatchError(message), commandLine)
+
+		pErr, ok := errorz.As[*shellz.ParseError](err)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(pErr.GetCommandLine()).To(Equal(commandLine))
+	}
+
+	_, err := shellz.ParseCommand("cmd 'a")
+	pErr, _ := errorz.As[*shellz.ParseError](err)
+	g.Expect(pErr.GetPos()).To(Equal(4))
+
+	g.Expect(func() { shellz.MustParseCommand("") }).To(Panic())
+}
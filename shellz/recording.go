@@ -0,0 +1,355 @@
+package shellz
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/hashz"
+	"github.com/ibrt/golang-utils/jsonz"
+	"github.com/ibrt/golang-utils/memz"
+)
+
+// InteractionKind describes the [Executor] method that produced a [*CassetteInteraction].
+type InteractionKind string
+
+// Known interaction kinds.
+const (
+	InteractionKindRun            InteractionKind = "Run"
+	InteractionKindOutput         InteractionKind = "Output"
+	InteractionKindCombinedOutput InteractionKind = "CombinedOutput"
+	InteractionKindStart          InteractionKind = "Start"
+)
+
+// Cassette is a sequence of recorded command executions.
+type Cassette struct {
+	Interactions []*CassetteInteraction `json:"interactions"`
+}
+
+// CassetteInteraction describes a single recorded command execution.
+// The env mode is empty for [EnvModeInherit], and the env allowlist is only set for [EnvModeAllowlist].
+type CassetteInteraction struct {
+	Kind         InteractionKind   `json:"kind"`
+	Command      string            `json:"command"`
+	Params       []string          `json:"params,omitempty"`
+	Dir          string            `json:"dir,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	EnvMode      string            `json:"envMode,omitempty"`
+	EnvAllowlist []string          `json:"envAllowlist,omitempty"`
+	UnsetEnv     []string          `json:"unsetEnv,omitempty"`
+	StdinSHA256  string            `json:"stdinSha256,omitempty"`
+	Stdout       string            `json:"stdout,omitempty"`
+	Stderr       string            `json:"stderr,omitempty"`
+	ExitCode     int               `json:"exitCode"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// NewCassette initializes a new, empty [*Cassette].
+func NewCassette() *Cassette {
+	return &Cassette{
+		Interactions: make([]*CassetteInteraction, 0),
+	}
+}
+
+// MustLoadCassette loads a [*Cassette] from a JSON file.
+func MustLoadCassette(filePath string) *Cassette {
+	return jsonz.MustUnmarshal[*Cassette](filez.MustReadFile(filePath))
+}
+
+// MustSave saves the [*Cassette] to a JSON file.
+func (c *Cassette) MustSave(filePath string) {
+	filez.MustWriteFile(filePath, 0777, 0666, jsonz.MustMarshalPretty(c))
+}
+
+var (
+	_ Executor          = (*RecordingExecutor)(nil)
+	_ SimulatedExecutor = (*RecordingExecutor)(nil)
+)
+
+// RecordingExecutor implements the [Executor] interface by delegating to another [Executor],
+// and records every command execution to a [*Cassette].
+//
+// The input of commands is hashed and recorded as a digest, unless it is an [*os.File] (e.g. a pipeline stage).
+type RecordingExecutor struct {
+	m        *sync.Mutex
+	base     Executor
+	cassette *Cassette
+	pending  map[*exec.Cmd]*pendingRecording
+}
+
+type pendingRecording struct {
+	interaction *CassetteInteraction
+	stdout      *bytes.Buffer
+	stderr      *bytes.Buffer
+	wait        func()
+}
+
+// NewRecordingExecutor initializes a new [*RecordingExecutor] wrapping the given [Executor].
+func NewRecordingExecutor(base Executor) *RecordingExecutor {
+	return &RecordingExecutor{
+		m:        &sync.Mutex{},
+		base:     base,
+		cassette: NewCassette(),
+		pending:  make(map[*exec.Cmd]*pendingRecording),
+	}
+}
+
+// GetCassette returns a copy of the [*Cassette] recorded so far.
+func (e *RecordingExecutor) GetCassette() *Cassette {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	return &Cassette{
+		Interactions: memz.ShallowCopySlice(e.cassette.Interactions),
+	}
+}
+
+// MustSave saves the [*Cassette] recorded so far to a JSON file.
+func (e *RecordingExecutor) MustSave(filePath string) {
+	e.GetCassette().MustSave(filePath)
+}
+
+// IsSimulated implements the [SimulatedExecutor] interface.
+func (e *RecordingExecutor) IsSimulated() bool {
+	return IsSimulated(e.base)
+}
+
+// ExecCmdCombinedOutput implements the [Executor] interface.
+func (e *RecordingExecutor) ExecCmdCombinedOutput(ctx context.Context, c *Command, cmd *exec.Cmd) ([]byte, error) {
+	i, err := NewCassetteInteraction(InteractionKindCombinedOutput, c, cmd)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	out, err := e.base.ExecCmdCombinedOutput(ctx, c, cmd)
+	i.Stdout = string(out)
+	e.record(i, err)
+	return out, err
+}
+
+// ExecCmdOutput implements the [Executor] interface.
+func (e *RecordingExecutor) ExecCmdOutput(ctx context.Context, c *Command, cmd *exec.Cmd) ([]byte, error) {
+	i, err := NewCassetteInteraction(InteractionKindOutput, c, cmd)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	errBuf := &bytes.Buffer{}
+	isStderrCaptured := cmd.Stderr == nil
+
+	if !isStderrCaptured {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, errBuf)
+	}
+
+	out, err := e.base.ExecCmdOutput(ctx, c, cmd)
+	i.Stdout = string(out)
+	i.Stderr = errBuf.String()
+
+	if eErr, ok := errorz.As[*exec.ExitError](err); ok && isStderrCaptured {
+		i.Stderr = string(eErr.Stderr)
+	}
+
+	e.record(i, err)
+	return out, err
+}
+
+// ExecCmdRun implements the [Executor] interface.
+func (e *RecordingExecutor) ExecCmdRun(ctx context.Context, c *Command, cmd *exec.Cmd) error {
+	i, err := NewCassetteInteraction(InteractionKindRun, c, cmd)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	outBuf := &bytes.Buffer{}
+	errBuf := &bytes.Buffer{}
+	cmd.Stdout = teeWriter(cmd.Stdout, outBuf)
+	cmd.Stderr = teeWriter(cmd.Stderr, errBuf)
+
+	err = e.base.ExecCmdRun(ctx, c, cmd)
+	i.Stdout = outBuf.String()
+	i.Stderr = errBuf.String()
+	e.record(i, err)
+	return err
+}
+
+// ExecCmdStart implements the [Executor] interface.
+func (e *RecordingExecutor) ExecCmdStart(ctx context.Context, c *Command, cmd *exec.Cmd) error {
+	i, err := NewCassetteInteraction(InteractionKindStart, c, cmd)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	p := &pendingRecording{
+		interaction: i,
+		stdout:      &bytes.Buffer{},
+		stderr:      &bytes.Buffer{},
+	}
+
+	finishStdout, _ := tapStartWriter(&cmd.Stdout, p.stdout)
+	finishStderr, _ := tapStartWriter(&cmd.Stderr, p.stderr)
+
+	err = e.base.ExecCmdStart(ctx, c, cmd)
+	waitStdout := finishStdout(err == nil)
+	waitStderr := finishStderr(err == nil)
+
+	if err != nil {
+		e.record(i, err)
+		return err
+	}
+
+	p.wait = func() {
+		waitStdout()
+		waitStderr()
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.pending[cmd] = p
+	return nil
+}
+
+// ExecCmdWait implements the [Executor] interface.
+func (e *RecordingExecutor) ExecCmdWait(ctx context.Context, c *Command, cmd *exec.Cmd) error {
+	err := e.base.ExecCmdWait(ctx, c, cmd)
+
+	e.m.Lock()
+	p, ok := e.pending[cmd]
+	delete(e.pending, cmd)
+	e.m.Unlock()
+
+	if ok {
+		p.wait()
+		p.interaction.Stdout = p.stdout.String()
+		p.interaction.Stderr = p.stderr.String()
+		e.record(p.interaction, err)
+	}
+
+	return err
+}
+
+// ExecLookPath implements the [Executor] interface.
+func (e *RecordingExecutor) ExecLookPath(c *Command, file string) (string, error) {
+	return e.base.ExecLookPath(c, file)
+}
+
+// OSChdir implements the [Executor] interface.
+func (e *RecordingExecutor) OSChdir(c *Command, dir string) error {
+	return e.base.OSChdir(c, dir)
+}
+
+// SyscallExec implements the [Executor] interface.
+func (e *RecordingExecutor) SyscallExec(c *Command, argv0 string, argv []string, envv []string) error {
+	return e.base.SyscallExec(c, argv0, argv, envv)
+}
+
+func (e *RecordingExecutor) record(i *CassetteInteraction, err error) {
+	if err != nil {
+		i.Error = err.Error()
+
+		if eErr, ok := errorz.As[interface{ ExitCode() int }](err); ok {
+			i.ExitCode = eErr.ExitCode()
+		}
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.cassette.Interactions = append(e.cassette.Interactions, i)
+}
+
+// tapStartWriter arranges for the output written to "*w" by a started command to be copied to "buf".
+// Writers that are not an [*os.File] are simply wrapped. Files (e.g. pipes created by [*exec.Cmd.StdoutPipe]) are
+// duplicated and fed through an intermediate pipe, so that their owner can close them after start as usual.
+// The returned function must be called after start, and returns a function that blocks until all output is copied.
+// The returned bool is false if the output cannot be copied (e.g. because files cannot be duplicated on this platform).
+func tapStartWriter(w *io.Writer, buf *bytes.Buffer) (func(isStarted bool) func(), bool) {
+	f, ok := (*w).(*os.File)
+	if !ok {
+		*w = teeWriter(*w, buf)
+		return func(bool) func() { return func() {} }, true
+	}
+
+	dup, err := dupFile(f)
+	if err != nil {
+		return func(bool) func() { return func() {} }, false
+	}
+
+	r, pw, err := os.Pipe()
+	if err != nil {
+		_ = dup.Close()
+		return func(bool) func() { return func() {} }, false
+	}
+
+	*w = pw
+
+	return func(isStarted bool) func() {
+		*w = f
+		_ = pw.Close()
+
+		if !isStarted {
+			_ = r.Close()
+			_ = dup.Close()
+			return func() {}
+		}
+
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			defer func() { _ = dup.Close() }()
+			defer func() { _ = r.Close() }()
+			_, _ = io.Copy(io.MultiWriter(dup, buf), r)
+		}()
+
+		return func() { <-done }
+	}, true
+}
+
+// NewCassetteInteraction initializes a new [*CassetteInteraction] describing the execution of the given command.
+// Secrets are redacted from the params, dir and env (see [*Command.AddSecretParams]).
+// If the input of the command is not an [*os.File], it is read in full to compute its digest and then replaced.
+func NewCassetteInteraction(kind InteractionKind, c *Command, cmd *exec.Cmd) (*CassetteInteraction, error) {
+	i := &CassetteInteraction{
+		Kind:    kind,
+		Command: c.cmd,
+		Params:  c.getRedactedParams(),
+		Dir:     c.redact(c.dir),
+		Env:     c.getRedactedEnv(),
+	}
+
+	if c.envMode != EnvModeInherit {
+		i.EnvMode = c.envMode.String()
+	}
+
+	if c.envMode == EnvModeAllowlist {
+		i.EnvAllowlist = c.GetEnvAllowlist()
+	}
+
+	if len(c.unsetEnv) > 0 {
+		i.UnsetEnv = c.GetUnsetEnv()
+	}
+
+	if _, ok := cmd.Stdin.(*os.File); cmd.Stdin != nil && !ok {
+		in, err := io.ReadAll(cmd.Stdin)
+		if err != nil {
+			return nil, errorz.Wrap(err)
+		}
+
+		i.StdinSHA256 = hashz.MustHashSHA256(in)
+		cmd.Stdin = bytes.NewReader(in)
+	}
+
+	return i, nil
+}
+
+func teeWriter(w io.Writer, buf *bytes.Buffer) io.Writer {
+	if w == nil {
+		return buf
+	}
+
+	return io.MultiWriter(w, buf)
+}
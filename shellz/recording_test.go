@@ -0,0 +1,173 @@
+package shellz_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/hashz"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+	"github.com/ibrt/golang-dev/shellz/tshellz"
+)
+
+type RecordingSuite struct {
+	// intentionally empty
+}
+
+func TestRecordingSuite(t *testing.T) {
+	fixturez.RunSuite(t, &RecordingSuite{})
+}
+
+func (*RecordingSuite) TestExitError(g *WithT) {
+	err := shellz.NewExitError(3, []byte("stderr"))
+	g.Expect(err.ExitCode()).To(Equal(3))
+	g.Expect(err.GetStderr()).To(Equal([]byte("stderr")))
+	g.Expect(err.Error()).To(Equal("exit status 3"))
+
+	eErr := shellz.NewExecutionError(err, shellz.NewCommand("cmd"))
+	g.Expect(eErr.GetExitCode()).To(Equal(3))
+	g.Expect(eErr.GetCapturedStderr()).To(Equal("stderr"))
+}
+
+func (*RecordingSuite) TestRecordingExecutor(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	e := shellz.NewRecordingExecutor(&shellz.RealExecutor{})
+
+	g.Expect(shellz.NewCommand("sh", "-c", "cat; echo err >&2").
+		SetIn(strings.NewReader("in")).
+		SetEcho(false).
+		SetExecutor(e).
+		Run()).To(Succeed())
+
+	g.Expect(shellz.NewCommand("sh", "-c", "echo out; echo err >&2; exit 3").
+		SetDir(filez.MustGetwd()).
+		SetEnv("K", "V").
+		SetEnvMode(shellz.EnvModeAllowlist, "PATH").
+		UnsetEnv("U").
+		SetEcho(false).
+		SetExecutor(e).
+		Output(false)).Error().To(HaveOccurred())
+
+	g.Expect(shellz.NewCommand("sh", "-c", "echo out; echo err >&2").
+		SetExecutor(e).
+		CombinedOutputString()).To(Equal("out\nerr\n"))
+
+	lines := make([]string, 0)
+
+	g.Expect(shellz.NewCommand("sh", "-c", "echo out").
+		SetEcho(false).
+		SetExecutor(e).
+		Lines(func(line string) { lines = append(lines, line) })).To(Succeed())
+	g.Expect(lines).To(Equal([]string{"out"}))
+
+	g.Expect(shellz.NewCommand("echo", "a").Pipe(shellz.NewCommand("cat").SetExecutor(e)).
+		SetEcho(false).
+		OutputString(false)).To(Equal("a\n"))
+
+	g.Expect(shellz.NewCommand("a0b6f9c2-2b1e-4d0f-9d64-6b2f0c8d1e2a").
+		SetEcho(false).
+		SetExecutor(e).
+		Run()).ToNot(Succeed())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("in"))
+	g.Expect(errBuf).To(Equal("err\n"))
+
+	g.Expect(e.GetCassette().Interactions).To(Equal([]*shellz.CassetteInteraction{
+		{
+			Kind:        shellz.InteractionKindRun,
+			Command:     "sh",
+			Params:      []string{"-c", "cat; echo err >&2"},
+			Env:         map[string]string{},
+			StdinSHA256: hashz.MustHashSHA256([]byte("in")),
+			Stdout:      "in",
+			Stderr:      "err\n",
+		},
+		{
+			Kind:         shellz.InteractionKindOutput,
+			Command:      "sh",
+			Params:       []string{"-c", "echo out; echo err >&2; exit 3"},
+			Dir:          filez.MustGetwd(),
+			Env:          map[string]string{"K": "V"},
+			EnvMode:      "allowlist",
+			EnvAllowlist: []string{"PATH"},
+			UnsetEnv:     []string{"U"},
+			Stdout:       "out\n",
+			Stderr:       "err\n",
+			ExitCode:     3,
+			Error:        "exit status 3",
+		},
+		{
+			Kind:    shellz.InteractionKindCombinedOutput,
+			Command: "sh",
+			Params:  []string{"-c", "echo out; echo err >&2"},
+			Env:     map[string]string{},
+			Stdout:  "out\nerr\n",
+		},
+		{
+			Kind:    shellz.InteractionKindStart,
+			Command: "sh",
+			Params:  []string{"-c", "echo out"},
+			Env:     map[string]string{},
+			Stdout:  "out\n",
+		},
+		{
+			Kind:    shellz.InteractionKindStart,
+			Command: "cat",
+			Env:     map[string]string{},
+			Stdout:  "a\n",
+		},
+		{
+			Kind:    shellz.InteractionKindRun,
+			Command: "a0b6f9c2-2b1e-4d0f-9d64-6b2f0c8d1e2a",
+			Env:     map[string]string{},
+			Error:   `exec: "a0b6f9c2-2b1e-4d0f-9d64-6b2f0c8d1e2a": executable file not found in $PATH`,
+		},
+	}))
+}
+
+func (*RecordingSuite) TestRecordAndReplay(g *WithT) {
+	cassetteFilePath := filepath.Join(filez.MustCreateTempDir(), "cassette.json")
+	defer filez.MustRemoveAll(filepath.Dir(cassetteFilePath))
+
+	run := func(e shellz.Executor) []any {
+		out1, err1 := shellz.NewCommand("sh", "-c", "cat; echo err >&2; exit 2").
+			SetIn(strings.NewReader("in")).
+			SetExecutor(e).
+			Output(false)
+
+		out2, err2 := shellz.NewCommand("sh", "-c", "echo out").
+			SetExecutor(e).
+			CombinedOutputString()
+
+		lines := make([]string, 0)
+
+		err3 := shellz.NewCommand("sh", "-c", "echo out; echo err >&2").
+			SetEcho(false).
+			SetExecutor(e).
+			Lines(func(line string) { lines = append(lines, line) })
+
+		eErr, ok := errorz.As[*shellz.ExecutionError](err1)
+		g.Expect(ok).To(BeTrue())
+
+		return []any{string(out1), eErr.GetExitCode(), eErr.GetCapturedStderr(), out2, err2, len(lines), err3}
+	}
+
+	re := shellz.NewRecordingExecutor(&shellz.RealExecutor{})
+	recorded := run(re)
+	re.MustSave(cassetteFilePath)
+
+	pe := tshellz.MustLoadReplayExecutor(cassetteFilePath)
+	replayed := run(pe)
+	g.Expect(replayed).To(Equal(recorded))
+	g.Expect(replayed).To(Equal([]any{"", 2, "err\n", "out\n", nil, 2, nil}))
+	g.Expect(pe.CheckDone()).To(Succeed())
+}
@@ -0,0 +1,441 @@
+package shellz
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ibrt/golang-utils/errorz"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+const (
+	newRedirectionFileMode = 0666 // before umask, like for files created by the shell
+)
+
+// fileRedirection describes a redirection of an output stream to a file.
+type fileRedirection struct {
+	filePath string
+	isAppend bool
+}
+
+// SetStdinFile configures the command to read its standard input from the file at "filePath", which takes precedence
+// over the input set with [*Command.SetIn]. An empty path disables the redirection.
+//
+// Like for the other redirections (see [*Command.SetStdoutFile]), relative paths are resolved against the dir of the
+// command, and the redirection is reflected in the echoed command line and in [*Command.String].
+func (c *Command) SetStdinFile(filePath string) *Command {
+	cc := c.clone()
+	cc.stdinFile = filePath
+	return cc
+}
+
+// GetStdinFile returns the path of the file standard input is read from, or an empty string if not redirected.
+func (c *Command) GetStdinFile() string {
+	return c.stdinFile
+}
+
+// SetStdoutFile configures the command to stream its standard output to the file at "filePath", instead of its usual
+// destination (e.g. the terminal, the output returned by [*Command.Output], or the lines passed to [*Command.Lines]).
+// The file is truncated, unless "isAppend" is true. An empty path disables the redirection.
+//
+// Files are written atomically: output is streamed to a temporary file in the same directory, which replaces the
+// target only if the command succeeds (in append mode, the temporary file starts as a copy of the target). Targets that
+// exist but are not regular files (e.g. "/dev/null", "/dev/stderr" or named pipes) are written to directly instead, and
+// symbolic links are followed. New files are created with the usual permissions (i.e. 0666 minus the umask).
+// Relative paths are resolved against the dir of the command, and the parent directory must exist. Redirections are
+// ignored in dry-run mode (see [SetDryRun]), while [*Command.Exec] and pipelines (see [*Pipeline]) fail if any is
+// configured.
+func (c *Command) SetStdoutFile(filePath string, isAppend bool) *Command {
+	cc := c.clone()
+	cc.stdoutFile = newFileRedirection(filePath, isAppend)
+	return cc
+}
+
+// GetStdoutFile returns the path of the file standard output is written to (or an empty string if not redirected),
+// and whether it is appended to.
+func (c *Command) GetStdoutFile() (string, bool) {
+	return c.stdoutFile.get()
+}
+
+// SetStderrFile is like [*Command.SetStdoutFile] but redirects standard error. If it is redirected to the same file as
+// standard output, both streams are written to it (like "2>&1").
+func (c *Command) SetStderrFile(filePath string, isAppend bool) *Command {
+	cc := c.clone()
+	cc.stderrFile = newFileRedirection(filePath, isAppend)
+	return cc
+}
+
+// GetStderrFile returns the path of the file standard error is written to (or an empty string if not redirected), and
+// whether it is appended to.
+func (c *Command) GetStderrFile() (string, bool) {
+	return c.stderrFile.get()
+}
+
+func newFileRedirection(filePath string, isAppend bool) *fileRedirection {
+	if filePath == "" {
+		return nil
+	}
+
+	return &fileRedirection{
+		filePath: filePath,
+		isAppend: isAppend,
+	}
+}
+
+func (r *fileRedirection) get() (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	return r.filePath, r.isAppend
+}
+
+func (r *fileRedirection) getOperator(prefix string) string {
+	if r.isAppend {
+		return prefix + ">>"
+	}
+
+	return prefix + ">"
+}
+
+// hasRedirections returns true if any stream is redirected to a file.
+func (c *Command) hasRedirections() bool {
+	return c.stdinFile != "" || c.stdoutFile != nil || c.stderrFile != nil
+}
+
+// isStderrToStdout returns true if standard error is redirected to the same file as standard output.
+func (c *Command) isStderrToStdout() bool {
+	return c.stdoutFile != nil && c.stderrFile != nil &&
+		filepath.Clean(c.stdoutFile.filePath) == filepath.Clean(c.stderrFile.filePath)
+}
+
+// getRedirections returns the redirections in the order they would be written in a POSIX shell.
+func (c *Command) getRedirections() []*consolez.Redirection {
+	redirections := make([]*consolez.Redirection, 0, 3)
+
+	if c.stdinFile != "" {
+		redirections = append(redirections, &consolez.Redirection{Operator: "<", FilePath: c.redact(c.stdinFile)})
+	}
+
+	if c.stdoutFile != nil {
+		redirections = append(redirections, &consolez.Redirection{
+			Operator: c.stdoutFile.getOperator(""),
+			FilePath: c.redact(c.stdoutFile.filePath),
+		})
+	}
+
+	if c.isStderrToStdout() {
+		redirections = append(redirections, &consolez.Redirection{Operator: "2>&1"})
+	} else if c.stderrFile != nil {
+		redirections = append(redirections, &consolez.Redirection{
+			Operator: c.stderrFile.getOperator("2"),
+			FilePath: c.redact(c.stderrFile.filePath),
+		})
+	}
+
+	return redirections
+}
+
+// resolvePath resolves a relative path against the dir of the command.
+func (c *Command) resolvePath(filePath string) string {
+	if c.dir == "" || filepath.IsAbs(filePath) {
+		return filePath
+	}
+
+	return filepath.Join(c.dir, filePath)
+}
+
+// redirections holds the files opened for the redirections of an execution.
+// A nil *redirections is valid and redirects nothing.
+type redirections struct {
+	stdin  *os.File
+	stdout *atomicFile
+	stderr *atomicFile
+}
+
+// openRedirections opens the files for the redirections of the command. It returns nil if there are none.
+func (c *Command) openRedirections() (*redirections, error) {
+	if !c.hasRedirections() || c.isDryRun() {
+		return nil, nil
+	}
+
+	r := &redirections{}
+
+	if c.stdinFile != "" {
+		f, err := os.Open(c.resolvePath(c.stdinFile))
+		if err != nil {
+			return nil, errorz.Wrap(&redirectionError{err: err})
+		}
+
+		r.stdin = f
+	}
+
+	if c.stdoutFile != nil {
+		f, err := createAtomicFile(c.resolvePath(c.stdoutFile.filePath), c.stdoutFile.isAppend)
+		if err != nil {
+			_ = r.close(err)
+			return nil, errorz.Wrap(&redirectionError{isOutput: true, err: err})
+		}
+
+		r.stdout = f
+	}
+
+	if c.isStderrToStdout() {
+		r.stderr = r.stdout
+	} else if c.stderrFile != nil {
+		f, err := createAtomicFile(c.resolvePath(c.stderrFile.filePath), c.stderrFile.isAppend)
+		if err != nil {
+			_ = r.close(err)
+			return nil, errorz.Wrap(&redirectionError{isOutput: true, err: err})
+		}
+
+		r.stderr = f
+	}
+
+	return r, nil
+}
+
+// redirectionError describes a failure to open the file of a redirection.
+type redirectionError struct {
+	isOutput bool
+	err      error
+}
+
+// Error implements the error interface.
+func (e *redirectionError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *redirectionError) Unwrap() error {
+	return e.err
+}
+
+// isRedirected returns true if the given stream is redirected to a file.
+func (r *redirections) isRedirected(stream Stream) bool {
+	if r == nil {
+		return false
+	}
+
+	if stream == StreamStderr {
+		return r.stderr != nil
+	}
+
+	return r.stdout != nil
+}
+
+// apply connects the redirected streams of "cmd" to their files, overriding their current configuration.
+// Output written to the files is still captured by "capture".
+func (r *redirections) apply(cmd *exec.Cmd, capture *outputCapture) {
+	if r == nil {
+		return
+	}
+
+	if r.stdin != nil {
+		cmd.Stdin = r.stdin
+	}
+
+	if r.stdout != nil {
+		cmd.Stdout = capture.tee(r.stdout.f, StreamStdout)
+	}
+
+	if r.stderr != nil {
+		cmd.Stderr = capture.tee(r.stderr.f, StreamStderr)
+	}
+}
+
+// close closes the files: the output files replace their targets if "err" is nil, and are discarded otherwise.
+// It returns "err", or the error encountered replacing the targets.
+func (r *redirections) close(err error) error {
+	if r == nil {
+		return err
+	}
+
+	if r.stdin != nil {
+		_ = r.stdin.Close()
+	}
+
+	for _, f := range []*atomicFile{r.stdout, r.stderr} {
+		if f == nil {
+			continue
+		}
+
+		if err != nil {
+			f.discard()
+		} else if cErr := f.commit(); cErr != nil {
+			err = cErr
+		}
+	}
+
+	return err
+}
+
+// atomicFile is a temporary file that replaces its target when committed, or the target itself if it is not a regular
+// file (e.g. "/dev/null" or a named pipe).
+type atomicFile struct {
+	f           *os.File
+	filePath    string
+	isDirect    bool
+	isCommitted bool
+	isDiscarded bool
+}
+
+// createAtomicFile creates a temporary file for the given target, which starts as a copy of it if "isAppend" is true.
+// Symbolic links are resolved, so that the file they point to is replaced instead of the link itself. If the target
+// exists but is not a regular file, it is opened for writing instead.
+func createAtomicFile(filePath string, isAppend bool) (*atomicFile, error) {
+	fi, err := os.Lstat(filePath)
+	if err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+		if filePath, err = filepath.EvalSymlinks(filePath); err != nil {
+			return nil, errorz.Wrap(err)
+		}
+
+		fi, err = os.Stat(filePath)
+	}
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, errorz.Wrap(err)
+	}
+
+	if fi != nil && !fi.Mode().IsRegular() {
+		return openDirectFile(filePath, isAppend)
+	}
+
+	f, err := createTempFile(filePath, fi)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	a := &atomicFile{
+		f:        f,
+		filePath: filePath,
+	}
+
+	if isAppend && fi != nil {
+		if err := copyFileTo(f, filePath); err != nil {
+			a.discard()
+			return nil, errorz.Wrap(err)
+		}
+	}
+
+	return a, nil
+}
+
+// createTempFile creates a temporary file in the directory of the given target. It has the same permissions as the
+// target if it exists ("fi" is not nil), or the ones a shell would give to a new file otherwise (honoring the umask).
+func createTempFile(filePath string, fi fs.FileInfo) (*os.File, error) {
+	dirPath, pattern := filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp"
+
+	if fi != nil {
+		f, err := os.CreateTemp(dirPath, pattern)
+		if err != nil {
+			return nil, errorz.Wrap(err)
+		}
+
+		if err := f.Chmod(fi.Mode().Perm()); err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+			return nil, errorz.Wrap(err)
+		}
+
+		return f, nil
+	}
+
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+
+	for range 10000 {
+		tmpFilePath := filepath.Join(dirPath, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+
+		f, err := os.OpenFile(tmpFilePath, os.O_RDWR|os.O_CREATE|os.O_EXCL, newRedirectionFileMode)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		return f, errorz.MaybeWrap(err)
+	}
+
+	return nil, errorz.Wrap(&fs.PathError{Op: "createtemp", Path: filepath.Join(dirPath, pattern), Err: fs.ErrExist})
+}
+
+// openDirectFile opens the given target for writing, for targets that cannot be replaced atomically.
+func openDirectFile(filePath string, isAppend bool) (*atomicFile, error) {
+	flag := os.O_WRONLY
+
+	if isAppend {
+		flag |= os.O_APPEND
+	}
+
+	f, err := os.OpenFile(filePath, flag, 0)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	return &atomicFile{
+		f:        f,
+		filePath: filePath,
+		isDirect: true,
+	}, nil
+}
+
+func copyFileTo(w io.Writer, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = io.Copy(w, f)
+	return errorz.MaybeWrap(err)
+}
+
+// commit closes the temporary file and replaces the target with it.
+// It is a no-op if already done, e.g. because standard output and error share the file.
+func (a *atomicFile) commit() error {
+	if a.isCommitted || a.isDiscarded {
+		return nil
+	}
+
+	a.isCommitted = true
+
+	// The file may have already been closed by an Executor that doesn't start a process.
+	if err := a.f.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		if !a.isDirect {
+			_ = os.Remove(a.f.Name())
+		}
+
+		return errorz.Wrap(err)
+	}
+
+	if a.isDirect {
+		return nil
+	}
+
+	if err := os.Rename(a.f.Name(), a.filePath); err != nil {
+		_ = os.Remove(a.f.Name())
+		return errorz.Wrap(err)
+	}
+
+	return nil
+}
+
+// discard closes and removes the temporary file, leaving the target untouched.
+func (a *atomicFile) discard() {
+	if a.isCommitted || a.isDiscarded {
+		return
+	}
+
+	a.isDiscarded = true
+	_ = a.f.Close()
+
+	if !a.isDirect {
+		_ = os.Remove(a.f.Name())
+	}
+}
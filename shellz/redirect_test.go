@@ -0,0 +1,289 @@
+package shellz_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/consolez"
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type RedirectSuite struct {
+	// intentionally empty
+}
+
+func TestRedirectSuite(t *testing.T) {
+	fixturez.RunSuite(t, &RedirectSuite{})
+}
+
+func listDir(dirPath string) []string {
+	entries, err := os.ReadDir(dirPath)
+	errorz.MaybeMustWrap(err)
+
+	names := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return names
+}
+
+func (*RedirectSuite) TestSetRedirections(g *WithT) {
+	c := shellz.NewCommand("cmd")
+	g.Expect(c.GetStdinFile()).To(BeEmpty())
+	g.Expect(c.GetStdoutFile()).To(BeEmpty())
+	g.Expect(c.GetStderrFile()).To(BeEmpty())
+
+	cc := c.SetStdinFile("in").SetStdoutFile("out", true).SetStderrFile("err", false)
+	g.Expect(cc.GetStdinFile()).To(Equal("in"))
+	g.Expect(c.GetStdoutFile()).To(BeEmpty())
+
+	filePath, isAppend := cc.GetStdoutFile()
+	g.Expect(filePath).To(Equal("out"))
+	g.Expect(isAppend).To(BeTrue())
+
+	filePath, isAppend = cc.GetStderrFile()
+	g.Expect(filePath).To(Equal("err"))
+	g.Expect(isAppend).To(BeFalse())
+
+	cc = cc.SetStdinFile("").SetStdoutFile("", true).SetStderrFile("", false)
+	g.Expect(cc.GetStdinFile()).To(BeEmpty())
+	g.Expect(cc.GetStdoutFile()).To(BeEmpty())
+	g.Expect(cc.GetStderrFile()).To(BeEmpty())
+}
+
+func (*RedirectSuite) TestString(g *WithT) {
+	c := shellz.NewCommand("cmd", "a").SetStdinFile("my in").SetStdoutFile("out", false).SetStderrFile("err", true)
+	g.Expect(c.String()).To(Equal("cmd a < 'my in' > out 2>> err"))
+
+	c = shellz.NewCommand("cmd").SetStdoutFile("out", true).SetStderrFile("./out", false)
+	g.Expect(c.String()).To(Equal("cmd >> out 2>&1"))
+}
+
+func (*RedirectSuite) TestRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	outFilePath := filepath.Join(dirPath, "out.txt")
+	errFilePath := filepath.Join(dirPath, "err.txt")
+
+	c := shellz.NewCommand("sh", "-c", "echo out; echo err >&2")
+	g.Expect(c.SetStdoutFile(outFilePath, false).Run()).To(Succeed())
+	g.Expect(c.SetStdoutFile(outFilePath, true).SetStderrFile(errFilePath, false).Run()).To(Succeed())
+	g.Expect(filez.MustReadFileString(outFilePath)).To(Equal("out\nout\n"))
+	g.Expect(filez.MustReadFileString(errFilePath)).To(Equal("err\n"))
+	g.Expect(listDir(dirPath)).To(ConsistOf("out.txt", "err.txt"))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(
+		fmt.Sprintf("%v sh \x1b[2m-c 'echo out; echo err >&2'\x1b[0m > %v\n", consolez.IconRunner, outFilePath) +
+			fmt.Sprintf("%v sh \x1b[2m-c 'echo out; echo err >&2'\x1b[0m >> %v 2> %v\n", consolez.IconRunner, outFilePath, errFilePath)))
+	g.Expect(errBuf).To(Equal("err\n"))
+}
+
+func (*RedirectSuite) TestRun_Combined(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	filez.MustWriteFileString(filepath.Join(dirPath, "out.txt"), 0777, 0600, "before\n")
+
+	g.Expect(shellz.NewCommand("sh", "-c", "echo out; echo err >&2").
+		SetDir(dirPath).
+		SetEcho(false).
+		SetStdoutFile("out.txt", true).
+		SetStderrFile("out.txt", true).
+		Run()).To(Succeed())
+
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "out.txt"))).To(SatisfyAny(
+		Equal("before\nout\nerr\n"),
+		Equal("before\nerr\nout\n")))
+
+	fi, err := os.Stat(filepath.Join(dirPath, "out.txt"))
+	g.Expect(err).To(Succeed())
+	g.Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0600)))
+}
+
+func (*RedirectSuite) TestRun_Symlink(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	targetFilePath := filez.MustWriteFileString(filepath.Join(dirPath, "target", "out.txt"), 0777, 0600, "before\n")
+	linkFilePath := filepath.Join(dirPath, "out.txt")
+	g.Expect(os.Symlink(filepath.Join("target", "out.txt"), linkFilePath)).To(Succeed())
+
+	c := shellz.NewCommand("echo", "out").SetEcho(false)
+	g.Expect(c.SetStdoutFile(linkFilePath, true).Run()).To(Succeed())
+	g.Expect(c.SetStdoutFile(linkFilePath, true).Run()).To(Succeed())
+
+	fi, err := os.Lstat(linkFilePath)
+	g.Expect(err).To(Succeed())
+	g.Expect(fi.Mode() & os.ModeSymlink).ToNot(BeZero())
+	g.Expect(filez.MustReadFileString(targetFilePath)).To(Equal("before\nout\nout\n"))
+	g.Expect(listDir(dirPath)).To(ConsistOf("out.txt", "target"))
+	g.Expect(listDir(filepath.Join(dirPath, "target"))).To(ConsistOf("out.txt"))
+
+	fi, err = os.Stat(targetFilePath)
+	g.Expect(err).To(Succeed())
+	g.Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0600)))
+}
+
+func (*RedirectSuite) TestRun_Error(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	outFilePath := filepath.Join(dirPath, "out.txt")
+	filez.MustWriteFileString(outFilePath, 0777, 0666, "before\n")
+
+	for _, isAppend := range []bool{false, true} {
+		err := shellz.NewCommand("sh", "-c", "echo out; echo fail >&2; exit 2").
+			SetEcho(false).
+			SetCaptureLimit(shellz.DefaultCaptureLimit).
+			SetStdoutFile(outFilePath, isAppend).
+			SetStderrFile(filepath.Join(dirPath, "err.txt"), isAppend).
+			Run()
+
+		eErr, ok := errorz.As[*shellz.ExecutionError](err)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(eErr.GetExitCode()).To(Equal(2))
+		g.Expect(eErr.GetCapturedStdout()).To(Equal("out\n"))
+		g.Expect(eErr.GetCapturedStderr()).To(Equal("fail\n"))
+		g.Expect(filez.MustReadFileString(outFilePath)).To(Equal("before\n"))
+		g.Expect(listDir(dirPath)).To(ConsistOf("out.txt"))
+	}
+
+	err := shellz.NewCommand("echo").SetEcho(false).SetStdoutFile(filepath.Join(dirPath, "missing", "out.txt"), false).Run()
+	g.Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+
+	err = shellz.NewCommand("cat").SetEcho(false).SetStdoutFile(outFilePath, false).SetStdinFile("missing").Run()
+	g.Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+	g.Expect(listDir(dirPath)).To(ConsistOf("out.txt"))
+}
+
+func (*RedirectSuite) TestRun_DevNull(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	for _, isAppend := range []bool{false, true} {
+		g.Expect(shellz.NewCommand("sh", "-c", "echo out; echo err >&2").
+			SetEcho(false).
+			SetStdoutFile(os.DevNull, isAppend).
+			SetStderrFile(os.DevNull, isAppend).
+			Run()).To(Succeed())
+
+		g.Expect(shellz.NewCommand("sh", "-c", "echo out; exit 1").
+			SetEcho(false).
+			SetStdoutFile(os.DevNull, isAppend).
+			Run()).To(MatchError("execution error: exit status 1"))
+
+		fi, err := os.Stat(os.DevNull)
+		g.Expect(err).To(Succeed())
+		g.Expect(fi.Mode() & os.ModeCharDevice).ToNot(BeZero())
+	}
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(BeEmpty())
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*RedirectSuite) TestStdinFile(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	filez.MustWriteFileString(filepath.Join(dirPath, "in.txt"), 0777, 0666, "in\n")
+
+	c := shellz.NewCommand("cat").SetDir(dirPath).SetStdinFile("in.txt")
+	g.Expect(c.OutputString(false)).To(Equal("in\n"))
+	g.Expect(c.SetStdoutFile("out.txt", false).OutputString(false)).To(BeEmpty())
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "out.txt"))).To(Equal("in\n"))
+}
+
+func (*RedirectSuite) TestOutput(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	c := shellz.NewCommand("sh", "-c", "echo out; echo err >&2").SetDir(dirPath)
+	g.Expect(c.SetStderrFile("err.txt", false).OutputString(true)).To(Equal("out\n"))
+	g.Expect(c.SetStderrFile("err.txt", true).CombinedOutputString()).To(Equal("out\n"))
+	g.Expect(c.SetStdoutFile("out.txt", false).CombinedOutputString()).To(Equal("err\n"))
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "err.txt"))).To(Equal("err\nerr\n"))
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "out.txt"))).To(Equal("out\n"))
+}
+
+func (*RedirectSuite) TestLines(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	events := make([]string, 0)
+
+	g.Expect(shellz.NewCommand("sh", "-c", "echo out; echo err >&2").
+		SetEcho(false).
+		SetDir(dirPath).
+		SetStdoutFile("out.txt", false).
+		LinesEx(nil, func(e *shellz.LineEvent) { events = append(events, fmt.Sprintf("%v: %v", e.Stream, e.Text)) })).
+		To(Succeed())
+
+	g.Expect(events).To(Equal([]string{"stderr: err"}))
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "out.txt"))).To(Equal("out\n"))
+}
+
+func (*RedirectSuite) TestStart(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	p, err := shellz.NewCommand("sh", "-c", "echo out; echo err >&2").
+		SetEcho(false).
+		SetDir(dirPath).
+		SetStderrFile("err.txt", false).
+		Start()
+	g.Expect(err).To(Succeed())
+	g.Expect(p.Wait()).To(Succeed())
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "err.txt"))).To(Equal("err\n"))
+
+	lines := make([]string, 0)
+	p.Subscribe(func(e *shellz.LineEvent) { lines = append(lines, e.Text) })()
+	g.Expect(lines).To(Equal([]string{"out"}))
+
+	p, err = shellz.NewCommand("sh", "-c", "echo out; exit 1").
+		SetEcho(false).
+		SetDir(dirPath).
+		SetStdoutFile("out.txt", false).
+		Start()
+	g.Expect(err).To(Succeed())
+	g.Expect(p.Wait()).ToNot(Succeed())
+	g.Expect(listDir(dirPath)).To(ConsistOf("err.txt"))
+
+	_, err = shellz.NewCommand("missing-1b6f0e").SetEcho(false).SetDir(dirPath).SetStdoutFile("out.txt", false).Start()
+	g.Expect(err).ToNot(Succeed())
+	g.Expect(listDir(dirPath)).To(ConsistOf("err.txt"))
+}
+
+func (*RedirectSuite) TestExec(g *WithT) {
+	err := shellz.NewCommand("echo").SetEcho(false).SetStdoutFile("out.txt", false).Exec()
+	g.Expect(err).To(MatchError("execution error: file redirections are not supported by Exec"))
+}
+
+func (*RedirectSuite) TestDryRun(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	shellz.SetDryRun(true)
+	defer shellz.SetDryRun(false)
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	g.Expect(shellz.NewCommand("echo").SetDir(dirPath).SetStdoutFile("out.txt", false).Run()).To(Succeed())
+	g.Expect(listDir(dirPath)).To(BeEmpty())
+}
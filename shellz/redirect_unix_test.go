@@ -0,0 +1,28 @@
+//go:build unix
+
+package shellz_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ibrt/golang-utils/filez"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+func (*RedirectSuite) TestRun_Umask(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	defer syscall.Umask(syscall.Umask(0027))
+
+	outFilePath := filepath.Join(dirPath, "out.txt")
+	g.Expect(shellz.NewCommand("echo", "out").SetEcho(false).SetStdoutFile(outFilePath, false).Run()).To(Succeed())
+
+	fi, err := os.Stat(outFilePath)
+	g.Expect(err).To(Succeed())
+	g.Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0640)))
+}
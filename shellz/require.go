@@ -0,0 +1,307 @@
+package shellz
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+var (
+	_ error                  = (*RequirementError)(nil)
+	_ consolez.DetailedError = (*RequirementError)(nil)
+)
+
+// DefaultRequirementTimeout is the maximum execution time of the commands run to check a [*Requirement].
+const DefaultRequirementTimeout = 30 * time.Second
+
+var (
+	versionRegexp = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+)
+
+// Requirement describes a tool that must be available for a workflow to run.
+type Requirement struct {
+	// Name identifies the requirement in reports (defaults to Cmd).
+	Name string
+
+	// Cmd is an executable that must be found on PATH (empty means no check).
+	Cmd string
+
+	// MinVersion is the minimum version of Cmd, e.g. "1.23" or "v2.20.1" (empty means no check). The version of Cmd is
+	// the first "X.Y[.Z]" sequence in the combined output of Cmd run with VersionParams.
+	MinVersion string
+
+	// VersionParams are the params that make Cmd print its version (defaults to "--version").
+	VersionParams []string
+
+	// Check is a command that must succeed, e.g. to check that a daemon is reachable (nil means no check).
+	// It is run without echo, and with [DefaultRequirementTimeout] unless it has its own timeout.
+	Check *Command
+
+	// Hint describes how to satisfy the requirement, e.g. how to install a missing tool.
+	Hint string
+}
+
+// GetName returns the name of the requirement.
+func (r *Requirement) GetName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	return r.Cmd
+}
+
+// RequirementResult describes the outcome of checking a [*Requirement].
+type RequirementResult struct {
+	Requirement *Requirement
+	Path        string // the path Cmd was found at, if any
+	Version     string // the version of Cmd, if checked
+	Err         error  // nil if the requirement is satisfied
+}
+
+// IsSatisfied returns true if the requirement is satisfied.
+func (r *RequirementResult) IsSatisfied() bool {
+	return r.Err == nil
+}
+
+// RequirementError describes one or more unsatisfied requirements.
+type RequirementError struct {
+	results []*RequirementResult
+}
+
+// GetResults returns the results of the unsatisfied requirements.
+func (e *RequirementError) GetResults() []*RequirementResult {
+	return e.results
+}
+
+// Error implements the error interface.
+func (e *RequirementError) Error() string {
+	failures := make([]string, 0, len(e.results))
+
+	for _, r := range e.results {
+		failures = append(failures, fmt.Sprintf("%v (%v)", r.Requirement.GetName(), r.Err.Error()))
+	}
+
+	return "unsatisfied requirements: " + strings.Join(failures, ", ")
+}
+
+// GetSummary implements the [consolez.DetailedError] interface.
+func (e *RequirementError) GetSummary() string {
+	if len(e.results) == 1 {
+		return fmt.Sprintf("requirement %q is not satisfied", e.results[0].Requirement.GetName())
+	}
+
+	return fmt.Sprintf("%v requirements are not satisfied", len(e.results))
+}
+
+// GetDetails implements the [consolez.DetailedError] interface.
+func (e *RequirementError) GetDetails() []*consolez.ErrorDetail {
+	details := make([]*consolez.ErrorDetail, 0, len(e.results))
+
+	for _, r := range e.results {
+		value := r.Err.Error()
+
+		if r.Requirement.Hint != "" {
+			value += "\n" + r.Requirement.Hint
+		}
+
+		details = append(details, &consolez.ErrorDetail{Label: r.Requirement.GetName(), Value: value})
+	}
+
+	return details
+}
+
+// CheckRequirements checks the given requirements in order, and returns a result for each of them.
+func CheckRequirements(requirements ...*Requirement) []*RequirementResult {
+	results := make([]*RequirementResult, 0, len(requirements))
+
+	for _, r := range requirements {
+		results = append(results, r.check())
+	}
+
+	return results
+}
+
+// Require checks the given requirements, and returns a [*RequirementError] if any of them is not satisfied.
+func Require(requirements ...*Requirement) error {
+	if err := newRequirementError(CheckRequirements(requirements...)); err != nil {
+		return errorz.Wrap(err)
+	}
+
+	return nil
+}
+
+// MustRequire is like [Require] but panics on error.
+func MustRequire(requirements ...*Requirement) {
+	errorz.MaybeMustWrap(Require(requirements...))
+}
+
+// Doctor is like [Require], but also prints a table with the outcome of each check, including hints on how to
+// satisfy the failing ones.
+func Doctor(requirements ...*Requirement) error {
+	results := CheckRequirements(requirements...)
+	t := consolez.DefaultCLI.NewTable("Requirement", "Status", "Version", "Error", "Hint")
+
+	for _, r := range results {
+		version := r.Version
+
+		if version == "" {
+			version = "-"
+		}
+
+		if r.IsSatisfied() {
+			t.AddRow(r.Requirement.GetName(), "pass", version, "-", "-")
+		} else {
+			t.AddRow(r.Requirement.GetName(), "fail", version, r.Err.Error(), orDash(r.Requirement.Hint))
+		}
+	}
+
+	t.Print()
+
+	if err := newRequirementError(results); err != nil {
+		return errorz.Wrap(err)
+	}
+
+	return nil
+}
+
+// MustDoctor is like [Doctor] but panics on error.
+func MustDoctor(requirements ...*Requirement) {
+	errorz.MaybeMustWrap(Doctor(requirements...))
+}
+
+func newRequirementError(results []*RequirementResult) *RequirementError {
+	failed := make([]*RequirementResult, 0)
+
+	for _, r := range results {
+		if !r.IsSatisfied() {
+			failed = append(failed, r)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return &RequirementError{results: failed}
+}
+
+func (r *Requirement) check() *RequirementResult {
+	res := &RequirementResult{Requirement: r}
+
+	if r.Cmd != "" {
+		c := NewCommand(r.Cmd, r.getVersionParams()...).SetEcho(false).SetTimeout(DefaultRequirementTimeout)
+
+		path, err := c.getExecutor().ExecLookPath(c, r.Cmd)
+		if err != nil {
+			res.Err = errorz.Errorf("executable %q not found on PATH", r.Cmd)
+			return res
+		}
+
+		res.Path = path
+
+		if r.MinVersion != "" {
+			if res.Err = r.checkVersion(res, c); res.Err != nil {
+				return res
+			}
+		}
+	}
+
+	if r.Check != nil {
+		c := r.Check.SetEcho(false)
+
+		if c.GetTimeout() <= 0 {
+			c = c.SetTimeout(DefaultRequirementTimeout)
+		}
+
+		if _, err := c.CombinedOutput(); err != nil {
+			res.Err = errorz.Errorf("check failed: %v", getErrorSummary(err))
+			return res
+		}
+	}
+
+	return res
+}
+
+func (r *Requirement) checkVersion(res *RequirementResult, c *Command) error {
+	minVersion, ok := parseVersion(r.MinVersion)
+	if !ok {
+		return errorz.Errorf("invalid minimum version %q", r.MinVersion)
+	}
+
+	out, err := c.CombinedOutputString()
+	if err != nil {
+		return errorz.Errorf("cannot determine version: %v", getErrorSummary(err))
+	}
+
+	version, ok := parseVersion(out)
+	if !ok {
+		return errorz.Errorf("cannot determine version from %q", strings.TrimSpace(out))
+	}
+
+	res.Version = formatVersion(version)
+
+	if slices.Compare(version[:], minVersion[:]) < 0 {
+		return errorz.Errorf("version %v is older than %v", res.Version, formatVersion(minVersion))
+	}
+
+	return nil
+}
+
+func (r *Requirement) getVersionParams() []string {
+	if len(r.VersionParams) == 0 {
+		return []string{"--version"}
+	}
+
+	return r.VersionParams
+}
+
+// getErrorSummary returns the summary of an [*ExecutionError], or the message of any other error.
+func getErrorSummary(err error) string {
+	if eErr, ok := errorz.As[*ExecutionError](err); ok {
+		return eErr.GetSummary()
+	}
+
+	return err.Error()
+}
+
+// parseVersion returns the components of the first "X.Y[.Z]" sequence in "s".
+func parseVersion(s string) ([3]int, bool) {
+	var version [3]int
+
+	m := versionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return version, false
+	}
+
+	for i, p := range m[1:] {
+		if p != "" {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return version, false
+			}
+
+			version[i] = n
+		}
+	}
+
+	return version, true
+}
+
+func formatVersion(version [3]int) string {
+	return fmt.Sprintf("%v.%v.%v", version[0], version[1], version[2])
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,124 @@
+package shellz_test
+
+import (
+	"testing"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/consolez"
+	"github.com/ibrt/golang-dev/shellz"
+	"github.com/ibrt/golang-dev/shellz/tshellz"
+)
+
+type RequireSuite struct {
+	// intentionally empty
+}
+
+func TestRequireSuite(t *testing.T) {
+	fixturez.RunSuite(t, &RequireSuite{})
+}
+
+func newRequireFakeExecutor() *tshellz.FakeExecutor {
+	e := tshellz.NewFakeExecutor()
+	e.On("go", "version").SetStdout("go version go1.22.1 linux/amd64\n")
+	e.On("git", "--version").SetStdout("git version 2.39\n")
+	e.On("tool", "--version").SetStdout("unknown\n")
+	e.On("broken", "--version").SetExitCode(1).SetStderr("boom\n")
+	e.On("daemon", "ping").SetExitCode(2)
+	return e
+}
+
+func (*RequireSuite) TestCheckRequirements(g *WithT) {
+	shellz.DefaultExecutor = newRequireFakeExecutor()
+	defer shellz.RestoreDefaultExecutor()
+
+	results := shellz.CheckRequirements(
+		&shellz.Requirement{Cmd: "go", MinVersion: "go1.22", VersionParams: []string{"version"}},
+		&shellz.Requirement{Cmd: "go", MinVersion: "1.22.2", VersionParams: []string{"version"}},
+		&shellz.Requirement{Cmd: "git", MinVersion: "v2.39.0"},
+		&shellz.Requirement{Cmd: "git", MinVersion: "latest"},
+		&shellz.Requirement{Cmd: "tool", MinVersion: "1.0"},
+		&shellz.Requirement{Cmd: "broken", MinVersion: "1.0"},
+		&shellz.Requirement{Name: "daemon", Check: shellz.NewCommand("daemon", "ping")},
+		&shellz.Requirement{Cmd: "tool"})
+
+	g.Expect(results).To(HaveLen(8))
+
+	g.Expect(results[0].IsSatisfied()).To(BeTrue())
+	g.Expect(results[0].Path).To(Equal("go"))
+	g.Expect(results[0].Version).To(Equal("1.22.1"))
+
+	g.Expect(results[1].Err).To(MatchError("version 1.22.1 is older than 1.22.2"))
+	g.Expect(results[1].Version).To(Equal("1.22.1"))
+
+	g.Expect(results[2].IsSatisfied()).To(BeTrue())
+	g.Expect(results[2].Version).To(Equal("2.39.0"))
+
+	g.Expect(results[3].Err).To(MatchError(`invalid minimum version "latest"`))
+	g.Expect(results[4].Err).To(MatchError(`cannot determine version from "unknown"`))
+	g.Expect(results[5].Err).To(MatchError(`cannot determine version: "broken" exited with code 1`))
+
+	g.Expect(results[6].Err).To(MatchError(`check failed: "daemon ping" exited with code 2`))
+	g.Expect(results[6].Requirement.GetName()).To(Equal("daemon"))
+
+	g.Expect(results[7].IsSatisfied()).To(BeTrue())
+	g.Expect(results[7].Version).To(BeEmpty())
+	g.Expect(results[7].Requirement.GetName()).To(Equal("tool"))
+}
+
+func (*RequireSuite) TestRequire(g *WithT) {
+	g.Expect(shellz.Require(&shellz.Requirement{Cmd: "sh"})).To(Succeed())
+	g.Expect(func() { shellz.MustRequire(&shellz.Requirement{Cmd: "sh"}) }).ToNot(Panic())
+
+	err := shellz.Require(
+		&shellz.Requirement{Cmd: "sh"},
+		&shellz.Requirement{Cmd: "missing-5c0e1a", Hint: "install it"})
+	g.Expect(err).To(MatchError(`unsatisfied requirements: missing-5c0e1a (executable "missing-5c0e1a" not found on PATH)`))
+
+	rErr, ok := errorz.As[*shellz.RequirementError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(rErr.GetResults()).To(HaveLen(1))
+	g.Expect(rErr.GetSummary()).To(Equal(`requirement "missing-5c0e1a" is not satisfied`))
+	g.Expect(rErr.GetDetails()).To(Equal([]*consolez.ErrorDetail{
+		{Label: "missing-5c0e1a", Value: "executable \"missing-5c0e1a\" not found on PATH\ninstall it"},
+	}))
+
+	err = shellz.Require(
+		&shellz.Requirement{Cmd: "missing-5c0e1a"},
+		&shellz.Requirement{Name: "other", Cmd: "missing-a0c7e2"})
+	rErr, _ = errorz.As[*shellz.RequirementError](err)
+	g.Expect(rErr.GetSummary()).To(Equal("2 requirements are not satisfied"))
+	g.Expect(rErr.GetDetails()[1].Label).To(Equal("other"))
+
+	g.Expect(func() { shellz.MustRequire(&shellz.Requirement{Cmd: "missing-5c0e1a"}) }).
+		To(PanicWith(MatchError(ContainSubstring("unsatisfied requirements"))))
+}
+
+func (*RequireSuite) TestDoctor(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(false), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	shellz.DefaultExecutor = newRequireFakeExecutor()
+	defer shellz.RestoreDefaultExecutor()
+
+	g.Expect(shellz.Doctor(
+		&shellz.Requirement{Cmd: "go", MinVersion: "1.22", VersionParams: []string{"version"}},
+		&shellz.Requirement{Cmd: "git"})).To(Succeed())
+
+	err := shellz.Doctor(
+		&shellz.Requirement{Cmd: "go", MinVersion: "1.23", VersionParams: []string{"version"}, Hint: "upgrade Go"},
+		&shellz.Requirement{Name: "daemon", Check: shellz.NewCommand("daemon", "ping")})
+	g.Expect(err).To(MatchError(ContainSubstring("unsatisfied requirements: go (version 1.22.1 is older than 1.23.0)")))
+
+	g.Expect(func() { shellz.MustDoctor(&shellz.Requirement{Cmd: "git"}) }).ToNot(Panic())
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(MatchRegexp(`go +\x1b\[0mpass +1\.22\.1 +- +-`))
+	g.Expect(outBuf).To(MatchRegexp(`git +\x1b\[0mpass +- +- +-`))
+	g.Expect(outBuf).To(MatchRegexp(`go +\x1b\[0mfail +1\.22\.1 +version 1\.22\.1 is older than 1\.23\.0 +upgrade Go`))
+	g.Expect(outBuf).To(MatchRegexp(`daemon +\x1b\[0mfail +- +check failed: "daemon ping" exited with code 2 +-`))
+	g.Expect(errBuf).To(BeEmpty())
+}
@@ -0,0 +1,302 @@
+package shellz
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+)
+
+var (
+	outOfMemoryMarkers = []string{
+		"out of memory",
+		"cannot allocate memory",
+		"memory exhausted",
+		"bad_alloc",
+		"memoryerror",
+	}
+)
+
+// ResourceUsage describes the resources used by an execution. It is reported by [*Command.RunWithUsage],
+// [*Process.GetResourceUsage], [*ExecutionError.GetResourceUsage] and to observers (see [ExitEvent]).
+type ResourceUsage struct {
+	// WallTime is the elapsed real time.
+	WallTime time.Duration
+
+	// UserTime is the CPU time spent in user mode.
+	UserTime time.Duration
+
+	// SystemTime is the CPU time spent in kernel mode.
+	SystemTime time.Duration
+
+	// PeakRSS is the peak resident set size in bytes (-1 if not available on this platform).
+	PeakRSS int64
+}
+
+// String implements the [fmt.Stringer] interface.
+func (u *ResourceUsage) String() string {
+	peakRSS := "-"
+
+	if u.PeakRSS >= 0 {
+		peakRSS = formatBytes(u.PeakRSS)
+	}
+
+	return fmt.Sprintf("wall %v, user %v, sys %v, peak rss %v",
+		u.WallTime.Round(time.Millisecond),
+		u.UserTime.Round(time.Millisecond),
+		u.SystemTime.Round(time.Millisecond),
+		peakRSS)
+}
+
+// newResourceUsage returns the resources used by "cmd", which started at "startTime".
+// It returns nil if no process was run, e.g. because the command failed to start or used a fake [Executor].
+func newResourceUsage(cmd *exec.Cmd, startTime time.Time) *ResourceUsage {
+	if cmd.ProcessState == nil {
+		return nil
+	}
+
+	return &ResourceUsage{
+		WallTime:   time.Since(startTime),
+		UserTime:   cmd.ProcessState.UserTime(),
+		SystemTime: cmd.ProcessState.SystemTime(),
+		PeakRSS:    getPeakRSS(cmd.ProcessState),
+	}
+}
+
+// RunWithUsage is like [*Command.Run], but also returns the resources used by the command, whether it succeeded or not.
+// The usage is nil if not available (e.g. if the command failed to start), and describes the last attempt if the
+// command is retried (see [*Command.SetRetry]).
+func (c *Command) RunWithUsage() (*ResourceUsage, error) {
+	return c.RunWithUsageContext(context.Background())
+}
+
+// MustRunWithUsage is like [*Command.RunWithUsage] but panics on error.
+func (c *Command) MustRunWithUsage() *ResourceUsage {
+	usage, err := c.RunWithUsage()
+	errorz.MaybeMustWrap(err)
+	return usage
+}
+
+// RunWithUsageContext is like [*Command.RunWithUsage] but terminates the command when the context is done.
+func (c *Command) RunWithUsageContext(ctx context.Context) (*ResourceUsage, error) {
+	o := &usageObserver{}
+	err := c.AddObserver(o).RunContext(ctx)
+	return o.usage.Load(), err
+}
+
+// usageObserver records the resource usage of the last execution it observes.
+type usageObserver struct {
+	usage atomic.Pointer[ResourceUsage]
+}
+
+// OnStart implements the [Observer] interface.
+func (*usageObserver) OnStart(*StartEvent) {
+	// intentionally empty
+}
+
+// OnExit implements the [Observer] interface.
+func (o *usageObserver) OnExit(e *ExitEvent) {
+	o.usage.Store(e.ResourceUsage)
+}
+
+// ResourceLimits describes limits on the resources used by a command. Zero values mean no limit.
+//
+// The address space and CPU time limits and the niceness are applied by running the command through "/bin/sh" (using
+// "ulimit" and "nice"), and are only supported on Unix platforms: elsewhere they are ignored.
+type ResourceLimits struct {
+	// MaxAddressSpace is the maximum size of the virtual memory of the process in bytes (RLIMIT_AS).
+	// Exceeding it makes memory allocations fail, which most programs report as a generic failure: it is reported as a
+	// [*LimitError] only if the captured standard error mentions running out of memory (see [*Command.SetCaptureLimit]).
+	MaxAddressSpace int64
+
+	// MaxCPUTime is the maximum CPU time of the process, rounded up to the second (RLIMIT_CPU).
+	MaxCPUTime time.Duration
+
+	// Niceness is added to the scheduling niceness of the process (negative values usually require privileges).
+	Niceness int
+
+	// MaxOutputBytes is the maximum number of bytes written to standard output and error combined. The command is
+	// terminated as soon as it is exceeded, except for [*Command.Output] and [*Command.CombinedOutput], which can only
+	// check standard output when the command exits. It is not supported by [*Pipeline] stages. Like
+	// [*Command.SetCaptureLimit], it makes [*Command.Run] write to the terminal through a pipe.
+	MaxOutputBytes int64
+}
+
+// isWrapped returns true if the limits require running the command through "/bin/sh".
+func (l *ResourceLimits) isWrapped() bool {
+	return l != nil && (l.MaxAddressSpace > 0 || l.MaxCPUTime > 0 || l.Niceness != 0)
+}
+
+// getWrapperScript returns a "/bin/sh" script that applies the limits and executes its arguments.
+func (l *ResourceLimits) getWrapperScript() string {
+	parts := make([]string, 0, 4)
+
+	if l.MaxAddressSpace > 0 {
+		parts = append(parts, fmt.Sprintf("ulimit -v %v", (l.MaxAddressSpace+1023)/1024))
+	}
+
+	if l.MaxCPUTime > 0 {
+		// The soft limit sends SIGXCPU, the hard limit (one second later) SIGKILL in case SIGXCPU is ignored.
+		seconds := getCPUTimeLimitSeconds(l.MaxCPUTime)
+		parts = append(parts, fmt.Sprintf("ulimit -S -t %v", seconds), fmt.Sprintf("ulimit -H -t %v", seconds+1))
+	}
+
+	if l.Niceness != 0 {
+		parts = append(parts, fmt.Sprintf(`exec nice -n %v "$@"`, l.Niceness))
+	} else {
+		parts = append(parts, `exec "$@"`)
+	}
+
+	return strings.Join(parts, " && ")
+}
+
+// SetResourceLimits sets the resource limits (nil to disable them).
+func (c *Command) SetResourceLimits(limits *ResourceLimits) *Command {
+	cc := c.clone()
+	cc.resourceLimits = limits
+	return cc
+}
+
+// GetResourceLimits returns the current resource limits.
+func (c *Command) GetResourceLimits() *ResourceLimits {
+	return c.resourceLimits
+}
+
+// getMaxOutputBytes returns the maximum number of output bytes, or 0 if not limited.
+func (c *Command) getMaxOutputBytes() int64 {
+	if c.resourceLimits == nil {
+		return 0
+	}
+
+	return max(c.resourceLimits.MaxOutputBytes, 0)
+}
+
+// maybeWrapResourceLimits rewrites "cmd" to apply the resource limits of the command, if any.
+// Commands that cannot be resolved are left alone, so that they fail to start as usual.
+func (c *Command) maybeWrapResourceLimits(cmd *exec.Cmd) {
+	if !c.resourceLimits.isWrapped() || cmd.Err != nil || !isResourceLimitWrapperSupported {
+		return
+	}
+
+	cmd.Args = append([]string{"sh", "-c", c.resourceLimits.getWrapperScript(), "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+}
+
+// checkLimits returns a [*LimitError] wrapping "err" (which can be nil) if the execution exceeded a resource limit,
+// or "err" otherwise.
+func (c *Command) checkLimits(cmd *exec.Cmd, capture *outputCapture, err error) error {
+	if c.resourceLimits == nil {
+		return err
+	}
+
+	switch {
+	case capture.isOutputLimitExceeded():
+		return &LimitError{kind: LimitKindOutputBytes, limit: formatBytes(c.resourceLimits.MaxOutputBytes), err: err}
+	case isCPUTimeLimitExceeded(cmd.ProcessState, c.resourceLimits.MaxCPUTime):
+		return &LimitError{kind: LimitKindCPUTime, limit: fmt.Sprintf("%vs", getCPUTimeLimitSeconds(c.resourceLimits.MaxCPUTime)), err: err}
+	case err != nil && c.resourceLimits.MaxAddressSpace > 0 && isOutOfMemory(capture.getStderr()):
+		return &LimitError{kind: LimitKindAddressSpace, limit: formatBytes(c.resourceLimits.MaxAddressSpace), err: err}
+	default:
+		return err
+	}
+}
+
+// LimitKind describes a kind of resource limit.
+type LimitKind int
+
+// Known limit kinds.
+const (
+	// LimitKindAddressSpace is the address space limit (see [ResourceLimits.MaxAddressSpace]).
+	LimitKindAddressSpace LimitKind = iota
+
+	// LimitKindCPUTime is the CPU time limit (see [ResourceLimits.MaxCPUTime]).
+	LimitKindCPUTime
+
+	// LimitKindOutputBytes is the output limit (see [ResourceLimits.MaxOutputBytes]).
+	LimitKindOutputBytes
+)
+
+// String implements the [fmt.Stringer] interface.
+func (k LimitKind) String() string {
+	switch k {
+	case LimitKindAddressSpace:
+		return "address space"
+	case LimitKindCPUTime:
+		return "cpu time"
+	case LimitKindOutputBytes:
+		return "output bytes"
+	default:
+		return fmt.Sprintf("LimitKind(%d)", int(k))
+	}
+}
+
+var (
+	_ error               = (*LimitError)(nil)
+	_ errorz.UnwrapSingle = (*LimitError)(nil)
+)
+
+// LimitError describes an execution that exceeded a resource limit (see [*Command.SetResourceLimits]).
+// It is wrapped by the returned [*ExecutionError], so it can be retrieved with [errors.As].
+type LimitError struct {
+	kind  LimitKind
+	limit string
+	err   error
+}
+
+// GetKind returns the kind of limit that was exceeded.
+func (e *LimitError) GetKind() LimitKind {
+	return e.kind
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	msg := fmt.Sprintf("%v limit exceeded (%v)", e.kind, e.limit)
+
+	if e.err != nil {
+		msg += ": " + e.err.Error()
+	}
+
+	return msg
+}
+
+// Unwrap implements the [errorz.UnwrapSingle] interface, returning the originating error (if any).
+func (e *LimitError) Unwrap() error {
+	return e.err
+}
+
+func getCPUTimeLimitSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+func isOutOfMemory(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+
+	for _, marker := range outOfMemoryMarkers {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%v B", n)
+	}
+
+	div, exp := int64(unit), 0
+
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,232 @@
+package shellz_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type ResourceSuite struct {
+	// intentionally empty
+}
+
+func TestResourceSuite(t *testing.T) {
+	fixturez.RunSuite(t, &ResourceSuite{})
+}
+
+func (*ResourceSuite) TestResourceUsage(g *WithT) {
+	o := newTestObserver()
+	shellz.NewCommand("sh", "-c", "sleep 0.1").SetEcho(false).AddObserver(o).MustRun()
+
+	g.Expect(o.exits).To(HaveLen(1))
+	u := o.exits[0].ResourceUsage
+	g.Expect(u).ToNot(BeNil())
+	g.Expect(u.WallTime).To(BeNumerically(">=", 100*time.Millisecond))
+	g.Expect(u.UserTime).To(BeNumerically(">=", 0))
+	g.Expect(u.SystemTime).To(BeNumerically(">=", 0))
+	g.Expect(u.PeakRSS).To(BeNumerically(">", 0))
+	g.Expect(u.String()).To(MatchRegexp(`^wall \S+, user \S+, sys \S+, peak rss \d+\.\d [KMG]iB$`))
+
+	err := shellz.NewCommand("sh", "-c", "exit 2").SetEcho(false).Run()
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetResourceUsage()).ToNot(BeNil())
+	g.Expect(eErr.GetResourceUsage().PeakRSS).To(BeNumerically(">", 0))
+
+	err = shellz.NewCommand("shellz-not-found").SetEcho(false).Run()
+	eErr, ok = errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetResourceUsage()).To(BeNil())
+}
+
+func (*ResourceSuite) TestRunWithUsage(g *WithT) {
+	u := shellz.NewCommand("sh", "-c", "sleep 0.1").SetEcho(false).MustRunWithUsage()
+	g.Expect(u).ToNot(BeNil())
+	g.Expect(u.WallTime).To(BeNumerically(">=", 100*time.Millisecond))
+	g.Expect(u.PeakRSS).To(BeNumerically(">", 0))
+
+	u, err := shellz.NewCommand("sh", "-c", "exit 2").SetEcho(false).RunWithUsage()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(u).ToNot(BeNil())
+
+	u, err = shellz.NewCommand("shellz-not-found").SetEcho(false).RunWithUsage()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(u).To(BeNil())
+}
+
+func (*ResourceSuite) TestResourceUsage_String(g *WithT) {
+	u := &shellz.ResourceUsage{
+		WallTime:   1500 * time.Millisecond,
+		UserTime:   time.Second,
+		SystemTime: 250 * time.Millisecond,
+		PeakRSS:    3 * 1024 * 1024,
+	}
+
+	g.Expect(u.String()).To(Equal("wall 1.5s, user 1s, sys 250ms, peak rss 3.0 MiB"))
+	u.PeakRSS = -1
+	g.Expect(u.String()).To(Equal("wall 1.5s, user 1s, sys 250ms, peak rss -"))
+}
+
+func (*ResourceSuite) TestResourceUsage_Process(g *WithT) {
+	p := shellz.NewCommand("sleep", "0.1").SetEcho(false).MustStart()
+	g.Expect(p.GetResourceUsage()).To(BeNil())
+	p.MustWait()
+	g.Expect(p.GetResourceUsage()).ToNot(BeNil())
+	g.Expect(p.GetResourceUsage().WallTime).To(BeNumerically(">=", 100*time.Millisecond))
+}
+
+func (*ResourceSuite) TestSetResourceLimits(g *WithT) {
+	c := shellz.NewCommand("true")
+	g.Expect(c.GetResourceLimits()).To(BeNil())
+
+	limits := &shellz.ResourceLimits{MaxOutputBytes: 10}
+	cc := c.SetResourceLimits(limits)
+	g.Expect(c.GetResourceLimits()).To(BeNil())
+	g.Expect(cc.GetResourceLimits()).To(BeIdenticalTo(limits))
+	g.Expect(cc.SetEcho(false).GetResourceLimits()).To(BeIdenticalTo(limits))
+}
+
+func (*ResourceSuite) TestLimits_AddressSpace(g *WithT) {
+	out := shellz.NewCommand("sh", "-c", "ulimit -v").
+		SetResourceLimits(&shellz.ResourceLimits{MaxAddressSpace: 512*1024*1024 + 1}).
+		SetEcho(false).
+		MustOutputString(false)
+	g.Expect(strings.TrimSpace(out)).To(Equal("524289"))
+
+	err := shellz.NewCommand("sh", "-c", "echo 'fatal error: out of memory' >&2; exit 2").
+		SetResourceLimits(&shellz.ResourceLimits{MaxAddressSpace: 512 * 1024 * 1024}).
+		SetEcho(false).
+		Run()
+	g.Expect(err).ToNot(Succeed())
+	g.Expect(errors.As(err, new(*shellz.LimitError))).To(BeFalse())
+
+	err = shellz.NewCommand("sh", "-c", "echo 'fatal error: out of memory' >&2; exit 2").
+		SetResourceLimits(&shellz.ResourceLimits{MaxAddressSpace: 512 * 1024 * 1024}).
+		SetCaptureLimit(shellz.DefaultCaptureLimit).
+		SetEcho(false).
+		Run()
+	g.Expect(err).To(MatchError("execution error: address space limit exceeded (512.0 MiB): exit status 2"))
+
+	lErr, ok := errorz.As[*shellz.LimitError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(lErr.GetKind()).To(Equal(shellz.LimitKindAddressSpace))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetExitCode()).To(Equal(2))
+}
+
+func (*ResourceSuite) TestLimits_CPUTime(g *WithT) {
+	err := shellz.NewCommand("sh", "-c", "while :; do :; done").
+		SetResourceLimits(&shellz.ResourceLimits{MaxCPUTime: 500 * time.Millisecond}).
+		SetTimeout(10 * time.Second).
+		SetEcho(false).
+		Run()
+	g.Expect(err).To(MatchError(MatchRegexp(`^execution error: cpu time limit exceeded \(1s\): signal: (CPU time limit exceeded|killed)$`)))
+
+	lErr, ok := errorz.As[*shellz.LimitError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(lErr.GetKind()).To(Equal(shellz.LimitKindCPUTime))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.IsTimedOut()).To(BeFalse())
+	g.Expect(eErr.GetResourceUsage().UserTime + eErr.GetResourceUsage().SystemTime).To(BeNumerically(">=", 900*time.Millisecond))
+}
+
+func (*ResourceSuite) TestLimits_Niceness(g *WithT) {
+	base := strings.TrimSpace(shellz.NewCommand("nice").SetEcho(false).MustOutputString(false))
+	g.Expect(base).To(Equal("0"))
+
+	out := shellz.NewCommand("nice").
+		SetResourceLimits(&shellz.ResourceLimits{Niceness: 5}).
+		SetEcho(false).
+		MustOutputString(false)
+	g.Expect(strings.TrimSpace(out)).To(Equal("5"))
+}
+
+func (*ResourceSuite) TestLimits_NotFound(g *WithT) {
+	err := shellz.NewCommand("shellz-not-found").
+		SetResourceLimits(&shellz.ResourceLimits{Niceness: 5}).
+		SetEcho(false).
+		Run()
+	g.Expect(err).To(MatchError(`execution error: exec: "shellz-not-found": executable file not found in $PATH`))
+}
+
+func (*ResourceSuite) TestLimits_OutputBytes(g *WithT) {
+	lines := 0
+
+	err := shellz.NewCommand("yes").
+		SetResourceLimits(&shellz.ResourceLimits{MaxOutputBytes: 1000}).
+		SetEcho(false).
+		Lines(func(string) { lines++ })
+	g.Expect(err).To(MatchError("execution error: output bytes limit exceeded (1000 B): signal: terminated"))
+	g.Expect(lines).To(BeNumerically(">", 0))
+
+	lErr, ok := errorz.As[*shellz.LimitError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(lErr.GetKind()).To(Equal(shellz.LimitKindOutputBytes))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.IsCanceled()).To(BeFalse())
+}
+
+func (*ResourceSuite) TestLimits_OutputBytes_Run(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	err := shellz.NewCommand("sh", "-c", "echo 12345; echo 67890 >&2; sleep 5").
+		SetResourceLimits(&shellz.ResourceLimits{MaxOutputBytes: 8}).
+		SetEcho(false).
+		Run()
+	g.Expect(err).To(MatchError("execution error: output bytes limit exceeded (8 B): signal: terminated"))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("12345\n"))
+	g.Expect(errBuf).To(Equal("67890\n"))
+}
+
+func (*ResourceSuite) TestLimits_OutputBytes_Output(g *WithT) {
+	out, err := shellz.NewCommand("echo", "0123456789").
+		SetResourceLimits(&shellz.ResourceLimits{MaxOutputBytes: 10}).
+		SetEcho(false).
+		Output(false)
+	g.Expect(err).To(MatchError("execution error: output bytes limit exceeded (10 B)"))
+	g.Expect(out).To(BeNil())
+
+	out, err = shellz.NewCommand("echo", "012345678").
+		SetResourceLimits(&shellz.ResourceLimits{MaxOutputBytes: 10}).
+		SetEcho(false).
+		Output(false)
+	g.Expect(err).To(Succeed())
+	g.Expect(string(out)).To(Equal("012345678\n"))
+}
+
+func (*ResourceSuite) TestLimits_OutputBytes_Start(g *WithT) {
+	p := shellz.NewCommand("yes").
+		SetResourceLimits(&shellz.ResourceLimits{MaxOutputBytes: 100}).
+		SetEcho(false).
+		MustStart()
+
+	err := p.Wait()
+	lErr, ok := errorz.As[*shellz.LimitError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(lErr.GetKind()).To(Equal(shellz.LimitKindOutputBytes))
+	g.Expect(p.GetResourceUsage()).ToNot(BeNil())
+}
+
+func (*ResourceSuite) TestLimitKind_String(g *WithT) {
+	g.Expect(shellz.LimitKindAddressSpace.String()).To(Equal("address space"))
+	g.Expect(shellz.LimitKindCPUTime.String()).To(Equal("cpu time"))
+	g.Expect(shellz.LimitKindOutputBytes.String()).To(Equal("output bytes"))
+	g.Expect(shellz.LimitKind(99).String()).To(Equal("LimitKind(99)"))
+}
@@ -0,0 +1,194 @@
+package shellz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+
+	"github.com/ibrt/golang-dev/consolez"
+)
+
+// RetryPolicy describes how a failing command is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the second attempt.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts (zero means no cap).
+	MaxBackoff time.Duration
+
+	// Multiplier is applied to the delay after each attempt (values lower than 1 are treated as 1).
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to the given fraction of its value (e.g. 0.2 means ±20%).
+	Jitter float64
+
+	// IsRetryable decides whether a failed attempt should be retried (nil means all failures are retryable).
+	IsRetryable func(err *ExecutionError) bool
+}
+
+// NewRetryPolicy initializes a new [*RetryPolicy] with exponential backoff and sensible defaults.
+func NewRetryPolicy(maxAttempts int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		IsRetryable:    nil,
+	}
+}
+
+// GetBackoff returns the delay to apply after the given (one-based) failed attempt.
+func (p *RetryPolicy) GetBackoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(math.Max(p.Multiplier, 1), float64(max(attempt-1, 0)))
+
+	if p.MaxBackoff > 0 {
+		backoff = math.Min(backoff, float64(p.MaxBackoff))
+	}
+
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(math.Max(backoff, 0))
+}
+
+func (p *RetryPolicy) isRetryable(err *ExecutionError) bool {
+	return p.IsRetryable == nil || p.IsRetryable(err)
+}
+
+// RetryOnExitCodes returns a retry predicate that matches any of the given exit codes.
+func RetryOnExitCodes(exitCodes ...int) func(err *ExecutionError) bool {
+	return func(err *ExecutionError) bool {
+		for _, exitCode := range exitCodes {
+			if err.GetExitCode() == exitCode {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// RetryOnStderrContains returns a retry predicate that matches if the captured standard error contains any of the
+// given substrings. Note that standard error is only captured by some execution modes.
+func RetryOnStderrContains(substrings ...string) func(err *ExecutionError) bool {
+	return func(err *ExecutionError) bool {
+		for _, substring := range substrings {
+			if strings.Contains(err.GetCapturedStderr(), substring) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+var (
+	_ error               = (*RetryError)(nil)
+	_ errorz.UnwrapSingle = (*RetryError)(nil)
+)
+
+// RetryError describes a command that failed after multiple attempts.
+type RetryError struct {
+	attempts []*ExecutionError
+}
+
+// GetAttempts returns the errors of all the attempts, in order.
+func (e *RetryError) GetAttempts() []*ExecutionError {
+	return e.attempts
+}
+
+// Error implements the error interface.
+func (e *RetryError) Error() string {
+	parts := make([]string, 0, len(e.attempts))
+
+	for i, attempt := range e.attempts {
+		parts = append(parts, fmt.Sprintf("attempt %v: %v", i+1, attempt.Error()))
+	}
+
+	return fmt.Sprintf("failed after %v attempts: %v", len(e.attempts), strings.Join(parts, "; "))
+}
+
+// Unwrap implements the [errorz.UnwrapSingle] interface, returning the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.attempts[len(e.attempts)-1]
+}
+
+// retry calls "attempt" according to the retry policy of the command.
+// If retries are enabled, the input is buffered so that it can be replayed for each attempt.
+func (c *Command) retry(ctx context.Context, attempt func(ctx context.Context, cc *Command) error) error {
+	if c.retryPolicy == nil || c.retryPolicy.MaxAttempts <= 1 {
+		return attempt(ctx, c)
+	}
+
+	var in []byte
+
+	if c.in != nil {
+		buf, err := io.ReadAll(c.in)
+		if err != nil {
+			return NewExecutionError(err, c)
+		}
+		in = buf
+	}
+
+	attempts := make([]*ExecutionError, 0, c.retryPolicy.MaxAttempts)
+
+	for i := 1; ; i++ {
+		cc := c
+
+		if in != nil {
+			cc = c.clone()
+			cc.in = bytes.NewReader(in)
+		}
+
+		err := attempt(ctx, cc)
+		if err == nil {
+			return nil
+		}
+
+		eErr, ok := errorz.As[*ExecutionError](err)
+		errorz.Assertf(ok, "unexpected error type: %T", err)
+		attempts = append(attempts, eErr)
+
+		if i >= c.retryPolicy.MaxAttempts || ctx.Err() != nil || !c.retryPolicy.isRetryable(eErr) {
+			consolez.DefaultCLI.Notice("retry", fmt.Sprintf("%v: attempt %v/%v failed", c.cmd, i, c.retryPolicy.MaxAttempts), eErr.Error())
+			break
+		}
+
+		backoff := c.retryPolicy.GetBackoff(i)
+		consolez.DefaultCLI.Notice("retry", fmt.Sprintf("%v: attempt %v/%v failed", c.cmd, i, c.retryPolicy.MaxAttempts), eErr.Error(), fmt.Sprintf("(retrying in %v)", backoff.Round(time.Millisecond)))
+
+		if !sleepContext(ctx, backoff) {
+			break
+		}
+	}
+
+	if len(attempts) == 1 {
+		return attempts[0]
+	}
+
+	return &RetryError{attempts: attempts}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
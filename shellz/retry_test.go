@@ -0,0 +1,201 @@
+package shellz_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type RetrySuite struct {
+	// intentionally empty
+}
+
+func TestRetrySuite(t *testing.T) {
+	fixturez.RunSuite(t, &RetrySuite{})
+}
+
+func newFlakyCommand(dirPath string, failures int, exitCode int) *shellz.Command {
+	return shellz.NewCommand("sh", "-c", fmt.Sprintf(
+		`n=$(cat count 2>/dev/null || echo 0); echo $((n+1)) > count; cat; if [ "$n" -lt %v ]; then echo "flaky $n" >&2; exit %v; fi`,
+		failures, exitCode)).
+		SetDir(dirPath).
+		SetEcho(false)
+}
+
+func newFastRetryPolicy(maxAttempts int) *shellz.RetryPolicy {
+	p := shellz.NewRetryPolicy(maxAttempts)
+	p.InitialBackoff = time.Millisecond
+	p.Jitter = 0
+	return p
+}
+
+func (*RetrySuite) TestNewRetryPolicy(g *WithT) {
+	p := shellz.NewRetryPolicy(3)
+	g.Expect(p.MaxAttempts).To(Equal(3))
+	g.Expect(p.InitialBackoff).To(Equal(time.Second))
+	g.Expect(p.MaxBackoff).To(Equal(30 * time.Second))
+	g.Expect(p.Multiplier).To(Equal(2.0))
+	g.Expect(p.Jitter).To(Equal(0.2))
+	g.Expect(p.IsRetryable).To(BeNil())
+}
+
+func (*RetrySuite) TestGetBackoff(g *WithT) {
+	p := &shellz.RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+
+	g.Expect(p.GetBackoff(1)).To(Equal(time.Second))
+	g.Expect(p.GetBackoff(2)).To(Equal(2 * time.Second))
+	g.Expect(p.GetBackoff(3)).To(Equal(4 * time.Second))
+	g.Expect(p.GetBackoff(4)).To(Equal(5 * time.Second))
+
+	p.Multiplier = 0
+	g.Expect(p.GetBackoff(3)).To(Equal(time.Second))
+
+	p.Multiplier = 2
+	p.Jitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		g.Expect(p.GetBackoff(2)).To(BeNumerically("~", 2*time.Second, time.Second))
+	}
+}
+
+func (*RetrySuite) TestRetryOnExitCodes(g *WithT) {
+	_, err := shellz.NewCommand("sh", "-c", "exit 3").CombinedOutput()
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+
+	g.Expect(shellz.RetryOnExitCodes(1, 3)(eErr)).To(BeTrue())
+	g.Expect(shellz.RetryOnExitCodes(1, 2)(eErr)).To(BeFalse())
+}
+
+func (*RetrySuite) TestRetryOnStderrContains(g *WithT) {
+	_, err := shellz.NewCommand("sh", "-c", "echo 'connection reset' >&2; exit 1").Output(false)
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+
+	g.Expect(shellz.RetryOnStderrContains("timeout", "reset")(eErr)).To(BeTrue())
+	g.Expect(shellz.RetryOnStderrContains("timeout")(eErr)).To(BeFalse())
+}
+
+func (*RetrySuite) TestSetRetry_Success(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	p := newFastRetryPolicy(3)
+	cmd := newFlakyCommand(dirPath, 2, 1).SetIn(strings.NewReader("input")).SetRetry(p)
+	g.Expect(cmd.GetRetry()).To(Equal(p))
+
+	out, err := cmd.Output(false)
+	g.Expect(err).To(Succeed())
+	g.Expect(string(out)).To(Equal("input"))
+	g.Expect(filez.MustReadFileString(filepath.Join(dirPath, "count"))).To(Equal("3\n"))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(
+		"[...................retry] sh: attempt 1/3 failed execution error: exit status 1 (retrying in 1ms)\n" +
+			"[...................retry] sh: attempt 2/3 failed execution error: exit status 1 (retrying in 2ms)\n"))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*RetrySuite) TestSetRetry_Exhausted(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	_, err := newFlakyCommand(dirPath, 5, 2).SetRetry(newFastRetryPolicy(2)).CombinedOutput()
+	g.Expect(err).To(MatchError("failed after 2 attempts: attempt 1: execution error: exit status 2; attempt 2: execution error: exit status 2"))
+
+	rErr, ok := errorz.As[*shellz.RetryError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(rErr.GetAttempts()).To(HaveLen(2))
+
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr).To(BeIdenticalTo(rErr.GetAttempts()[1]))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(
+		"[...................retry] sh: attempt 1/2 failed execution error: exit status 2 (retrying in 1ms)\n" +
+			"[...................retry] sh: attempt 2/2 failed execution error: exit status 2\n"))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*RetrySuite) TestSetRetry_NotRetryable(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	p := newFastRetryPolicy(3)
+	p.IsRetryable = shellz.RetryOnStderrContains("flaky 0")
+
+	_, err := newFlakyCommand(dirPath, 5, 1).SetRetry(p).Output(false)
+	g.Expect(err).To(MatchError("failed after 2 attempts: attempt 1: execution error: exit status 1; attempt 2: execution error: exit status 1"))
+
+	p.IsRetryable = shellz.RetryOnExitCodes(2)
+	_, err = newFlakyCommand(dirPath, 5, 1).SetRetry(p).Output(false)
+	g.Expect(err).To(MatchError("execution error: exit status 1"))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal(
+		"[...................retry] sh: attempt 1/3 failed execution error: exit status 1 (retrying in 1ms)\n" +
+			"[...................retry] sh: attempt 2/3 failed execution error: exit status 1\n" +
+			"[...................retry] sh: attempt 1/3 failed execution error: exit status 1\n"))
+	g.Expect(errBuf).To(BeEmpty())
+}
+
+func (*RetrySuite) TestSetRetry_Lines(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	receivedLines := make([]string, 0)
+
+	g.Expect(newFlakyCommand(dirPath, 1, 1).SetIn(strings.NewReader("input\n")).SetRetry(newFastRetryPolicy(2)).Lines(func(line string) {
+		receivedLines = append(receivedLines, line)
+	})).To(Succeed())
+
+	g.Expect(receivedLines).To(ContainElements("input", "flaky 0"))
+	g.Expect(receivedLines).To(HaveLen(3))
+}
+
+func (*RetrySuite) TestSetRetry_Canceled(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	p := newFastRetryPolicy(100)
+	p.InitialBackoff = time.Minute
+
+	start := time.Now()
+	err := newFlakyCommand(dirPath, 100, 1).SetRetry(p).RunContext(ctx)
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(err).To(MatchError("execution error: exit status 1"))
+}
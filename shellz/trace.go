@@ -0,0 +1,283 @@
+package shellz
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/jsonz"
+	"github.com/ibrt/golang-utils/memz"
+)
+
+var (
+	_ Observer = (*TraceObserver)(nil)
+	_ Observer = (*JSONLObserver)(nil)
+)
+
+// TraceObserver implements the [Observer] interface by writing executions as Chrome trace events (JSON array format),
+// which can be opened in "chrome://tracing" or "https://ui.perfetto.dev". Concurrent executions are laid out on
+// separate threads. Events are written as executions complete, so a partial trace is readable even if
+// [*TraceObserver.Close] is never called.
+type TraceObserver struct {
+	m       *sync.Mutex
+	w       io.Writer
+	origin  time.Time
+	pid     int
+	lanes   []bool
+	execs   map[uint64]int
+	isEmpty bool
+	err     error
+}
+
+type traceEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat"`
+	Ph   string         `json:"ph"`
+	TS   int64          `json:"ts"`
+	Dur  int64          `json:"dur"`
+	PID  int            `json:"pid"`
+	TID  int            `json:"tid"`
+	Args map[string]any `json:"args"`
+}
+
+// NewTraceObserver initializes a new [*TraceObserver] that writes to "w".
+func NewTraceObserver(w io.Writer) *TraceObserver {
+	return &TraceObserver{
+		m:       &sync.Mutex{},
+		w:       w,
+		origin:  time.Now(),
+		pid:     os.Getpid(),
+		lanes:   make([]bool, 0),
+		execs:   make(map[uint64]int),
+		isEmpty: true,
+		err:     nil,
+	}
+}
+
+// MustCreateTraceObserver initializes a new [*TraceObserver] that writes to a newly created file.
+func MustCreateTraceObserver(filePath string) *TraceObserver {
+	return NewTraceObserver(mustCreateObserverFile(filePath))
+}
+
+// OnStart implements the [Observer] interface.
+func (o *TraceObserver) OnStart(e *StartEvent) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	lane := len(o.lanes)
+
+	for i, isBusy := range o.lanes {
+		if !isBusy {
+			lane = i
+			break
+		}
+	}
+
+	if lane == len(o.lanes) {
+		o.lanes = append(o.lanes, true)
+	} else {
+		o.lanes[lane] = true
+	}
+
+	o.execs[e.ID] = lane
+}
+
+// OnExit implements the [Observer] interface.
+func (o *TraceObserver) OnExit(e *ExitEvent) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	lane, ok := o.execs[e.ID]
+	if ok {
+		delete(o.execs, e.ID)
+		o.lanes[lane] = false
+	}
+
+	args := map[string]any{
+		"command":     e.Command.String(),
+		"exitCode":    e.ExitCode,
+		"stdoutBytes": e.StdoutBytes,
+		"stderrBytes": e.StderrBytes,
+	}
+
+	if e.ResourceUsage != nil {
+		args["userTimeMs"] = getMilliseconds(e.ResourceUsage.UserTime)
+		args["systemTimeMs"] = getMilliseconds(e.ResourceUsage.SystemTime)
+		args["peakRssBytes"] = e.ResourceUsage.PeakRSS
+	}
+
+	if e.Error != nil {
+		args["error"] = e.Error.Error()
+	}
+
+	buf := jsonz.MustMarshal(&traceEvent{
+		Name: e.Command.getShortName(),
+		Cat:  "shellz",
+		Ph:   "X",
+		TS:   e.StartTime.Sub(o.origin).Microseconds(),
+		Dur:  e.Duration.Microseconds(),
+		PID:  o.pid,
+		TID:  lane + 1,
+		Args: args,
+	})
+
+	if o.isEmpty {
+		o.write("[\n")
+		o.isEmpty = false
+	} else {
+		o.write(",\n")
+	}
+
+	o.write(string(buf))
+}
+
+// Close terminates the trace, and closes the underlying writer if it implements [io.Closer].
+// It returns the first error encountered while writing, if any.
+func (o *TraceObserver) Close() error {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	if o.isEmpty {
+		o.write("[")
+	}
+
+	o.write("\n]\n")
+
+	if c, ok := o.w.(io.Closer); ok {
+		if err := c.Close(); err != nil && o.err == nil {
+			o.err = errorz.Wrap(err)
+		}
+	}
+
+	return o.err
+}
+
+// MustClose is like [*TraceObserver.Close] but panics on error.
+func (o *TraceObserver) MustClose() {
+	errorz.MaybeMustWrap(o.Close())
+}
+
+func (o *TraceObserver) write(s string) {
+	if o.err == nil {
+		_, o.err = io.WriteString(o.w, s)
+		o.err = errorz.MaybeWrap(o.err)
+	}
+}
+
+// JSONLObserver implements the [Observer] interface by writing one JSON object per line for each event.
+type JSONLObserver struct {
+	m   *sync.Mutex
+	w   io.Writer
+	err error
+}
+
+type jsonlEvent struct {
+	Event        string     `json:"event"`
+	ID           uint64     `json:"id"`
+	Time         time.Time  `json:"time"`
+	Command      string     `json:"command"`
+	StartTime    *time.Time `json:"startTime,omitempty"`
+	DurationMS   *float64   `json:"durationMs,omitempty"`
+	ExitCode     *int       `json:"exitCode,omitempty"`
+	StdoutBytes  *int64     `json:"stdoutBytes,omitempty"`
+	StderrBytes  *int64     `json:"stderrBytes,omitempty"`
+	UserTimeMS   *float64   `json:"userTimeMs,omitempty"`
+	SystemTimeMS *float64   `json:"systemTimeMs,omitempty"`
+	PeakRSSBytes *int64     `json:"peakRssBytes,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// NewJSONLObserver initializes a new [*JSONLObserver] that writes to "w".
+func NewJSONLObserver(w io.Writer) *JSONLObserver {
+	return &JSONLObserver{
+		m:   &sync.Mutex{},
+		w:   w,
+		err: nil,
+	}
+}
+
+// MustCreateJSONLObserver initializes a new [*JSONLObserver] that writes to a newly created file.
+func MustCreateJSONLObserver(filePath string) *JSONLObserver {
+	return NewJSONLObserver(mustCreateObserverFile(filePath))
+}
+
+// OnStart implements the [Observer] interface.
+func (o *JSONLObserver) OnStart(e *StartEvent) {
+	o.write(&jsonlEvent{
+		Event:   "start",
+		ID:      e.ID,
+		Time:    e.Time,
+		Command: e.Command.String(),
+	})
+}
+
+// OnExit implements the [Observer] interface.
+func (o *JSONLObserver) OnExit(e *ExitEvent) {
+	le := &jsonlEvent{
+		Event:       "exit",
+		ID:          e.ID,
+		Time:        e.Time,
+		Command:     e.Command.String(),
+		StartTime:   memz.Ptr(e.StartTime),
+		DurationMS:  memz.Ptr(getMilliseconds(e.Duration)),
+		ExitCode:    memz.Ptr(e.ExitCode),
+		StdoutBytes: memz.Ptr(e.StdoutBytes),
+		StderrBytes: memz.Ptr(e.StderrBytes),
+	}
+
+	if e.ResourceUsage != nil {
+		le.UserTimeMS = memz.Ptr(getMilliseconds(e.ResourceUsage.UserTime))
+		le.SystemTimeMS = memz.Ptr(getMilliseconds(e.ResourceUsage.SystemTime))
+		le.PeakRSSBytes = memz.Ptr(e.ResourceUsage.PeakRSS)
+	}
+
+	if e.Error != nil {
+		le.Error = e.Error.Error()
+	}
+
+	o.write(le)
+}
+
+// Close closes the underlying writer if it implements [io.Closer].
+// It returns the first error encountered while writing, if any.
+func (o *JSONLObserver) Close() error {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	if c, ok := o.w.(io.Closer); ok {
+		if err := c.Close(); err != nil && o.err == nil {
+			o.err = errorz.Wrap(err)
+		}
+	}
+
+	return o.err
+}
+
+// MustClose is like [*JSONLObserver.Close] but panics on error.
+func (o *JSONLObserver) MustClose() {
+	errorz.MaybeMustWrap(o.Close())
+}
+
+func (o *JSONLObserver) write(e *jsonlEvent) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	if o.err == nil {
+		_, o.err = o.w.Write(append(jsonz.MustMarshal(e), '\n'))
+		o.err = errorz.MaybeWrap(o.err)
+	}
+}
+
+func getMilliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func mustCreateObserverFile(filePath string) *os.File {
+	errorz.MaybeMustWrap(os.MkdirAll(filepath.Dir(filePath), 0777))
+	f, err := os.Create(filePath)
+	errorz.MaybeMustWrap(err)
+	return f
+}
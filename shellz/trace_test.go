@@ -0,0 +1,151 @@
+package shellz_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ibrt/golang-utils/filez"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/jsonz"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+type TraceSuite struct {
+	// intentionally empty
+}
+
+func TestTraceSuite(t *testing.T) {
+	fixturez.RunSuite(t, &TraceSuite{})
+}
+
+func (*TraceSuite) TestTraceObserver(g *WithT) {
+	buf := &bytes.Buffer{}
+	o := shellz.NewTraceObserver(buf)
+
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	// Each member waits for the other to start, so that they overlap and are traced on different threads.
+	_, _ = shellz.NewGroup().
+		SetConcurrency(2).
+		SetEcho(false).
+		SetSummary(false).
+		SetPolicy(shellz.GroupPolicyCollectAll).
+		Add("a", shellz.NewCommand("sh", "-c", "touch a; until [ -e b ]; do sleep 0.01; done; sleep 0.1").SetDir(dirPath).AddObserver(o)).
+		Add("b", shellz.NewCommand("/bin/sh", "-c", "touch b; until [ -e a ]; do sleep 0.01; done; echo x; exit 2").SetDir(dirPath).AddObserver(o)).
+		Run()
+
+	shellz.NewCommand("true", "sub").SetEcho(false).AddObserver(o).MustRun()
+	g.Expect(o.Close()).To(Succeed())
+
+	events := jsonz.MustUnmarshal[[]map[string]any](buf.Bytes())
+	g.Expect(events).To(HaveLen(3))
+
+	byName := make(map[string]map[string]any)
+	tids := make(map[float64]struct{})
+
+	for _, e := range events {
+		byName[e["name"].(string)] = e
+		g.Expect(e["cat"]).To(Equal("shellz"))
+		g.Expect(e["ph"]).To(Equal("X"))
+		g.Expect(e["pid"]).To(BeNumerically(">", 0))
+		g.Expect(e["ts"]).To(BeNumerically(">=", 0))
+		g.Expect(e["dur"]).To(BeNumerically(">=", 0))
+		tids[e["tid"].(float64)] = struct{}{}
+	}
+
+	g.Expect(tids).To(HaveLen(2))
+	g.Expect(byName).To(HaveKey("sh"))
+	g.Expect(byName).To(HaveKey("true sub"))
+	g.Expect(byName["sh"]["dur"]).To(BeNumerically(">=", 100000))
+
+	args := byName["sh"]["args"].(map[string]any)
+	g.Expect(args).To(HaveLen(7))
+	g.Expect(args["command"]).To(HaveSuffix(" && sh -c 'touch a; until [ -e b ]; do sleep 0.01; done; sleep 0.1'"))
+	g.Expect(args).To(HaveKeyWithValue("exitCode", float64(0)))
+	g.Expect(args).To(HaveKeyWithValue("stdoutBytes", float64(0)))
+	g.Expect(args).To(HaveKeyWithValue("stderrBytes", float64(0)))
+	g.Expect(args["userTimeMs"]).To(BeNumerically(">=", 0))
+	g.Expect(args["systemTimeMs"]).To(BeNumerically(">=", 0))
+	g.Expect(args["peakRssBytes"]).To(BeNumerically(">", 0))
+
+	for _, e := range events {
+		if args := e["args"].(map[string]any); strings.HasSuffix(args["command"].(string), " && /bin/sh -c 'touch b; until [ -e a ]; do sleep 0.01; done; echo x; exit 2'") {
+			g.Expect(args["exitCode"]).To(Equal(float64(2)))
+			g.Expect(args["stdoutBytes"]).To(Equal(float64(2)))
+			g.Expect(args["error"]).To(Equal("execution error: exit status 2"))
+		}
+	}
+}
+
+func (*TraceSuite) TestTraceObserver_Empty(g *WithT) {
+	buf := &bytes.Buffer{}
+	g.Expect(shellz.NewTraceObserver(buf).Close()).To(Succeed())
+	g.Expect(buf.String()).To(Equal("[\n]\n"))
+	g.Expect(json.Valid(buf.Bytes())).To(BeTrue())
+}
+
+func (*TraceSuite) TestMustCreateTraceObserver(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	filePath := filepath.Join(dirPath, "sub", "trace.json")
+	o := shellz.MustCreateTraceObserver(filePath)
+	shellz.NewCommand("true").SetEcho(false).AddObserver(o).MustRun()
+
+	// A partial trace is valid if terminated by a closing bracket.
+	g.Expect(json.Valid([]byte(filez.MustReadFileString(filePath) + "]"))).To(BeTrue())
+
+	o.MustClose()
+	g.Expect(jsonz.MustUnmarshalString[[]map[string]any](filez.MustReadFileString(filePath))).To(HaveLen(1))
+	g.Expect(o.Close()).ToNot(Succeed())
+}
+
+func (*TraceSuite) TestJSONLObserver(g *WithT) {
+	dirPath := filez.MustCreateTempDir()
+	defer filez.MustRemoveAll(dirPath)
+
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard, outz.GetOutputSetupFatihColor(true), outz.OutputSetupRodaineTable)
+	defer outz.ResetOutputCapture()
+
+	filePath := filepath.Join(dirPath, "log.jsonl")
+	o := shellz.MustCreateJSONLObserver(filePath)
+
+	g.Expect(shellz.NewCommand("sh", "-c", "echo x; exit 2").AddSecretParams("s3cr3t").SetEcho(false).AddObserver(o).Run()).
+		ToNot(Succeed())
+	o.MustClose()
+
+	lines := strings.Split(strings.TrimSpace(filez.MustReadFileString(filePath)), "\n")
+	g.Expect(lines).To(HaveLen(2))
+
+	start := jsonz.MustUnmarshalString[map[string]any](lines[0])
+	g.Expect(start).To(HaveKeyWithValue("event", "start"))
+	g.Expect(start).To(HaveKeyWithValue("command", "sh -c 'echo x; exit 2' '***'"))
+	g.Expect(start).To(HaveKey("id"))
+	g.Expect(start).To(HaveKey("time"))
+	g.Expect(start).ToNot(HaveKey("exitCode"))
+
+	exit := jsonz.MustUnmarshalString[map[string]any](lines[1])
+	g.Expect(exit).To(HaveKeyWithValue("event", "exit"))
+	g.Expect(exit).To(HaveKeyWithValue("id", start["id"]))
+	g.Expect(exit).To(HaveKeyWithValue("startTime", start["time"]))
+	g.Expect(exit).To(HaveKeyWithValue("exitCode", float64(2)))
+	g.Expect(exit).To(HaveKeyWithValue("stdoutBytes", float64(-1)))
+	g.Expect(exit).To(HaveKeyWithValue("stderrBytes", float64(-1)))
+	g.Expect(exit).To(HaveKeyWithValue("error", "execution error: exit status 2"))
+	g.Expect(exit).To(HaveKey("durationMs"))
+	g.Expect(exit).To(HaveKey("userTimeMs"))
+	g.Expect(exit).To(HaveKey("systemTimeMs"))
+	g.Expect(exit).To(HaveKey("peakRssBytes"))
+
+	g.Expect(o.Close()).ToNot(Succeed())
+}
@@ -0,0 +1,497 @@
+package tshellz
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ibrt/golang-utils/errorz"
+
+	"github.com/ibrt/golang-dev/consolez"
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+// FakeCall describes a command handled by a [*FakeExecutor].
+type FakeCall struct {
+	// Method is the [shellz.Executor] method that was called.
+	Method shellz.ExecutorMethod
+
+	// Command is the command being executed.
+	Command *shellz.Command
+
+	// Cmd is the command (see [*shellz.Command.GetCmd]).
+	Cmd string
+
+	// Params are the params (see [*shellz.Command.GetParams]).
+	Params []string
+
+	// Dir is the dir (see [*shellz.Command.GetDir]).
+	Dir string
+
+	// Env are the env overrides (see [*shellz.Command.GetEnv]).
+	Env map[string]string
+
+	// Stdin is the input, read in full (nil if the command has no input or its input is an [*os.File]).
+	Stdin []byte
+}
+
+// String returns the command line, without dir and env (see [*shellz.Command.String] for a full command line).
+func (c *FakeCall) String() string {
+	words := make([]string, 0, len(c.Params)+1)
+	words = append(words, consolez.ShellQuote(c.Cmd))
+
+	for _, p := range c.Params {
+		words = append(words, consolez.ShellQuote(p))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// FakeResponse describes the response of a [*FakeExecutor] to a command.
+type FakeResponse struct {
+	// Stdout is the standard output.
+	Stdout string
+
+	// Stderr is the standard error.
+	Stderr string
+
+	// ExitCode is the exit code.
+	ExitCode int
+
+	// Err, if set, is returned instead of an exit error, e.g. to simulate a failure to start.
+	Err error
+}
+
+// FakeRule describes how a [*FakeExecutor] responds to matching commands (see [*FakeExecutor.On]).
+type FakeRule struct {
+	m           *sync.Mutex
+	cmd         *regexp.Regexp
+	params      []*regexp.Regexp
+	isAnyParams bool
+	paramsRe    *regexp.Regexp
+	resp        *FakeResponse
+	respond     func(call *FakeCall) *FakeResponse
+	times       int
+	count       int
+}
+
+// SetStdout sets the standard output of the response.
+func (r *FakeRule) SetStdout(stdout string) *FakeRule {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.resp.Stdout = stdout
+	return r
+}
+
+// SetStderr sets the standard error of the response.
+func (r *FakeRule) SetStderr(stderr string) *FakeRule {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.resp.Stderr = stderr
+	return r
+}
+
+// SetExitCode sets the exit code of the response.
+func (r *FakeRule) SetExitCode(exitCode int) *FakeRule {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.resp.ExitCode = exitCode
+	return r
+}
+
+// SetError sets the error of the response (see [FakeResponse.Err]).
+func (r *FakeRule) SetError(err error) *FakeRule {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.resp.Err = err
+	return r
+}
+
+// SetFunc sets a function that computes the response, taking precedence over the static response. If the function
+// returns nil, the static response is used.
+func (r *FakeRule) SetFunc(f func(call *FakeCall) *FakeResponse) *FakeRule {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.respond = f
+	return r
+}
+
+// SetTimes limits the number of commands the rule responds to (if positive). Once exhausted, the rule is skipped in
+// favor of the following ones.
+func (r *FakeRule) SetTimes(times int) *FakeRule {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.times = times
+	return r
+}
+
+// GetCount returns the number of commands the rule responded to.
+func (r *FakeRule) GetCount() int {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.count
+}
+
+// claim returns the response of the rule for the given call, or nil if the rule does not match or is exhausted.
+func (r *FakeRule) claim(call *FakeCall) *FakeResponse {
+	if !r.matches(call) {
+		return nil
+	}
+
+	r.m.Lock()
+	if r.times > 0 && r.count >= r.times {
+		r.m.Unlock()
+		return nil
+	}
+
+	r.count++
+	respond := r.respond
+	resp := *r.resp
+	r.m.Unlock()
+
+	if respond != nil {
+		if fResp := respond(call); fResp != nil {
+			return fResp
+		}
+	}
+
+	return &resp
+}
+
+// matches returns true if the command and params of the call match the rule.
+func (r *FakeRule) matches(call *FakeCall) bool {
+	if !r.cmd.MatchString(call.Cmd) {
+		return false
+	}
+
+	if r.paramsRe != nil {
+		return r.paramsRe.MatchString(strings.Join(call.Params, " "))
+	}
+
+	return matchParams(r.params, r.isAnyParams, call.Params)
+}
+
+var (
+	_ shellz.Executor          = (*FakeExecutor)(nil)
+	_ shellz.SimulatedExecutor = (*FakeExecutor)(nil)
+)
+
+// FakeExecutor implements the [shellz.Executor] interface by responding to commands according to a list of rules (see
+// [*FakeExecutor.On]), and records the commands it handles for later assertions (see [*FakeExecutor.GetCalls] and
+// [HaveRun]). Rules are evaluated in the order they were added, and the first matching one responds.
+//
+// In strict mode (the default), commands that match no rule fail with an error. In lenient mode they succeed with no
+// output. Exec-related methods are supported: [*shellz.Command.Exec] responds like [*shellz.Command.Run], without
+// replacing the current process.
+type FakeExecutor struct {
+	m        *sync.Mutex
+	rules    []*FakeRule
+	calls    []*FakeCall
+	isStrict bool
+	pending  map[*exec.Cmd]*pendingFake
+}
+
+type pendingFake struct {
+	resp *FakeResponse
+	done chan struct{}
+}
+
+// NewFakeExecutor initializes a new [*FakeExecutor] in strict mode.
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{
+		m:        &sync.Mutex{},
+		rules:    make([]*FakeRule, 0),
+		calls:    make([]*FakeCall, 0),
+		isStrict: true,
+		pending:  make(map[*exec.Cmd]*pendingFake),
+	}
+}
+
+// SetStrict enables or disables strict mode.
+func (e *FakeExecutor) SetStrict(isStrict bool) *FakeExecutor {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.isStrict = isStrict
+	return e
+}
+
+// GetStrict returns true if strict mode is enabled.
+func (e *FakeExecutor) GetStrict() bool {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return e.isStrict
+}
+
+// On adds a rule that matches commands by name and params, and responds successfully with no output until configured
+// otherwise. The command and params are glob patterns, where "*" matches any sequence of characters and "?" matches
+// any single character. A last param of "**" matches any number of remaining params (including none).
+//
+// For example, On("go", "test", "**") matches "go test" and "go test -v ./...", while On("go", "build", "./*") matches
+// "go build ./cmd/..." but not "go build -v ./cmd/...".
+func (e *FakeExecutor) On(cmd string, params ...string) *FakeRule {
+	return e.addRule(newGlobRule(cmd, params))
+}
+
+// OnRegexp adds a rule like [*FakeExecutor.On], but that matches the params, joined by a single space, against a
+// regular expression. The command is still a glob pattern.
+func (e *FakeExecutor) OnRegexp(cmd string, paramsRegexp *regexp.Regexp) *FakeRule {
+	r := newRule(cmd)
+	r.paramsRe = paramsRegexp
+	return e.addRule(r)
+}
+
+// GetCalls returns the commands handled so far, in order.
+func (e *FakeExecutor) GetCalls() []*FakeCall {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return slices.Clone(e.calls)
+}
+
+// Reset removes all rules and recorded calls.
+func (e *FakeExecutor) Reset() {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.rules = make([]*FakeRule, 0)
+	e.calls = make([]*FakeCall, 0)
+}
+
+// IsSimulated implements the [shellz.SimulatedExecutor] interface.
+func (*FakeExecutor) IsSimulated() bool {
+	return true
+}
+
+// ExecCmdCombinedOutput implements the [shellz.Executor] interface.
+func (e *FakeExecutor) ExecCmdCombinedOutput(_ context.Context, c *shellz.Command, cmd *exec.Cmd) ([]byte, error) {
+	resp, err := e.handle(shellz.ExecutorMethodExecCmdCombinedOutput, c, cmd)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	return []byte(resp.Stdout + resp.Stderr), newFakeError(resp, false)
+}
+
+// ExecCmdOutput implements the [shellz.Executor] interface.
+func (e *FakeExecutor) ExecCmdOutput(_ context.Context, c *shellz.Command, cmd *exec.Cmd) ([]byte, error) {
+	resp, err := e.handle(shellz.ExecutorMethodExecCmdOutput, c, cmd)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	if cmd.Stderr != nil {
+		_, _ = io.WriteString(cmd.Stderr, resp.Stderr)
+	}
+
+	return []byte(resp.Stdout), newFakeError(resp, cmd.Stderr == nil)
+}
+
+// ExecCmdRun implements the [shellz.Executor] interface.
+func (e *FakeExecutor) ExecCmdRun(_ context.Context, c *shellz.Command, cmd *exec.Cmd) error {
+	resp, err := e.handle(shellz.ExecutorMethodExecCmdRun, c, cmd)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	writeOutput(cmd.Stdout, resp.Stdout, false)
+	writeOutput(cmd.Stderr, resp.Stderr, false)
+	return newFakeError(resp, false)
+}
+
+// ExecCmdStart implements the [shellz.Executor] interface.
+// The output is written asynchronously, then the output files (e.g. pipes) are closed.
+func (e *FakeExecutor) ExecCmdStart(_ context.Context, c *shellz.Command, cmd *exec.Cmd) error {
+	resp, err := e.handle(shellz.ExecutorMethodExecCmdStart, c, cmd)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	if resp.Err != nil {
+		return resp.Err
+	}
+
+	p := &pendingFake{
+		resp: resp,
+		done: make(chan struct{}),
+	}
+
+	stdout, stderr := cmd.Stdout, cmd.Stderr
+
+	go func() {
+		defer close(p.done)
+		writeOutput(stdout, resp.Stdout, true)
+		writeOutput(stderr, resp.Stderr, true)
+	}()
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.pending[cmd] = p
+	return nil
+}
+
+// ExecCmdWait implements the [shellz.Executor] interface.
+func (e *FakeExecutor) ExecCmdWait(_ context.Context, _ *shellz.Command, cmd *exec.Cmd) error {
+	e.m.Lock()
+	p, ok := e.pending[cmd]
+	delete(e.pending, cmd)
+	e.m.Unlock()
+
+	if !ok {
+		return errorz.Errorf("fake: wait called for a command that was not started")
+	}
+
+	<-p.done
+	return newFakeError(p.resp, false)
+}
+
+// ExecLookPath implements the [shellz.Executor] interface.
+func (e *FakeExecutor) ExecLookPath(_ *shellz.Command, file string) (string, error) {
+	return file, nil
+}
+
+// OSChdir implements the [shellz.Executor] interface.
+func (e *FakeExecutor) OSChdir(_ *shellz.Command, _ string) error {
+	return nil
+}
+
+// SyscallExec implements the [shellz.Executor] interface.
+func (e *FakeExecutor) SyscallExec(c *shellz.Command, _ string, _ []string, _ []string) error {
+	resp, err := e.handle(shellz.ExecutorMethodSyscallExec, c, nil)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	writeOutput(os.Stdout, resp.Stdout, false)
+	writeOutput(os.Stderr, resp.Stderr, false)
+	return newFakeError(resp, false)
+}
+
+func newRule(cmd string) *FakeRule {
+	return &FakeRule{
+		m:    &sync.Mutex{},
+		cmd:  compileGlob(cmd),
+		resp: &FakeResponse{},
+	}
+}
+
+func newGlobRule(cmd string, params []string) *FakeRule {
+	r := newRule(cmd)
+
+	if len(params) > 0 && params[len(params)-1] == "**" {
+		r.isAnyParams = true
+		params = params[:len(params)-1]
+	}
+
+	for _, p := range params {
+		r.params = append(r.params, compileGlob(p))
+	}
+
+	return r
+}
+
+func (e *FakeExecutor) addRule(r *FakeRule) *FakeRule {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.rules = append(e.rules, r)
+	return r
+}
+
+// handle records the call and returns the response of the first matching rule.
+func (e *FakeExecutor) handle(method shellz.ExecutorMethod, c *shellz.Command, cmd *exec.Cmd) (*FakeResponse, error) {
+	call := &FakeCall{
+		Method:  method,
+		Command: c,
+		Cmd:     c.GetCmd(),
+		Params:  c.GetParams(),
+		Dir:     c.GetDir(),
+		Env:     c.GetEnv(),
+	}
+
+	if cmd != nil && cmd.Stdin != nil {
+		if _, ok := cmd.Stdin.(*os.File); !ok {
+			in, err := io.ReadAll(cmd.Stdin)
+			if err != nil {
+				return nil, errorz.Wrap(err)
+			}
+
+			call.Stdin = in
+			cmd.Stdin = bytes.NewReader(in)
+		}
+	}
+
+	e.m.Lock()
+	e.calls = append(e.calls, call)
+	rules := slices.Clone(e.rules)
+	isStrict := e.isStrict
+	e.m.Unlock()
+
+	for _, r := range rules {
+		if resp := r.claim(call); resp != nil {
+			return resp, nil
+		}
+	}
+
+	if isStrict {
+		return nil, errorz.Errorf("fake: unexpected command: %v", call.String())
+	}
+
+	return &FakeResponse{}, nil
+}
+
+func newFakeError(resp *FakeResponse, includeStderr bool) error {
+	if resp.Err != nil {
+		return resp.Err
+	}
+
+	if resp.ExitCode == 0 {
+		return nil
+	}
+
+	var stderr []byte
+
+	if includeStderr {
+		stderr = []byte(resp.Stderr)
+	}
+
+	return shellz.NewExitError(resp.ExitCode, stderr)
+}
+
+// compileGlob converts a glob pattern, where "*" matches any sequence of characters and "?" matches any single
+// character, to an anchored regular expression.
+func compileGlob(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString("(?s:.*)")
+		case '?':
+			b.WriteString("(?s:.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// matchParams returns true if the params match the given compiled glob patterns, optionally followed by any params.
+func matchParams(patterns []*regexp.Regexp, isAnyParams bool, params []string) bool {
+	if len(params) < len(patterns) || (!isAnyParams && len(params) > len(patterns)) {
+		return false
+	}
+
+	for i, re := range patterns {
+		if !re.MatchString(params[i]) {
+			return false
+		}
+	}
+
+	return true
+}
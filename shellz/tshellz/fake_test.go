@@ -0,0 +1,174 @@
+package tshellz_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/ibrt/golang-utils/fixturez"
+	"github.com/ibrt/golang-utils/outz"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+	"github.com/ibrt/golang-dev/shellz/tshellz"
+)
+
+type FakeSuite struct {
+	// intentionally empty
+}
+
+func TestFakeSuite(t *testing.T) {
+	fixturez.RunSuite(t, &FakeSuite{})
+}
+
+func (*FakeSuite) TestFakeExecutor(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	e := tshellz.NewFakeExecutor()
+	g.Expect(e.GetStrict()).To(BeTrue())
+
+	e.On("go", "list", "**").SetStdout("pkg\n")
+	e.On("git", "status").SetStdout("clean\n").SetStderr("warning\n")
+	r := e.On("git", "push", "*").SetExitCode(128).SetStderr("denied\n")
+
+	g.Expect(shellz.NewCommand("go", "list", "-m", "all").SetExecutor(e).OutputString(false)).To(Equal("pkg\n"))
+	g.Expect(shellz.NewCommand("go", "list").SetExecutor(e).CombinedOutputString()).To(Equal("pkg\n"))
+	g.Expect(shellz.NewCommand("git", "status").SetEcho(false).SetExecutor(e).Run()).To(Succeed())
+
+	_, err := shellz.NewCommand("git", "push", "origin").SetExecutor(e).Output(false)
+	eErr, ok := errorz.As[*shellz.ExecutionError](err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(eErr.GetExitCode()).To(Equal(128))
+	g.Expect(eErr.GetCapturedStderr()).To(Equal("denied\n"))
+	g.Expect(r.GetCount()).To(Equal(1))
+
+	err = shellz.NewCommand("git", "push", "origin", "main").SetEcho(false).SetExecutor(e).Run()
+	g.Expect(err).To(MatchError("execution error: fake: unexpected command: git push origin main"))
+
+	outBuf, errBuf := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("clean\n"))
+	g.Expect(errBuf).To(Equal("warning\n"))
+
+	calls := e.GetCalls()
+	g.Expect(calls).To(HaveLen(5))
+	g.Expect(calls[0].Method).To(Equal(shellz.ExecutorMethodExecCmdOutput))
+	g.Expect(calls[0].Cmd).To(Equal("go"))
+	g.Expect(calls[0].Params).To(Equal([]string{"list", "-m", "all"}))
+	g.Expect(calls[1].Method).To(Equal(shellz.ExecutorMethodExecCmdCombinedOutput))
+	g.Expect(calls[2].Method).To(Equal(shellz.ExecutorMethodExecCmdRun))
+	g.Expect(calls[4].String()).To(Equal("git push origin main"))
+}
+
+func (*FakeSuite) TestIsSimulated(g *WithT) {
+	g.Expect(shellz.IsSimulated(tshellz.NewFakeExecutor())).To(BeTrue())
+	g.Expect(shellz.IsSimulated(tshellz.NewReplayExecutor(shellz.NewCassette()))).To(BeTrue())
+	g.Expect(shellz.IsSimulated(&tshellz.MockExecutor{})).To(BeTrue())
+}
+
+func (*FakeSuite) TestFakeExecutor_Lenient(g *WithT) {
+	e := tshellz.NewFakeExecutor().SetStrict(false)
+	g.Expect(e.GetStrict()).To(BeFalse())
+
+	g.Expect(shellz.NewCommand("anything", "goes").SetExecutor(e).OutputString(false)).To(Equal(""))
+	g.Expect(e).To(tshellz.HaveRun("anything", "goes"))
+
+	e.Reset()
+	g.Expect(e.GetCalls()).To(BeEmpty())
+}
+
+func (*FakeSuite) TestFakeExecutor_Rules(g *WithT) {
+	e := tshellz.NewFakeExecutor()
+	e.On("date").SetStdout("first").SetTimes(1)
+	e.On("date").SetStdout("second")
+	e.OnRegexp("docker", regexp.MustCompile(`^compose (up|down)\b`)).SetStdout("compose")
+	e.On("/usr/*/env", "K=?").SetStdout("env")
+
+	e.On("cat").SetFunc(func(call *tshellz.FakeCall) *tshellz.FakeResponse {
+		if len(call.Stdin) == 0 {
+			return nil
+		}
+
+		return &tshellz.FakeResponse{Stdout: strings.ToUpper(string(call.Stdin)) + call.Dir + call.Env["K"]}
+	}).SetStdout("static")
+
+	g.Expect(shellz.NewCommand("date").SetExecutor(e).OutputString(false)).To(Equal("first"))
+	g.Expect(shellz.NewCommand("date").SetExecutor(e).OutputString(false)).To(Equal("second"))
+	g.Expect(shellz.NewCommand("date").SetExecutor(e).OutputString(false)).To(Equal("second"))
+	g.Expect(shellz.NewCommand("docker", "compose", "up", "-d").SetExecutor(e).OutputString(false)).To(Equal("compose"))
+	g.Expect(shellz.NewCommand("docker", "compose", "upx").SetExecutor(e).OutputString(false)).Error().To(HaveOccurred())
+	g.Expect(shellz.NewCommand("/usr/bin/env", "K=V").SetExecutor(e).OutputString(false)).To(Equal("env"))
+	g.Expect(shellz.NewCommand("/usr/bin/env", "K=VV").SetExecutor(e).OutputString(false)).Error().To(HaveOccurred())
+
+	g.Expect(shellz.NewCommand("cat").
+		SetIn(strings.NewReader("in")).
+		SetDir("dir").
+		SetEnv("K", "V").
+		SetExecutor(e).
+		OutputString(false)).To(Equal("INdirV"))
+	g.Expect(shellz.NewCommand("cat").SetExecutor(e).OutputString(false)).To(Equal("static"))
+
+	e.On("fail").SetError(errorz.Errorf("start error"))
+	g.Expect(shellz.NewCommand("fail").SetExecutor(e).Output(false)).Error().To(MatchError("execution error: start error"))
+}
+
+func (*FakeSuite) TestFakeExecutor_Start(g *WithT) {
+	e := tshellz.NewFakeExecutor()
+	e.On("tail", "-f", "**").SetStdout("a\nb\n").SetExitCode(1)
+
+	lines := make([]string, 0)
+	err := shellz.NewCommand("tail", "-f", "log").SetEcho(false).SetExecutor(e).Lines(func(line string) { lines = append(lines, line) })
+	g.Expect(err).To(MatchError("execution error: exit status 1"))
+	g.Expect(lines).To(Equal([]string{"a", "b"}))
+
+	e.On("fail").SetError(errorz.Errorf("start error"))
+	_, err = shellz.NewCommand("fail").SetEcho(false).SetExecutor(e).Start()
+	g.Expect(err).To(MatchError("execution error: start error"))
+
+	g.Expect(e.GetCalls()[0].Method).To(Equal(shellz.ExecutorMethodExecCmdStart))
+}
+
+func (*FakeSuite) TestFakeExecutor_Exec(g *WithT) {
+	outz.MustBeginOutputCapture(outz.OutputSetupStandard)
+	defer outz.ResetOutputCapture()
+
+	e := tshellz.NewFakeExecutor()
+	e.On("psql").SetStdout("out\n")
+	e.On("false").SetExitCode(1)
+
+	g.Expect(shellz.NewCommand("psql").SetDir(".").SetEcho(false).SetExecutor(e).Exec()).To(Succeed())
+	g.Expect(shellz.NewCommand("false").SetEcho(false).SetExecutor(e).Exec()).To(MatchError("execution error: exit status 1"))
+	g.Expect(e).To(tshellz.HaveRun("psql"))
+	g.Expect(e.GetCalls()[0].Method).To(Equal(shellz.ExecutorMethodSyscallExec))
+
+	outBuf, _ := outz.MustEndOutputCapture()
+	g.Expect(outBuf).To(Equal("out\n"))
+}
+
+func (*FakeSuite) TestHaveRun(g *WithT) {
+	e := tshellz.NewFakeExecutor().SetStrict(false)
+	g.Expect(shellz.NewCommand("go", "test", "-v", "./...").SetEcho(false).SetExecutor(e).Run()).To(Succeed())
+
+	g.Expect(e).To(tshellz.HaveRun("go", "test", "**"))
+	g.Expect(e).To(tshellz.HaveRun("go", "test", "-v", "./..."))
+	g.Expect(e).To(tshellz.HaveRun("go", "*", "-?", "**"))
+	g.Expect(e.GetCalls()).To(tshellz.HaveRun("go", "test", "**"))
+	g.Expect(e).ToNot(tshellz.HaveRun("go", "test"))
+	g.Expect(e).ToNot(tshellz.HaveRun("go", "build", "**"))
+
+	m := tshellz.HaveRun("go", "build", "**")
+	g.Expect(m.Match(e)).To(BeFalse())
+	g.Expect(m.FailureMessage(e)).To(Equal("Expected a command matching\n  go build '**'\nto have run, but ran:\n  1. go test -v ./..."))
+
+	m = tshellz.HaveRun("go", "test", "**")
+	g.Expect(m.Match(e)).To(BeTrue())
+	g.Expect(m.NegatedFailureMessage(e)).To(Equal("Expected no command matching\n  go test '**'\nto have run, but ran:\n  1. go test -v ./..."))
+
+	m = tshellz.HaveRun("go")
+	g.Expect(m.Match([]*tshellz.FakeCall{})).To(BeFalse())
+	g.Expect(m.FailureMessage(nil)).To(HaveSuffix("but ran:\n  (none)"))
+
+	_, err := m.Match("go")
+	g.Expect(err).To(MatchError("HaveRun expects a *tshellz.FakeExecutor or []*tshellz.FakeCall, got string"))
+}
@@ -1,3 +1,16 @@
 //go:generate go run go.uber.org/mock/mockgen@v0.5.0 -typed -source ./../command.go -destination ./mocks.gen.go -package tshellz
 
 package tshellz
+
+import (
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+var (
+	_ shellz.SimulatedExecutor = (*MockExecutor)(nil)
+)
+
+// IsSimulated implements the [shellz.SimulatedExecutor] interface.
+func (*MockExecutor) IsSimulated() bool {
+	return true
+}
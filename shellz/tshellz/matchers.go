@@ -0,0 +1,75 @@
+package tshellz
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ibrt/golang-utils/errorz"
+	"github.com/onsi/gomega/types"
+)
+
+var (
+	_ types.GomegaMatcher = (*haveRunMatcher)(nil)
+)
+
+// HaveRun returns a matcher that succeeds if the actual value, a [*FakeExecutor] or a []*[FakeCall], includes a
+// command matching the given command and params patterns, with the same syntax as [*FakeExecutor.On]. For example:
+//
+//	g.Expect(e).To(tshellz.HaveRun("go", "test", "**"))
+func HaveRun(cmd string, params ...string) types.GomegaMatcher {
+	return &haveRunMatcher{
+		rule:        newGlobRule(cmd, params),
+		description: (&FakeCall{Cmd: cmd, Params: params}).String(),
+	}
+}
+
+type haveRunMatcher struct {
+	rule        *FakeRule
+	description string
+	calls       []*FakeCall
+}
+
+// Match implements the [types.GomegaMatcher] interface.
+func (m *haveRunMatcher) Match(actual any) (bool, error) {
+	switch v := actual.(type) {
+	case *FakeExecutor:
+		m.calls = v.GetCalls()
+	case []*FakeCall:
+		m.calls = v
+	default:
+		return false, errorz.Errorf("HaveRun expects a *tshellz.FakeExecutor or []*tshellz.FakeCall, got %T", actual)
+	}
+
+	for _, call := range m.calls {
+		if m.rule.matches(call) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// FailureMessage implements the [types.GomegaMatcher] interface.
+func (m *haveRunMatcher) FailureMessage(_ any) string {
+	return fmt.Sprintf("Expected a command matching\n  %v\nto have run, but ran:\n%v", m.description, formatCalls(m.calls))
+}
+
+// NegatedFailureMessage implements the [types.GomegaMatcher] interface.
+func (m *haveRunMatcher) NegatedFailureMessage(_ any) string {
+	return fmt.Sprintf("Expected no command matching\n  %v\nto have run, but ran:\n%v", m.description, formatCalls(m.calls))
+}
+
+// formatCalls returns a human-readable list of calls.
+func formatCalls(calls []*FakeCall) string {
+	if len(calls) == 0 {
+		return "  (none)"
+	}
+
+	lines := make([]string, 0, len(calls))
+
+	for i, call := range calls {
+		lines = append(lines, fmt.Sprintf("  %v. %v", i+1, call.String()))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -10,6 +10,7 @@
 package tshellz
 
 import (
+	context "context"
 	exec "os/exec"
 	reflect "reflect"
 
@@ -42,18 +43,18 @@ func (m *MockExecutor) EXPECT() *MockExecutorMockRecorder {
 }
 
 // ExecCmdCombinedOutput mocks base method.
-func (m *MockExecutor) ExecCmdCombinedOutput(c *shellz.Command, cmd *exec.Cmd) ([]byte, error) {
+func (m *MockExecutor) ExecCmdCombinedOutput(ctx context.Context, c *shellz.Command, cmd *exec.Cmd) ([]byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecCmdCombinedOutput", c, cmd)
+	ret := m.ctrl.Call(m, "ExecCmdCombinedOutput", ctx, c, cmd)
 	ret0, _ := ret[0].([]byte)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ExecCmdCombinedOutput indicates an expected call of ExecCmdCombinedOutput.
-func (mr *MockExecutorMockRecorder) ExecCmdCombinedOutput(c, cmd any) *MockExecutorExecCmdCombinedOutputCall {
+func (mr *MockExecutorMockRecorder) ExecCmdCombinedOutput(ctx, c, cmd any) *MockExecutorExecCmdCombinedOutputCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdCombinedOutput", reflect.TypeOf((*MockExecutor)(nil).ExecCmdCombinedOutput), c, cmd)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdCombinedOutput", reflect.TypeOf((*MockExecutor)(nil).ExecCmdCombinedOutput), ctx, c, cmd)
 	return &MockExecutorExecCmdCombinedOutputCall{Call: call}
 }
 
@@ -69,30 +70,30 @@ func (c_2 *MockExecutorExecCmdCombinedOutputCall) Return(arg0 []byte, arg1 error
 }
 
 // Do rewrite *gomock.Call.Do
-func (c_2 *MockExecutorExecCmdCombinedOutputCall) Do(f func(*shellz.Command, *exec.Cmd) ([]byte, error)) *MockExecutorExecCmdCombinedOutputCall {
+func (c_2 *MockExecutorExecCmdCombinedOutputCall) Do(f func(context.Context, *shellz.Command, *exec.Cmd) ([]byte, error)) *MockExecutorExecCmdCombinedOutputCall {
 	c_2.Call = c_2.Call.Do(f)
 	return c_2
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c_2 *MockExecutorExecCmdCombinedOutputCall) DoAndReturn(f func(*shellz.Command, *exec.Cmd) ([]byte, error)) *MockExecutorExecCmdCombinedOutputCall {
+func (c_2 *MockExecutorExecCmdCombinedOutputCall) DoAndReturn(f func(context.Context, *shellz.Command, *exec.Cmd) ([]byte, error)) *MockExecutorExecCmdCombinedOutputCall {
 	c_2.Call = c_2.Call.DoAndReturn(f)
 	return c_2
 }
 
 // ExecCmdOutput mocks base method.
-func (m *MockExecutor) ExecCmdOutput(c *shellz.Command, cmd *exec.Cmd) ([]byte, error) {
+func (m *MockExecutor) ExecCmdOutput(ctx context.Context, c *shellz.Command, cmd *exec.Cmd) ([]byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecCmdOutput", c, cmd)
+	ret := m.ctrl.Call(m, "ExecCmdOutput", ctx, c, cmd)
 	ret0, _ := ret[0].([]byte)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ExecCmdOutput indicates an expected call of ExecCmdOutput.
-func (mr *MockExecutorMockRecorder) ExecCmdOutput(c, cmd any) *MockExecutorExecCmdOutputCall {
+func (mr *MockExecutorMockRecorder) ExecCmdOutput(ctx, c, cmd any) *MockExecutorExecCmdOutputCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdOutput", reflect.TypeOf((*MockExecutor)(nil).ExecCmdOutput), c, cmd)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdOutput", reflect.TypeOf((*MockExecutor)(nil).ExecCmdOutput), ctx, c, cmd)
 	return &MockExecutorExecCmdOutputCall{Call: call}
 }
 
@@ -108,29 +109,29 @@ func (c_2 *MockExecutorExecCmdOutputCall) Return(arg0 []byte, arg1 error) *MockE
 }
 
 // Do rewrite *gomock.Call.Do
-func (c_2 *MockExecutorExecCmdOutputCall) Do(f func(*shellz.Command, *exec.Cmd) ([]byte, error)) *MockExecutorExecCmdOutputCall {
+func (c_2 *MockExecutorExecCmdOutputCall) Do(f func(context.Context, *shellz.Command, *exec.Cmd) ([]byte, error)) *MockExecutorExecCmdOutputCall {
 	c_2.Call = c_2.Call.Do(f)
 	return c_2
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c_2 *MockExecutorExecCmdOutputCall) DoAndReturn(f func(*shellz.Command, *exec.Cmd) ([]byte, error)) *MockExecutorExecCmdOutputCall {
+func (c_2 *MockExecutorExecCmdOutputCall) DoAndReturn(f func(context.Context, *shellz.Command, *exec.Cmd) ([]byte, error)) *MockExecutorExecCmdOutputCall {
 	c_2.Call = c_2.Call.DoAndReturn(f)
 	return c_2
 }
 
 // ExecCmdRun mocks base method.
-func (m *MockExecutor) ExecCmdRun(c *shellz.Command, cmd *exec.Cmd) error {
+func (m *MockExecutor) ExecCmdRun(ctx context.Context, c *shellz.Command, cmd *exec.Cmd) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecCmdRun", c, cmd)
+	ret := m.ctrl.Call(m, "ExecCmdRun", ctx, c, cmd)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ExecCmdRun indicates an expected call of ExecCmdRun.
-func (mr *MockExecutorMockRecorder) ExecCmdRun(c, cmd any) *MockExecutorExecCmdRunCall {
+func (mr *MockExecutorMockRecorder) ExecCmdRun(ctx, c, cmd any) *MockExecutorExecCmdRunCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdRun", reflect.TypeOf((*MockExecutor)(nil).ExecCmdRun), c, cmd)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdRun", reflect.TypeOf((*MockExecutor)(nil).ExecCmdRun), ctx, c, cmd)
 	return &MockExecutorExecCmdRunCall{Call: call}
 }
 
@@ -146,29 +147,29 @@ func (c_2 *MockExecutorExecCmdRunCall) Return(arg0 error) *MockExecutorExecCmdRu
 }
 
 // Do rewrite *gomock.Call.Do
-func (c_2 *MockExecutorExecCmdRunCall) Do(f func(*shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdRunCall {
+func (c_2 *MockExecutorExecCmdRunCall) Do(f func(context.Context, *shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdRunCall {
 	c_2.Call = c_2.Call.Do(f)
 	return c_2
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c_2 *MockExecutorExecCmdRunCall) DoAndReturn(f func(*shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdRunCall {
+func (c_2 *MockExecutorExecCmdRunCall) DoAndReturn(f func(context.Context, *shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdRunCall {
 	c_2.Call = c_2.Call.DoAndReturn(f)
 	return c_2
 }
 
 // ExecCmdStart mocks base method.
-func (m *MockExecutor) ExecCmdStart(c *shellz.Command, cmd *exec.Cmd) error {
+func (m *MockExecutor) ExecCmdStart(ctx context.Context, c *shellz.Command, cmd *exec.Cmd) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecCmdStart", c, cmd)
+	ret := m.ctrl.Call(m, "ExecCmdStart", ctx, c, cmd)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ExecCmdStart indicates an expected call of ExecCmdStart.
-func (mr *MockExecutorMockRecorder) ExecCmdStart(c, cmd any) *MockExecutorExecCmdStartCall {
+func (mr *MockExecutorMockRecorder) ExecCmdStart(ctx, c, cmd any) *MockExecutorExecCmdStartCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdStart", reflect.TypeOf((*MockExecutor)(nil).ExecCmdStart), c, cmd)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdStart", reflect.TypeOf((*MockExecutor)(nil).ExecCmdStart), ctx, c, cmd)
 	return &MockExecutorExecCmdStartCall{Call: call}
 }
 
@@ -184,29 +185,29 @@ func (c_2 *MockExecutorExecCmdStartCall) Return(arg0 error) *MockExecutorExecCmd
 }
 
 // Do rewrite *gomock.Call.Do
-func (c_2 *MockExecutorExecCmdStartCall) Do(f func(*shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdStartCall {
+func (c_2 *MockExecutorExecCmdStartCall) Do(f func(context.Context, *shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdStartCall {
 	c_2.Call = c_2.Call.Do(f)
 	return c_2
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c_2 *MockExecutorExecCmdStartCall) DoAndReturn(f func(*shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdStartCall {
+func (c_2 *MockExecutorExecCmdStartCall) DoAndReturn(f func(context.Context, *shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdStartCall {
 	c_2.Call = c_2.Call.DoAndReturn(f)
 	return c_2
 }
 
 // ExecCmdWait mocks base method.
-func (m *MockExecutor) ExecCmdWait(c *shellz.Command, cmd *exec.Cmd) error {
+func (m *MockExecutor) ExecCmdWait(ctx context.Context, c *shellz.Command, cmd *exec.Cmd) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecCmdWait", c, cmd)
+	ret := m.ctrl.Call(m, "ExecCmdWait", ctx, c, cmd)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ExecCmdWait indicates an expected call of ExecCmdWait.
-func (mr *MockExecutorMockRecorder) ExecCmdWait(c, cmd any) *MockExecutorExecCmdWaitCall {
+func (mr *MockExecutorMockRecorder) ExecCmdWait(ctx, c, cmd any) *MockExecutorExecCmdWaitCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdWait", reflect.TypeOf((*MockExecutor)(nil).ExecCmdWait), c, cmd)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCmdWait", reflect.TypeOf((*MockExecutor)(nil).ExecCmdWait), ctx, c, cmd)
 	return &MockExecutorExecCmdWaitCall{Call: call}
 }
 
@@ -222,13 +223,13 @@ func (c_2 *MockExecutorExecCmdWaitCall) Return(arg0 error) *MockExecutorExecCmdW
 }
 
 // Do rewrite *gomock.Call.Do
-func (c_2 *MockExecutorExecCmdWaitCall) Do(f func(*shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdWaitCall {
+func (c_2 *MockExecutorExecCmdWaitCall) Do(f func(context.Context, *shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdWaitCall {
 	c_2.Call = c_2.Call.Do(f)
 	return c_2
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c_2 *MockExecutorExecCmdWaitCall) DoAndReturn(f func(*shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdWaitCall {
+func (c_2 *MockExecutorExecCmdWaitCall) DoAndReturn(f func(context.Context, *shellz.Command, *exec.Cmd) error) *MockExecutorExecCmdWaitCall {
 	c_2.Call = c_2.Call.DoAndReturn(f)
 	return c_2
 }
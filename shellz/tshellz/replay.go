@@ -0,0 +1,280 @@
+package tshellz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ibrt/golang-utils/errorz"
+
+	"github.com/ibrt/golang-dev/shellz"
+)
+
+var (
+	_ shellz.Executor          = (*ReplayExecutor)(nil)
+	_ shellz.SimulatedExecutor = (*ReplayExecutor)(nil)
+)
+
+// ReplayExecutor implements the [shellz.Executor] interface by serving the results of the interactions recorded in
+// a [*shellz.Cassette], in order. Commands that do not match the next recorded interaction fail with an error that
+// describes the differences. Exec-related methods are not supported.
+type ReplayExecutor struct {
+	m        *sync.Mutex
+	cassette *shellz.Cassette
+	next     int
+	pending  map[*exec.Cmd]*pendingReplay
+}
+
+type pendingReplay struct {
+	interaction *shellz.CassetteInteraction
+	done        chan struct{}
+}
+
+// NewReplayExecutor initializes a new [*ReplayExecutor].
+func NewReplayExecutor(cassette *shellz.Cassette) *ReplayExecutor {
+	return &ReplayExecutor{
+		m:        &sync.Mutex{},
+		cassette: cassette,
+		next:     0,
+		pending:  make(map[*exec.Cmd]*pendingReplay),
+	}
+}
+
+// MustLoadReplayExecutor initializes a new [*ReplayExecutor] from a cassette file.
+func MustLoadReplayExecutor(filePath string) *ReplayExecutor {
+	return NewReplayExecutor(shellz.MustLoadCassette(filePath))
+}
+
+// GetRemaining returns the interactions that have not been replayed yet.
+func (e *ReplayExecutor) GetRemaining() []*shellz.CassetteInteraction {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	return slices.Clone(e.cassette.Interactions[e.next:])
+}
+
+// CheckDone returns an error if some interactions have not been replayed yet.
+func (e *ReplayExecutor) CheckDone() error {
+	if remaining := e.GetRemaining(); len(remaining) > 0 {
+		lines := make([]string, 0, len(remaining))
+
+		for _, i := range remaining {
+			lines = append(lines, fmt.Sprintf("  %v: %v", i.Kind, formatCommand(i)))
+		}
+
+		return errorz.Errorf("%v interaction(s) not replayed:\n%v", len(remaining), strings.Join(lines, "\n"))
+	}
+
+	return nil
+}
+
+// IsSimulated implements the [shellz.SimulatedExecutor] interface.
+func (*ReplayExecutor) IsSimulated() bool {
+	return true
+}
+
+// ExecCmdCombinedOutput implements the [shellz.Executor] interface.
+func (e *ReplayExecutor) ExecCmdCombinedOutput(_ context.Context, c *shellz.Command, cmd *exec.Cmd) ([]byte, error) {
+	i, err := e.match(shellz.InteractionKindCombinedOutput, c, cmd)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	return []byte(i.Stdout), newReplayError(i, false)
+}
+
+// ExecCmdOutput implements the [shellz.Executor] interface.
+func (e *ReplayExecutor) ExecCmdOutput(_ context.Context, c *shellz.Command, cmd *exec.Cmd) ([]byte, error) {
+	i, err := e.match(shellz.InteractionKindOutput, c, cmd)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	if cmd.Stderr != nil {
+		_, _ = io.WriteString(cmd.Stderr, i.Stderr)
+	}
+
+	return []byte(i.Stdout), newReplayError(i, cmd.Stderr == nil)
+}
+
+// ExecCmdRun implements the [shellz.Executor] interface.
+func (e *ReplayExecutor) ExecCmdRun(_ context.Context, c *shellz.Command, cmd *exec.Cmd) error {
+	i, err := e.match(shellz.InteractionKindRun, c, cmd)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	writeOutput(cmd.Stdout, i.Stdout, false)
+	writeOutput(cmd.Stderr, i.Stderr, false)
+	return newReplayError(i, false)
+}
+
+// ExecCmdStart implements the [shellz.Executor] interface.
+// The recorded output is written asynchronously, then the output files (e.g. pipes) are closed.
+func (e *ReplayExecutor) ExecCmdStart(_ context.Context, c *shellz.Command, cmd *exec.Cmd) error {
+	i, err := e.match(shellz.InteractionKindStart, c, cmd)
+	if err != nil {
+		return errorz.Wrap(err)
+	}
+
+	p := &pendingReplay{
+		interaction: i,
+		done:        make(chan struct{}),
+	}
+
+	stdout, stderr := cmd.Stdout, cmd.Stderr
+
+	go func() {
+		defer close(p.done)
+		writeOutput(stdout, i.Stdout, true)
+		writeOutput(stderr, i.Stderr, true)
+	}()
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.pending[cmd] = p
+	return nil
+}
+
+// ExecCmdWait implements the [shellz.Executor] interface.
+func (e *ReplayExecutor) ExecCmdWait(_ context.Context, _ *shellz.Command, cmd *exec.Cmd) error {
+	e.m.Lock()
+	p, ok := e.pending[cmd]
+	delete(e.pending, cmd)
+	e.m.Unlock()
+
+	if !ok {
+		return errorz.Errorf("replay: wait called for a command that was not started")
+	}
+
+	<-p.done
+	return newReplayError(p.interaction, false)
+}
+
+// ExecLookPath implements the [shellz.Executor] interface.
+func (e *ReplayExecutor) ExecLookPath(_ *shellz.Command, file string) (string, error) {
+	return "", errorz.Errorf("replay: exec is not supported: %v", file)
+}
+
+// OSChdir implements the [shellz.Executor] interface.
+func (e *ReplayExecutor) OSChdir(_ *shellz.Command, dir string) error {
+	return errorz.Errorf("replay: exec is not supported: chdir %v", dir)
+}
+
+// SyscallExec implements the [shellz.Executor] interface.
+func (e *ReplayExecutor) SyscallExec(_ *shellz.Command, argv0 string, _ []string, _ []string) error {
+	return errorz.Errorf("replay: exec is not supported: %v", argv0)
+}
+
+func (e *ReplayExecutor) match(kind shellz.InteractionKind, c *shellz.Command, cmd *exec.Cmd) (*shellz.CassetteInteraction, error) {
+	actual, err := shellz.NewCassetteInteraction(kind, c, cmd)
+	if err != nil {
+		return nil, errorz.Wrap(err)
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if e.next >= len(e.cassette.Interactions) {
+		return nil, errorz.Errorf("replay: unexpected command (all %v interaction(s) already replayed): %v: %v",
+			len(e.cassette.Interactions), actual.Kind, formatCommand(actual))
+	}
+
+	expected := e.cassette.Interactions[e.next]
+
+	if diff := diffInteractions(expected, actual); len(diff) > 0 {
+		return nil, errorz.Errorf("replay: unexpected command (interaction %v/%v):\n%v",
+			e.next+1, len(e.cassette.Interactions), strings.Join(diff, "\n"))
+	}
+
+	e.next++
+	return expected, nil
+}
+
+func diffInteractions(expected, actual *shellz.CassetteInteraction) []string {
+	diff := make([]string, 0)
+
+	addDiff := func(field string, isEqual bool, expectedValue, actualValue any) {
+		if !isEqual {
+			diff = append(diff, fmt.Sprintf("  %v:\n    - expected: %q\n    + actual:   %q", field, expectedValue, actualValue))
+		}
+	}
+
+	addDiff("kind", expected.Kind == actual.Kind, expected.Kind, actual.Kind)
+	addDiff("command", expected.Command == actual.Command, expected.Command, actual.Command)
+	addDiff("params", slices.Equal(expected.Params, actual.Params), expected.Params, actual.Params)
+	addDiff("dir", expected.Dir == actual.Dir, expected.Dir, actual.Dir)
+	addDiff("env", isEnvEqual(expected.Env, actual.Env), formatEnv(expected.Env), formatEnv(actual.Env))
+	addDiff("env mode", expected.EnvMode == actual.EnvMode, expected.EnvMode, actual.EnvMode)
+	addDiff("env allowlist",
+		slices.Equal(expected.EnvAllowlist, actual.EnvAllowlist), expected.EnvAllowlist, actual.EnvAllowlist)
+	addDiff("unset env", slices.Equal(expected.UnsetEnv, actual.UnsetEnv), expected.UnsetEnv, actual.UnsetEnv)
+	addDiff("stdin (sha256)", expected.StdinSHA256 == actual.StdinSHA256, expected.StdinSHA256, actual.StdinSHA256)
+
+	return diff
+}
+
+func isEnvEqual(expected, actual map[string]string) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	for k, v := range expected {
+		if av, ok := actual[k]; !ok || av != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatEnv(env map[string]string) []string {
+	parts := make([]string, 0, len(env))
+
+	for k, v := range env {
+		parts = append(parts, fmt.Sprintf("%v=%v", k, v))
+	}
+
+	slices.Sort(parts)
+	return parts
+}
+
+func formatCommand(i *shellz.CassetteInteraction) string {
+	return strings.Join(append([]string{i.Command}, i.Params...), " ")
+}
+
+func newReplayError(i *shellz.CassetteInteraction, includeStderr bool) error {
+	if i.Error == "" {
+		return nil
+	}
+
+	if i.ExitCode != 0 {
+		var stderr []byte
+
+		if includeStderr {
+			stderr = []byte(i.Stderr)
+		}
+
+		return shellz.NewExitError(i.ExitCode, stderr)
+	}
+
+	return errorz.Errorf("%v", i.Error)
+}
+
+// writeOutput writes the recorded output to "w", optionally closing it if it is a file other than the standard ones.
+func writeOutput(w io.Writer, out string, closeFile bool) {
+	if w == nil {
+		return
+	}
+
+	_, _ = io.WriteString(w, out)
+
+	if f, ok := w.(*os.File); ok && closeFile && f != os.Stdout && f != os.Stderr {
+		_ = f.Close()
+	}
+}
@@ -0,0 +1,90 @@
+package tshellz_test
+
+import (
+	"testing"
+
+	"github.com/ibrt/golang-utils/fixturez"
+	. "github.com/onsi/gomega"
+
+	"github.com/ibrt/golang-dev/shellz"
+	"github.com/ibrt/golang-dev/shellz/tshellz"
+)
+
+type ReplaySuite struct {
+	// intentionally empty
+}
+
+func TestReplaySuite(t *testing.T) {
+	fixturez.RunSuite(t, &ReplaySuite{})
+}
+
+func (*ReplaySuite) TestReplayExecutor(g *WithT) {
+	e := tshellz.NewReplayExecutor(&shellz.Cassette{
+		Interactions: []*shellz.CassetteInteraction{
+			{
+				Kind:    shellz.InteractionKindOutput,
+				Command: "go",
+				Params:  []string{"list", "./..."},
+				Stdout:  "pkg\n",
+			},
+			{
+				Kind:     shellz.InteractionKindRun,
+				Command:  "git",
+				Params:   []string{"status"},
+				ExitCode: 128,
+				Error:    "exit status 128",
+			},
+		},
+	})
+
+	g.Expect(e.CheckDone()).To(MatchError("2 interaction(s) not replayed:\n  Output: go list ./...\n  Run: git status"))
+
+	g.Expect(shellz.NewCommand("go", "list", "./...").
+		SetExecutor(e).
+		OutputString(false)).To(Equal("pkg\n"))
+
+	err := shellz.NewCommand("git", "status").SetEcho(false).SetExecutor(e).Run()
+	g.Expect(err).To(MatchError("execution error: exit status 128"))
+
+	g.Expect(e.CheckDone()).To(Succeed())
+	g.Expect(e.GetRemaining()).To(BeEmpty())
+
+	err = shellz.NewCommand("git", "status").SetEcho(false).SetExecutor(e).Run()
+	g.Expect(err).To(MatchError("execution error: replay: unexpected command (all 2 interaction(s) already replayed): Run: git status"))
+}
+
+func (*ReplaySuite) TestReplayExecutor_Mismatch(g *WithT) {
+	e := tshellz.NewReplayExecutor(&shellz.Cassette{
+		Interactions: []*shellz.CassetteInteraction{
+			{
+				Kind:    shellz.InteractionKindOutput,
+				Command: "go",
+				Params:  []string{"list", "./..."},
+				Env:     map[string]string{"K": "V"},
+				EnvMode: "clean",
+			},
+		},
+	})
+
+	_, err := shellz.NewCommand("go", "list", "-m").
+		SetDir("dir").
+		SetEnvMode(shellz.EnvModeAllowlist, "PATH").
+		UnsetEnv("U").
+		SetExecutor(e).
+		Output(false)
+	g.Expect(err).To(MatchError("execution error: replay: unexpected command (interaction 1/1):\n" +
+		"  params:\n    - expected: [\"list\" \"./...\"]\n    + actual:   [\"list\" \"-m\"]\n" +
+		"  dir:\n    - expected: \"\"\n    + actual:   \"dir\"\n" +
+		"  env:\n    - expected: [\"K=V\"]\n    + actual:   []\n" +
+		"  env mode:\n    - expected: \"clean\"\n    + actual:   \"allowlist\"\n" +
+		"  env allowlist:\n    - expected: []\n    + actual:   [\"PATH\"]\n" +
+		"  unset env:\n    - expected: []\n    + actual:   [\"U\"]"))
+
+	g.Expect(e.GetRemaining()).To(HaveLen(1))
+}
+
+func (*ReplaySuite) TestReplayExecutor_Exec(g *WithT) {
+	e := tshellz.NewReplayExecutor(&shellz.Cassette{})
+	g.Expect(shellz.NewCommand("go").SetEcho(false).SetExecutor(e).Exec()).
+		To(MatchError("execution error: replay: exec is not supported: go"))
+}